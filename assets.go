@@ -0,0 +1,319 @@
+package gofre
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/response"
+	"github.com/ixtendio/gofre/router/path"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	pathpkg "path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	headerCacheControl   = "Cache-Control"
+	headerAcceptEncoding = "Accept-Encoding"
+	headerVary           = "Vary"
+	indexFileName        = "index.html"
+)
+
+// the precompressed variants, in the order of preference
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{encoding: "br", extension: ".br"},
+	{encoding: "gzip", extension: ".gz"},
+}
+
+// assetFileInfo holds the details computed for an asset file
+type assetFileInfo struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// assetsHandler serves the static resources from a fs.FS
+type assetsHandler struct {
+	fsys         fs.FS
+	urlPrefix    string
+	cacheControl string
+	dirListing   bool
+	fileServer   http.Handler
+	// the fingerprinted paths (fingerprinted path -> original path)
+	fingerprinted map[string]string
+	// the etag cache (file path -> *assetFileInfo)
+	filesInfo sync.Map
+}
+
+func newAssetsHandler(fsys fs.FS, urlPrefix string, cacheControl string, dirListing bool) *assetsHandler {
+	h := &assetsHandler{
+		fsys:         fsys,
+		urlPrefix:    urlPrefix,
+		cacheControl: cacheControl,
+		dirListing:   dirListing,
+	}
+	if dirListing {
+		h.fileServer = http.StripPrefix(urlPrefix, http.FileServer(http.FS(fsys)))
+	}
+	return h
+}
+
+// assetsFS returns the fs.FS from where the static resources are served
+func (c *ResourcesConfig) assetsFS() (fs.FS, error) {
+	if c.FS == nil {
+		return os.DirFS(c.AssetsDirPath), nil
+	}
+	dir := pathpkg.Clean(strings.TrimPrefix(c.AssetsDirPath, "./"))
+	if dir == "." || dir == "/" {
+		return c.FS, nil
+	}
+	return fs.Sub(c.FS, strings.TrimPrefix(dir, "/"))
+}
+
+func (h *assetsHandler) handle(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+	name := strings.TrimPrefix(mc.R.URL.Path, h.urlPrefix)
+	name = strings.TrimPrefix(pathpkg.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return notFoundResponse(), nil
+	}
+	if original, ok := h.fingerprinted[name]; ok {
+		// the content of a fingerprinted path never changes
+		if info, err := fs.Stat(h.fsys, original); err == nil && !info.IsDir() {
+			return h.fileResponse(mc.R, original, info, immutableAssetsCacheControl)
+		}
+		return notFoundResponse(), nil
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if err != nil {
+		return notFoundResponse(), nil
+	}
+	if info.IsDir() {
+		indexName := pathpkg.Join(name, indexFileName)
+		if indexInfo, err := fs.Stat(h.fsys, indexName); err == nil && !indexInfo.IsDir() {
+			name, info = indexName, indexInfo
+		} else if h.dirListing {
+			return response.HandlerAdaptor(h.fileServer), nil
+		} else {
+			return notFoundResponse(), nil
+		}
+	}
+	return h.fileResponse(mc.R, name, info, h.cacheControl)
+}
+
+// fileResponse returns the response that serves the file or, if the client accepts it, its precompressed variant
+func (h *assetsHandler) fileResponse(req *http.Request, name string, info fs.FileInfo, cacheControl string) (response.HttpResponse, error) {
+	headers := response.NewHttpHeaders()
+	if len(cacheControl) > 0 {
+		headers.Set(headerCacheControl, cacheControl)
+	}
+
+	contentType := mime.TypeByExtension(pathpkg.Ext(name))
+	acceptEncoding := req.Header.Get(headerAcceptEncoding)
+	servedName := name
+	for _, pe := range precompressedEncodings {
+		if _, err := fs.Stat(h.fsys, name+pe.extension); err != nil {
+			continue
+		}
+		// the response depends on the Accept-Encoding header as long as a precompressed variant exists
+		headers.Set(headerVary, headerAcceptEncoding)
+		if servedName == name && acceptsEncoding(acceptEncoding, pe.encoding) {
+			servedName = name + pe.extension
+			headers.Set("Content-Encoding", pe.encoding)
+			if len(contentType) == 0 {
+				contentType = "application/octet-stream"
+			}
+		}
+	}
+	if len(contentType) > 0 {
+		headers.Set(response.HeaderContentType, contentType)
+	}
+
+	file, err := h.fsys.Open(servedName)
+	if err != nil {
+		headers.Release()
+		return nil, fmt.Errorf("failed to open the static resource: %s, err: %w", servedName, err)
+	}
+	if servedName != name {
+		if info, err = file.Stat(); err != nil {
+			file.Close()
+			headers.Release()
+			return nil, fmt.Errorf("failed to read the static resource info: %s, err: %w", servedName, err)
+		}
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		file.Close()
+		headers.Release()
+		return nil, errors.New("the static resource: " + servedName + " does not implement io.Seeker")
+	}
+	etag, err := h.etag(servedName, info, content)
+	if err != nil {
+		file.Close()
+		headers.Release()
+		return nil, err
+	}
+
+	resp := response.ContentHttpResponseWithHeadersAndCookies(pathpkg.Base(name), info.ModTime(), content, headers, nil)
+	resp.ETag = etag
+	return resp, nil
+}
+
+// etag returns the strong entity tag of a file, computed from its content. The value is cached as long as the file size and modification time don't change
+func (h *assetsHandler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if cached, ok := h.filesInfo.Load(name); ok {
+		fi := cached.(*assetFileInfo)
+		if fi.size == info.Size() && fi.modTime.Equal(info.ModTime()) {
+			return fi.etag, nil
+		}
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", fmt.Errorf("failed to compute the etag for the static resource: %s, err: %w", name, err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to compute the etag for the static resource: %s, err: %w", name, err)
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	h.filesInfo.Store(name, &assetFileInfo{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		etag:    etag,
+	})
+	return etag, nil
+}
+
+// acceptsEncoding returns true if the Accept-Encoding header value accepts the encoding with a quality greater than zero
+func acceptsEncoding(acceptEncoding string, encoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		enc := strings.TrimSpace(params[0])
+		if strings.EqualFold(enc, encoding) || enc == "*" {
+			return qualityValue(params[1:]) > 0
+		}
+	}
+	return false
+}
+
+// spaHandler serves the single-page-application index file for the unmatched requests
+type spaHandler struct {
+	assets           *assetsHandler
+	contextPath      string
+	pathPrefix       string
+	indexFile        string
+	excludedPrefixes []string
+	// the handler called for the requests that are not client side routes. If nil, a plain 404 response is returned
+	notFound handler.Handler
+}
+
+func newSPAHandler(assets *assetsHandler, contextPath string, config SPAConfig) *spaHandler {
+	return &spaHandler{
+		assets:           assets,
+		contextPath:      contextPath,
+		pathPrefix:       config.PathPrefix,
+		indexFile:        strings.TrimPrefix(pathpkg.Clean("/"+config.IndexFile), "/"),
+		excludedPrefixes: config.ExcludedPathPrefixes,
+	}
+}
+
+func (h *spaHandler) handle(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+	if !h.isClientSideRoute(mc.R) {
+		if h.notFound != nil {
+			return h.notFound(ctx, mc)
+		}
+		return notFoundResponse(), nil
+	}
+	info, err := fs.Stat(h.assets.fsys, h.indexFile)
+	if err != nil || info.IsDir() {
+		return notFoundResponse(), nil
+	}
+	// the index file should always be revalidated, so that the clients will get the new application versions
+	return h.assets.fileResponse(mc.R, h.indexFile, info, "no-cache")
+}
+
+func (h *spaHandler) isClientSideRoute(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	urlPath := req.URL.Path
+	if len(h.contextPath) > 0 {
+		if !hasPathPrefix(urlPath, h.contextPath) {
+			return false
+		}
+		urlPath = urlPath[len(h.contextPath):]
+		if urlPath == "" {
+			urlPath = "/"
+		}
+	}
+	if !hasPathPrefix(urlPath, h.pathPrefix) {
+		return false
+	}
+	for _, excludedPrefix := range h.excludedPrefixes {
+		if hasPathPrefix(urlPath, excludedPrefix) {
+			return false
+		}
+	}
+	if len(pathpkg.Ext(urlPath)) > 0 {
+		return false
+	}
+	return acceptsHTML(req.Header.Get(response.HeaderAccept))
+}
+
+// hasPathPrefix returns true if the prefix matches the url path on a segment boundary
+func hasPathPrefix(urlPath string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if len(prefix) == 0 {
+		return true
+	}
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")
+}
+
+// acceptsHTML returns true if the Accept header value explicitly accepts an HTML document (the wildcards are ignored)
+func acceptsHTML(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			continue
+		}
+		if qualityValue(params[1:]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// qualityValue returns the value of the q parameter or 1 if the parameter is missing
+func qualityValue(params []string) float64 {
+	for _, param := range params {
+		name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found && strings.EqualFold(strings.TrimSpace(name), "q") {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				return q
+			}
+		}
+	}
+	return 1
+}
+
+func notFoundResponse() response.HttpResponse {
+	return response.PlainTextHttpResponse(http.StatusNotFound, "404 page not found")
+}
@@ -0,0 +1,288 @@
+package gofre
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/response"
+	"github.com/ixtendio/gofre/router/path"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newAssetsTestMuxHandler(t *testing.T, dirListing bool) *MuxHandler {
+	fsys := fstest.MapFS{
+		"web/templates/index.html":      &fstest.MapFile{Data: []byte(`{{define "index.html"}}hello {{.}}{{end}}`)},
+		"web/assets/app.js":             &fstest.MapFile{Data: []byte("console.log('app')")},
+		"web/assets/app.js.gz":          &fstest.MapFile{Data: []byte("gzip-content")},
+		"web/assets/app.js.br":          &fstest.MapFile{Data: []byte("br-content")},
+		"web/assets/style.css":          &fstest.MapFile{Data: []byte("body{}")},
+		"web/assets/docs/index.html":    &fstest.MapFile{Data: []byte("<h1>docs</h1>")},
+		"web/assets/images/logo.svg":    &fstest.MapFile{Data: []byte("<svg></svg>")},
+		"web/secret.txt":                &fstest.MapFile{Data: []byte("secret")},
+		"web/assets/images/favicon.ico": &fstest.MapFile{Data: []byte("ico")},
+	}
+	m, err := NewMuxHandler(&Config{
+		ResourcesConfig: &ResourcesConfig{
+			FS:                   fsys,
+			TemplatesPathPattern: "web/templates/*.html",
+			AssetsDirPath:        "./web/assets",
+			AssetsCacheControl:   "public, max-age=60",
+			AssetsDirListing:     dirListing,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestMuxHandler_ServeAssetsFromFS(t *testing.T) {
+	m := newAssetsTestMuxHandler(t, false)
+	type args struct {
+		path    string
+		headers http.Header
+	}
+	type want struct {
+		statusCode      int
+		body            string
+		contentType     string
+		contentEncoding string
+		vary            string
+		cacheControl    string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "identity",
+			args: args{path: "/assets/app.js"},
+			want: want{statusCode: http.StatusOK, body: "console.log('app')", contentType: "text/javascript; charset=utf-8", vary: "Accept-Encoding", cacheControl: "public, max-age=60"},
+		},
+		{
+			name: "brotli is preferred",
+			args: args{path: "/assets/app.js", headers: http.Header{"Accept-Encoding": {"gzip, deflate, br"}}},
+			want: want{statusCode: http.StatusOK, body: "br-content", contentType: "text/javascript; charset=utf-8", contentEncoding: "br", vary: "Accept-Encoding", cacheControl: "public, max-age=60"},
+		},
+		{
+			name: "gzip when brotli is not accepted",
+			args: args{path: "/assets/app.js", headers: http.Header{"Accept-Encoding": {"gzip, br;q=0"}}},
+			want: want{statusCode: http.StatusOK, body: "gzip-content", contentType: "text/javascript; charset=utf-8", contentEncoding: "gzip", vary: "Accept-Encoding", cacheControl: "public, max-age=60"},
+		},
+		{
+			name: "no precompressed variant",
+			args: args{path: "/assets/style.css", headers: http.Header{"Accept-Encoding": {"gzip"}}},
+			want: want{statusCode: http.StatusOK, body: "body{}", contentType: "text/css; charset=utf-8", cacheControl: "public, max-age=60"},
+		},
+		{
+			name: "directory with index.html",
+			args: args{path: "/assets/docs/"},
+			want: want{statusCode: http.StatusOK, body: "<h1>docs</h1>", contentType: "text/html; charset=utf-8", cacheControl: "public, max-age=60"},
+		},
+		{
+			name: "directory listing is disabled",
+			args: args{path: "/assets/images/"},
+			want: want{statusCode: http.StatusNotFound, body: "404 page not found", contentType: "text/plain; charset=utf-8"},
+		},
+		{
+			name: "file not found",
+			args: args{path: "/assets/missing.js"},
+			want: want{statusCode: http.StatusNotFound, body: "404 page not found", contentType: "text/plain; charset=utf-8"},
+		},
+		{
+			name: "files outside the assets dir are not served",
+			args: args{path: "/assets/../secret.txt"},
+			want: want{statusCode: http.StatusNotFound},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tt.args.path
+			for k, v := range tt.args.headers {
+				req.Header[k] = v
+			}
+			recorder := httptest.NewRecorder()
+			m.ServeHTTP(recorder, req)
+			got := want{
+				statusCode:      recorder.Code,
+				body:            recorder.Body.String(),
+				contentType:     recorder.Header().Get("Content-Type"),
+				contentEncoding: recorder.Header().Get("Content-Encoding"),
+				vary:            recorder.Header().Get("Vary"),
+				cacheControl:    recorder.Header().Get("Cache-Control"),
+			}
+			if got != tt.want {
+				t.Errorf("ServeHTTP() got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMuxHandler_ServeAssetsETag(t *testing.T) {
+	m := newAssetsTestMuxHandler(t, false)
+	req := httptest.NewRequest(http.MethodGet, "/assets/style.css", nil)
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, req)
+	etag := recorder.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || len(etag) != 34 {
+		t.Fatalf("ServeHTTP() ETag = %q, want a strong ETag", etag)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	recorder = httptest.NewRecorder()
+	m.ServeHTTP(recorder, req)
+	if gzipETag := recorder.Header().Get("ETag"); gzipETag == etag || len(gzipETag) == 0 {
+		t.Fatalf("ServeHTTP() gzip variant ETag = %q, want a different ETag", gzipETag)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/assets/style.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	m.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusNotModified {
+		t.Errorf("ServeHTTP() with If-None-Match status code = %v, want %v", recorder.Code, http.StatusNotModified)
+	}
+}
+
+func TestMuxHandler_ServeAssetsDirListing(t *testing.T) {
+	m := newAssetsTestMuxHandler(t, true)
+	req := httptest.NewRequest(http.MethodGet, "/assets/images/", nil)
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "logo.svg") {
+		t.Errorf("ServeHTTP() got: %d %s, want the directory listing", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestMuxHandler_TemplatesFromFS(t *testing.T) {
+	m := newAssetsTestMuxHandler(t, false)
+	var sb strings.Builder
+	if err := m.ExecutableTemplate().ExecuteTemplate(&sb, "index.html", "world"); err != nil {
+		t.Fatalf("ExecuteTemplate() unexpected error: %v", err)
+	}
+	if sb.String() != "hello world" {
+		t.Errorf("ExecuteTemplate() got: %q, want: %q", sb.String(), "hello world")
+	}
+}
+
+func TestMuxHandler_SPAFallback(t *testing.T) {
+	fsys := fstest.MapFS{
+		"assets/index.html": &fstest.MapFile{Data: []byte("<div id=app></div>")},
+		"assets/app.js":     &fstest.MapFile{Data: []byte("app()")},
+	}
+	m, err := NewMuxHandler(&Config{
+		ContextPath: "/ctx",
+		ResourcesConfig: &ResourcesConfig{
+			FS:                 fsys,
+			AssetsDirPath:      "assets",
+			AssetsCacheControl: "public, max-age=3600",
+			Template:           response.NilTemplate{},
+			SPA: &SPAConfig{
+				PathPrefix:           "/app",
+				ExcludedPathPrefixes: []string{"/app/api"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+	m.HandleGet("/ctx/app/api/users", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.JsonHttpResponseOK([]string{"john"}), nil
+	})
+	const htmlAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		accept           string
+		wantStatusCode   int
+		wantBody         string
+		wantCacheControl string
+	}{
+		{name: "client side route", path: "/ctx/app/settings/profile", accept: htmlAccept, wantStatusCode: http.StatusOK, wantBody: "<div id=app></div>", wantCacheControl: "no-cache"},
+		{name: "the prefix itself", path: "/ctx/app", accept: htmlAccept, wantStatusCode: http.StatusOK, wantBody: "<div id=app></div>", wantCacheControl: "no-cache"},
+		{name: "the registered routes are still matched", path: "/ctx/app/api/users", accept: htmlAccept, wantStatusCode: http.StatusOK, wantBody: `["john"]`},
+		{name: "the assets are still served", path: "/ctx/assets/app.js", accept: "*/*", wantStatusCode: http.StatusOK, wantBody: "app()", wantCacheControl: "public, max-age=3600"},
+		{name: "missing file", path: "/ctx/app/main.js", accept: htmlAccept, wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+		{name: "html is not explicitly accepted", path: "/ctx/app/settings", accept: "*/*", wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+		{name: "html is not acceptable", path: "/ctx/app/settings", accept: "text/html;q=0", wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+		{name: "excluded prefix", path: "/ctx/app/api/unknown", accept: htmlAccept, wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+		{name: "outside the prefix", path: "/ctx/other", accept: htmlAccept, wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+		{name: "outside the context path", path: "/app/settings", accept: htmlAccept, wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+		{name: "not a GET request", method: http.MethodPost, path: "/ctx/app/settings", accept: htmlAccept, wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			recorder := httptest.NewRecorder()
+			m.ServeHTTP(recorder, req)
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ServeHTTP() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("ServeHTTP() Cache-Control got: %q, want: %q", got, tt.wantCacheControl)
+			}
+		})
+	}
+}
+
+func TestMuxHandler_TemplatesLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"web/pages/_layout.html":    &fstest.MapFile{Data: []byte(`<script src="{{asset "app.js"}}"></script>{{block "content" .}}{{end}}`)},
+		"web/pages/index.html":      &fstest.MapFile{Data: []byte(`{{define "content"}}{{shout .}}{{end}}`)},
+		"web/partials/footer.html":  &fstest.MapFile{Data: []byte(`{{define "footer"}}{{safe "<footer></footer>"}}{{end}}`)},
+		"web/assets/app.js":         &fstest.MapFile{Data: []byte("app()")},
+		"web/templates/ignore.html": &fstest.MapFile{Data: []byte(`{{`)},
+	}
+	m, err := NewMuxHandler(&Config{
+		ResourcesConfig: &ResourcesConfig{
+			FS:                   fsys,
+			TemplatesPathPattern: "web/templates/*.html",
+			AssetsDirPath:        "web/assets",
+			TemplatesLayout: &response.LayoutTemplateOptions{
+				PagesDir:     "web/pages",
+				PartialsDirs: []string{"web/partials"},
+				Funcs:        template.FuncMap{"shout": strings.ToUpper},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+	m.HandleGet("/", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.TemplateHttpResponseOK(m.ExecutableTemplate(), "index.html", "home"), nil
+	})
+	m.HandleGet("/footer", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.TemplateHttpResponseOK(m.ExecutableTemplate(), "footer", nil), nil
+	})
+	tests := []struct {
+		path     string
+		wantBody string
+	}{
+		{path: "/", wantBody: `<script src="/assets/app.js"></script>HOME`},
+		{path: "/footer", wantBody: `<footer></footer>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != http.StatusOK || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ServeHTTP() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), http.StatusOK, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Errorf("ServeHTTP() Content-Type got: %q", got)
+			}
+		})
+	}
+}
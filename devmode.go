@@ -0,0 +1,279 @@
+package gofre
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/response"
+	"github.com/ixtendio/gofre/router/path"
+	"hash/fnv"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDevModePollInterval   = time.Second
+	defaultDevModeLiveReloadPath = "/_gofre/livereload"
+	liveReloadEventName          = "reload"
+	htmlContentType              = "text/html; charset=utf-8"
+)
+
+// DevModeConfig contains the settings for the development mode.
+// The files are checked for changes by polling, at most once per PollInterval, when a template is rendered or while a page
+// is connected to the live reload endpoint, so no background goroutine or file system notification support is required
+type DevModeConfig struct {
+	//the minimum time between two checks for changed files. Default: 1 second
+	PollInterval time.Duration
+	//if true, the pages that include the {{liveReload}} template function are refreshed when the templates or the static resources change. Default: false
+	LiveReload bool
+	//the web path (relative to the context path) of the live reload server-sent events endpoint. Default: "/_gofre/livereload"
+	LiveReloadPath string
+}
+
+func (c *DevModeConfig) setDefaults() {
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultDevModePollInterval
+	}
+	if c.LiveReloadPath == "" {
+		c.LiveReloadPath = defaultDevModeLiveReloadPath
+	}
+}
+
+// watchedDir is a directory checked for changes
+type watchedDir struct {
+	fsys fs.FS
+	dir  string
+}
+
+// devReloader implements response.ExecutableTemplate and parses the templates again when the files change
+type devReloader struct {
+	config       *ResourcesConfig
+	pollInterval time.Duration
+	templateDirs []watchedDir
+	assetsDirs   []watchedDir
+
+	mu                sync.Mutex
+	lastCheck         time.Time
+	templatesSnapshot uint64
+	assetsSnapshot    uint64
+	// incremented each time a template or a static resource changes
+	version  uint64
+	template response.ExecutableTemplate
+	binder   *templateBinder
+	parseErr error
+}
+
+func newDevReloader(config *ResourcesConfig) *devReloader {
+	r := &devReloader{
+		config:       config,
+		pollInterval: config.DevMode.PollInterval,
+		templateDirs: config.templateDirs(),
+	}
+	if assetsFS, err := config.assetsFS(); err == nil {
+		r.assetsDirs = []watchedDir{{fsys: assetsFS, dir: "."}}
+	}
+	r.templatesSnapshot = snapshot(r.templateDirs)
+	r.assetsSnapshot = snapshot(r.assetsDirs)
+	r.parse()
+	r.lastCheck = time.Now()
+	return r
+}
+
+// templateDirs returns the directories that contain the templates
+func (c *ResourcesConfig) templateDirs() []watchedDir {
+	fsys := c.FS
+	if c.TemplatesLayout != nil {
+		if fsys == nil {
+			fsys = os.DirFS(".")
+		}
+		dirs := []watchedDir{{fsys: fsys, dir: c.TemplatesLayout.PagesDir}}
+		for _, dir := range c.TemplatesLayout.PartialsDirs {
+			dirs = append(dirs, watchedDir{fsys: fsys, dir: dir})
+		}
+		for i := range dirs {
+			if dirs[i].dir == "" {
+				dirs[i].dir = "pages"
+			}
+			dirs[i].dir = strings.TrimPrefix(pathpkg.Clean("/"+strings.TrimPrefix(dirs[i].dir, "./")), "/")
+		}
+		return dirs
+	}
+	if fsys != nil {
+		return []watchedDir{{fsys: fsys, dir: pathpkg.Dir(strings.TrimPrefix(c.TemplatesPathPattern, "./"))}}
+	}
+	return []watchedDir{{fsys: os.DirFS(filepath.Dir(c.TemplatesPathPattern)), dir: "."}}
+}
+
+// snapshot returns a hash of the names, sizes and modification times of all the files from the directories
+func snapshot(dirs []watchedDir) uint64 {
+	hash := fnv.New64a()
+	for _, wd := range dirs {
+		dir := wd.dir
+		if dir == "" {
+			dir = "."
+		}
+		_ = fs.WalkDir(wd.fsys, dir, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				io.WriteString(hash, name+":"+err.Error()+"\n")
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			io.WriteString(hash, name+":"+strconv.FormatInt(info.Size(), 10)+":"+strconv.FormatInt(info.ModTime().UnixNano(), 10)+"\n")
+			return nil
+		})
+	}
+	return hash.Sum64()
+}
+
+// refresh checks the files for changes, if the poll interval elapsed, and returns the current version
+func (r *devReloader) refresh() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if now.Sub(r.lastCheck) < r.pollInterval {
+		return r.version
+	}
+	r.lastCheck = now
+	if templatesSnapshot := snapshot(r.templateDirs); templatesSnapshot != r.templatesSnapshot {
+		r.templatesSnapshot = templatesSnapshot
+		r.parse()
+		r.version++
+	}
+	if assetsSnapshot := snapshot(r.assetsDirs); assetsSnapshot != r.assetsSnapshot {
+		r.assetsSnapshot = assetsSnapshot
+		r.version++
+	}
+	return r.version
+}
+
+// parse parses the templates and creates their binder
+func (r *devReloader) parse() {
+	r.template, r.parseErr = r.config.parseTemplate()
+	r.binder = nil
+	if r.parseErr == nil {
+		r.binder, r.parseErr = newTemplateBinder(r.config, r.template)
+	}
+}
+
+func (r *devReloader) current() (response.ExecutableTemplate, error) {
+	tmpl, _, err := r.currentWithBinder()
+	return tmpl, err
+}
+
+func (r *devReloader) currentWithBinder() (response.ExecutableTemplate, *templateBinder, error) {
+	r.refresh()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.template, r.binder, r.parseErr
+}
+
+func (r *devReloader) Execute(wr io.Writer, data any) error {
+	tmpl, err := r.current()
+	if err != nil {
+		return r.writeErrorPage(wr, err)
+	}
+	return tmpl.Execute(wr, data)
+}
+
+func (r *devReloader) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	tmpl, err := r.current()
+	if err != nil {
+		return r.writeErrorPage(wr, err)
+	}
+	return tmpl.ExecuteTemplate(wr, name, data)
+}
+
+func (r *devReloader) ExecuteFragment(wr io.Writer, pageName string, fragmentName string, data any) error {
+	tmpl, err := r.current()
+	if err != nil {
+		return r.writeErrorPage(wr, err)
+	}
+	if ft, ok := tmpl.(response.FragmentTemplate); ok {
+		return ft.ExecuteFragment(wr, pageName, fragmentName, data)
+	}
+	return tmpl.ExecuteTemplate(wr, fragmentName, data)
+}
+
+// WithContext binds the request scoped functions to the current templates or, if they can not be parsed, returns the
+// reloader itself, so that the parse error is rendered
+func (r *devReloader) WithContext(ctx context.Context, mc path.MatchingContext) (response.ExecutableTemplate, func(), error) {
+	tmpl, binder, err := r.currentWithBinder()
+	if err != nil {
+		return r, func() {}, nil
+	}
+	if binder == nil {
+		return tmpl, func() {}, nil
+	}
+	return binder.WithContext(ctx, mc)
+}
+
+// ContentType returns the HTML content type, because the templates are always parsed with html/template
+func (r *devReloader) ContentType() string {
+	return htmlContentType
+}
+
+// writeErrorPage renders the parse error in the browser and returns it as a response.RenderError, so that the page is
+// written by the buffered responses too, and the error will be logged
+func (r *devReloader) writeErrorPage(wr io.Writer, parseErr error) error {
+	page := `<!DOCTYPE html><html><head><meta charset="utf-8"><title>Template error</title></head><body>` +
+		`<h1>Template error</h1><pre>` + template.HTMLEscapeString(parseErr.Error()) + `</pre>` +
+		string(r.config.liveReloadScript()) + `</body></html>`
+	if _, err := io.WriteString(wr, page); err != nil {
+		return err
+	}
+	return &response.RenderError{Err: parseErr, ContentType: htmlContentType, Page: []byte(page)}
+}
+
+// handleLiveReload streams a reload event when the templates or the static resources change
+func (r *devReloader) handleLiveReload(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+	resp := response.SSEHttpResponse(func(ctx context.Context, lastEventId string) <-chan response.ServerSentEvent {
+		ch := make(chan response.ServerSentEvent)
+		go func() {
+			defer close(ch)
+			version := r.refresh()
+			ticker := time.NewTicker(r.pollInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				if r.refresh() == version {
+					continue
+				}
+				select {
+				case ch <- response.ServerSentEvent{Name: liveReloadEventName, Data: []string{"changed"}}:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}()
+		return ch
+	})
+	resp.AllowHTTP1 = true
+	return resp, nil
+}
+
+// liveReloadScript returns the script that refreshes the page when the live reload endpoint sends a reload event or
+// when it reconnects after the server was restarted. It is empty if the live reload is not enabled
+func (c *ResourcesConfig) liveReloadScript() template.HTML {
+	if c.DevMode == nil || !c.DevMode.LiveReload || len(c.liveReloadUrl) == 0 {
+		return ""
+	}
+	return template.HTML(`<script>(function(){var lost=false,es=new EventSource("` + template.JSEscapeString(c.liveReloadUrl) + `");` +
+		`es.addEventListener("` + liveReloadEventName + `",function(){es.close();location.reload()});` +
+		`es.onerror=function(){lost=true};es.onopen=function(){if(lost){location.reload()}}})();</script>`)
+}
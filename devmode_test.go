@@ -0,0 +1,131 @@
+package gofre
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/response"
+	"github.com/ixtendio/gofre/router/path"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeDevModeTestFile(t *testing.T, name string, content string, modTime time.Time) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newDevModeTestMuxHandler(t *testing.T, dir string) *MuxHandler {
+	m, err := NewMuxHandler(&Config{
+		ContextPath: "/ctx",
+		ResourcesConfig: &ResourcesConfig{
+			FS:                   os.DirFS(dir),
+			TemplatesPathPattern: "templates/*.html",
+			AssetsDirPath:        "assets",
+			DevMode: &DevModeConfig{
+				PollInterval: time.Millisecond,
+				LiveReload:   true,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+	m.HandleGet("/ctx/", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.TemplateHttpResponseOK(m.ExecutableTemplate(), "index.html", "dev"), nil
+	})
+	return m
+}
+
+func TestMuxHandler_DevModeTemplatesReload(t *testing.T) {
+	dir := t.TempDir()
+	templateFile := filepath.Join(dir, "templates", "index.html")
+	modTime := time.Now().Add(-time.Hour)
+	writeDevModeTestFile(t, templateFile, `v1 {{.}}{{liveReload}}`, modTime)
+	m := newDevModeTestMuxHandler(t, dir)
+
+	const script = `<script>(function(){var lost=false,es=new EventSource("/ctx/_gofre/livereload");`
+	steps := []struct {
+		name            string
+		content         string
+		wantStatusCode  int
+		wantBodyPrefix  string
+		wantBodyContain string
+	}{
+		{name: "initial templates", wantStatusCode: http.StatusOK, wantBodyPrefix: "v1 dev" + script},
+		{name: "changed template", content: `v2 {{.}}`, wantStatusCode: http.StatusOK, wantBodyPrefix: "v2 dev"},
+		{name: "parse error", content: `v3 {{.}`, wantStatusCode: http.StatusInternalServerError, wantBodyPrefix: "<!DOCTYPE html>", wantBodyContain: "index.html:1: bad character U+007D &#39;}&#39;"},
+		{name: "fixed template", content: `v4 {{.}}`, wantStatusCode: http.StatusOK, wantBodyPrefix: "v4 dev"},
+	}
+	for i, step := range steps {
+		if len(step.content) > 0 {
+			writeDevModeTestFile(t, templateFile, step.content, modTime.Add(time.Duration(i)*time.Second))
+			time.Sleep(2 * time.Millisecond)
+		}
+		recorder := httptest.NewRecorder()
+		m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ctx/", nil))
+		body := recorder.Body.String()
+		if recorder.Code != step.wantStatusCode {
+			t.Errorf("%s: ServeHTTP() got status code: %d, want: %d", step.name, recorder.Code, step.wantStatusCode)
+		}
+		if !strings.HasPrefix(body, step.wantBodyPrefix) || !strings.Contains(body, step.wantBodyContain) {
+			t.Errorf("%s: ServeHTTP() got body: %q, want prefix: %q, containing: %q", step.name, body, step.wantBodyPrefix, step.wantBodyContain)
+		}
+		if got := recorder.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+			t.Errorf("%s: ServeHTTP() Content-Type got: %q", step.name, got)
+		}
+	}
+}
+
+func TestMuxHandler_DevModeLiveReload(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour)
+	writeDevModeTestFile(t, filepath.Join(dir, "templates", "index.html"), `{{.}}`, modTime)
+	writeDevModeTestFile(t, filepath.Join(dir, "assets", "app.css"), `body{}`, modTime)
+	m := newDevModeTestMuxHandler(t, dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/ctx/_gofre/livereload", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.ServeHTTP(recorder, req)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	writeDevModeTestFile(t, filepath.Join(dir, "assets", "app.css"), `body{color:red}`, modTime.Add(time.Second))
+	<-done
+
+	if ctx.Err() != nil {
+		t.Fatalf("ServeHTTP() the reload event was not sent")
+	}
+	if want := "event: reload\ndata: changed\n\n"; recorder.Body.String() != want {
+		t.Errorf("ServeHTTP() got body: %q, want: %q", recorder.Body.String(), want)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("ServeHTTP() Content-Type got: %q", got)
+	}
+}
+
+func TestResourcesConfig_LiveReloadDisabled(t *testing.T) {
+	c := &ResourcesConfig{DevMode: &DevModeConfig{}, liveReloadUrl: "/_gofre/livereload"}
+	if got := c.liveReloadScript(); got != "" {
+		t.Errorf("liveReloadScript() got: %q, want empty", got)
+	}
+	c = &ResourcesConfig{}
+	if got := c.liveReloadScript(); got != "" {
+		t.Errorf("liveReloadScript() got: %q, want empty", got)
+	}
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 var ErrAccessDenied = errors.New("access denied")
 var ErrUnauthorizedRequest = errors.New("unauthorized request")
@@ -14,6 +17,14 @@ func (e ErrBadRequest) Error() string {
 	return e.err.Error()
 }
 
+func (e ErrBadRequest) Unwrap() error {
+	return e.err
+}
+
+func (e ErrBadRequest) StatusCode() int {
+	return http.StatusBadRequest
+}
+
 func NewBadRequestWithMessage(msg string) ErrBadRequest {
 	return ErrBadRequest{
 		err: errors.New(msg),
@@ -34,6 +45,14 @@ func (e ErrObjectNotFound) Error() string {
 	return e.err.Error()
 }
 
+func (e ErrObjectNotFound) Unwrap() error {
+	return e.err
+}
+
+func (e ErrObjectNotFound) StatusCode() int {
+	return http.StatusNotFound
+}
+
 func NewObjectNotFoundWithMessage(msg string) ErrObjectNotFound {
 	return ErrObjectNotFound{
 		err: errors.New(msg),
@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HttpStatusError is implemented by the errors that know the HTTP status code of the response
+type HttpStatusError interface {
+	error
+	StatusCode() int
+}
+
+// CodedError is implemented by the errors that carry a machine-readable code, for example "insufficient_funds"
+type CodedError interface {
+	error
+	Code() string
+}
+
+// PublicError is implemented by the errors that carry a message that can be shown to the clients, while
+// the Error() method can contain internal details, that should only be logged
+type PublicError interface {
+	error
+	PublicMessage() string
+}
+
+// StatusCode returns the HTTP status code of an error, that can be wrapped: the status code of the first HttpStatusError from
+// the chain, http.StatusUnauthorized for ErrUnauthorizedRequest, http.StatusForbidden for ErrWrongCredentials and ErrAccessDenied,
+// or http.StatusInternalServerError for any other error
+func StatusCode(err error) int {
+	var statusErr HttpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode()
+	}
+	if errors.Is(err, ErrUnauthorizedRequest) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, ErrWrongCredentials) || errors.Is(err, ErrAccessDenied) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrHttpStatus is an error with an HTTP status code, an optional machine-readable code and a public message,
+// that can wrap an internal error (which is not exposed to the clients)
+type ErrHttpStatus struct {
+	statusCode    int
+	code          string
+	publicMessage string
+	err           error
+}
+
+// NewHttpStatusError creates an error with an HTTP status code and a public message
+func NewHttpStatusError(statusCode int, publicMessage string) *ErrHttpStatus {
+	return &ErrHttpStatus{
+		statusCode:    statusCode,
+		publicMessage: publicMessage,
+	}
+}
+
+// WithCode sets the machine-readable code
+func (e *ErrHttpStatus) WithCode(code string) *ErrHttpStatus {
+	e.code = code
+	return e
+}
+
+// Wrap sets the internal error, that is returned by Unwrap and included in the Error() message
+func (e *ErrHttpStatus) Wrap(err error) *ErrHttpStatus {
+	e.err = err
+	return e
+}
+
+// Error returns the public message and the internal error message, if any
+func (e *ErrHttpStatus) Error() string {
+	msg := e.publicMessage
+	if len(msg) == 0 {
+		msg = http.StatusText(e.statusCode)
+	}
+	if e.err != nil {
+		return msg + ": " + e.err.Error()
+	}
+	return msg
+}
+
+func (e *ErrHttpStatus) Unwrap() error {
+	return e.err
+}
+
+func (e *ErrHttpStatus) StatusCode() int {
+	return e.statusCode
+}
+
+func (e *ErrHttpStatus) Code() string {
+	return e.code
+}
+
+// PublicMessage returns the public message or, if missing, the status text
+func (e *ErrHttpStatus) PublicMessage() string {
+	if len(e.publicMessage) == 0 {
+		return http.StatusText(e.statusCode)
+	}
+	return e.publicMessage
+}
+
+func NewUnauthorized(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusUnauthorized, publicMessage)
+}
+
+func NewForbidden(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusForbidden, publicMessage)
+}
+
+func NewMethodNotAllowed(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusMethodNotAllowed, publicMessage)
+}
+
+func NewNotAcceptable(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusNotAcceptable, publicMessage)
+}
+
+func NewConflict(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusConflict, publicMessage)
+}
+
+func NewGone(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusGone, publicMessage)
+}
+
+func NewPreconditionFailed(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusPreconditionFailed, publicMessage)
+}
+
+func NewRequestEntityTooLarge(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusRequestEntityTooLarge, publicMessage)
+}
+
+func NewUnsupportedMediaType(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusUnsupportedMediaType, publicMessage)
+}
+
+func NewUnprocessableEntity(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusUnprocessableEntity, publicMessage)
+}
+
+func NewTooManyRequests(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusTooManyRequests, publicMessage)
+}
+
+func NewNotImplemented(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusNotImplemented, publicMessage)
+}
+
+func NewServiceUnavailable(publicMessage string) *ErrHttpStatus {
+	return NewHttpStatusError(http.StatusServiceUnavailable, publicMessage)
+}
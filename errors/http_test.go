@@ -0,0 +1,94 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "ErrBadRequest", err: NewBadRequestWithMessage("invalid email"), want: http.StatusBadRequest},
+		{name: "wrapped ErrObjectNotFound", err: fmt.Errorf("load user: %w", NewObjectNotFoundWithMessage("user not found")), want: http.StatusNotFound},
+		{name: "ErrUnauthorizedRequest", err: ErrUnauthorizedRequest, want: http.StatusUnauthorized},
+		{name: "wrapped ErrWrongCredentials", err: fmt.Errorf("login: %w", ErrWrongCredentials), want: http.StatusForbidden},
+		{name: "ErrAccessDenied", err: ErrAccessDenied, want: http.StatusForbidden},
+		{name: "ErrHttpStatus", err: NewTooManyRequests(""), want: http.StatusTooManyRequests},
+		{name: "ErrProblem with status", err: NewProblem(http.StatusPaymentRequired, "", "Out of credit", ""), want: http.StatusPaymentRequired},
+		{name: "ErrProblem from error", err: NewProblemFromError("", "Conflict", NewConflict("")), want: http.StatusConflict},
+		{name: "ErrProblem from a sentinel error", err: NewProblemFromError("", "Access denied", ErrAccessDenied), want: http.StatusForbidden},
+		{name: "custom error", err: errors.New("db connection refused"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusCode(tt.err); got != tt.want {
+				t.Errorf("StatusCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrHttpStatus(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	tests := []struct {
+		name              string
+		err               *ErrHttpStatus
+		wantStatusCode    int
+		wantMsg           string
+		wantPublicMessage string
+		wantCode          string
+		wantCause         error
+	}{
+		{
+			name:              "public message",
+			err:               NewUnprocessableEntity("insufficient funds"),
+			wantStatusCode:    http.StatusUnprocessableEntity,
+			wantMsg:           "insufficient funds",
+			wantPublicMessage: "insufficient funds",
+		},
+		{
+			name:              "status text",
+			err:               NewGone(""),
+			wantStatusCode:    http.StatusGone,
+			wantMsg:           "Gone",
+			wantPublicMessage: "Gone",
+		},
+		{
+			name:              "with code and cause",
+			err:               NewServiceUnavailable("try again later").WithCode("db_unavailable").Wrap(cause),
+			wantStatusCode:    http.StatusServiceUnavailable,
+			wantMsg:           "try again later: dial tcp: connection refused",
+			wantPublicMessage: "try again later",
+			wantCode:          "db_unavailable",
+			wantCause:         cause,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.StatusCode(); got != tt.wantStatusCode {
+				t.Errorf("StatusCode() = %v, want %v", got, tt.wantStatusCode)
+			}
+			if got := tt.err.Error(); got != tt.wantMsg {
+				t.Errorf("Error() = %v, want %v", got, tt.wantMsg)
+			}
+			if got := tt.err.PublicMessage(); got != tt.wantPublicMessage {
+				t.Errorf("PublicMessage() = %v, want %v", got, tt.wantPublicMessage)
+			}
+			if got := tt.err.Code(); got != tt.wantCode {
+				t.Errorf("Code() = %v, want %v", got, tt.wantCode)
+			}
+			if got := errors.Unwrap(tt.err); got != tt.wantCause {
+				t.Errorf("Unwrap() = %v, want %v", got, tt.wantCause)
+			}
+			var publicErr PublicError
+			if !errors.As(fmt.Errorf("wrapped: %w", tt.err), &publicErr) {
+				t.Errorf("errors.As() PublicError not found")
+			}
+		})
+	}
+}
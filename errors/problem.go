@@ -0,0 +1,72 @@
+package errors
+
+import "net/http"
+
+// ErrProblem is an error that carries the RFC 9457 problem details members, translated to an application/problem+json
+// response by the middleware.ErrProblemResponse
+type ErrProblem struct {
+	// a URI reference that identifies the problem type, for example "https://example.com/problems/out-of-credit". Default: "about:blank"
+	Type string
+	// a short, human-readable summary of the problem type
+	Title string
+	// the HTTP status code. If 0, it is resolved from the wrapped error
+	Status int
+	// a human-readable explanation specific to this occurrence of the problem
+	Detail string
+	// the extension members, for example "errors", "traceId" or "retryAfter"
+	Extensions map[string]any
+	err        error
+}
+
+func (e *ErrProblem) Error() string {
+	if len(e.Detail) > 0 {
+		return e.Detail
+	}
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return e.Title
+}
+
+func (e *ErrProblem) Unwrap() error {
+	return e.err
+}
+
+// StatusCode returns the Status or, if missing, the status code of the wrapped error
+func (e *ErrProblem) StatusCode() int {
+	if e.Status > 0 {
+		return e.Status
+	}
+	if e.err != nil {
+		return StatusCode(e.err)
+	}
+	return http.StatusInternalServerError
+}
+
+// WithExtension adds an extension member
+func (e *ErrProblem) WithExtension(name string, value any) *ErrProblem {
+	if e.Extensions == nil {
+		e.Extensions = map[string]any{}
+	}
+	e.Extensions[name] = value
+	return e
+}
+
+// NewProblem creates a problem with an explicit status code
+func NewProblem(status int, problemType string, title string, detail string) *ErrProblem {
+	return &ErrProblem{
+		Type:   problemType,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// NewProblemFromError creates a problem that wraps an error, for example an ErrBadRequest, so that the status code is resolved from it
+func NewProblemFromError(problemType string, title string, err error) *ErrProblem {
+	return &ErrProblem{
+		Type:  problemType,
+		Title: title,
+		err:   err,
+	}
+}
@@ -0,0 +1,49 @@
+package errors
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestErrProblem(t *testing.T) {
+	cause := NewBadRequestWithMessage("invalid email")
+	tests := []struct {
+		name      string
+		err       *ErrProblem
+		wantMsg   string
+		wantCause error
+	}{
+		{
+			name:    "with detail",
+			err:     NewProblem(402, "https://example.com/problems/out-of-credit", "Out of credit", "the balance is 30"),
+			wantMsg: "the balance is 30",
+		},
+		{
+			name:    "with title only",
+			err:     NewProblem(402, "https://example.com/problems/out-of-credit", "Out of credit", ""),
+			wantMsg: "Out of credit",
+		},
+		{
+			name:      "from error",
+			err:       NewProblemFromError("https://example.com/problems/validation", "Validation failed", cause),
+			wantMsg:   "invalid email",
+			wantCause: cause,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantMsg {
+				t.Errorf("Error() got: %q, want: %q", got, tt.wantMsg)
+			}
+			if got := errors.Unwrap(tt.err); got != tt.wantCause {
+				t.Errorf("Unwrap() got: %v, want: %v", got, tt.wantCause)
+			}
+		})
+	}
+
+	err := NewProblem(429, "", "", "").WithExtension("retryAfter", 30).WithExtension("traceId", "abc")
+	if want := map[string]any{"retryAfter": 30, "traceId": "abc"}; !reflect.DeepEqual(err.Extensions, want) {
+		t.Errorf("WithExtension() got: %v, want: %v", err.Extensions, want)
+	}
+}
@@ -94,6 +94,15 @@ func main() {
 		return response.StreamHttpResponse("image/png", f), nil
 	})
 
+	// attachment download example, with range and conditional requests support
+	gofreMux.HandleGet("/download/attachment", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		f, err := os.Open("./resources/assets/image.png")
+		if err != nil {
+			return nil, err
+		}
+		return response.FileAttachmentHttpResponse(f, "image.png")
+	})
+
 	// template example
 	gofreMux.HandleGet("/tmpl/{tmplName}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
 		templateName := mc.PathVar("tmplName") + ".html"
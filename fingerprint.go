@@ -0,0 +1,171 @@
+package gofre
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ixtendio/gofre/i18n"
+	"html/template"
+	"io"
+	"io/fs"
+	pathpkg "path"
+	"strings"
+)
+
+const (
+	fingerprintLength           = 10
+	immutableAssetsCacheControl = "public, max-age=31536000, immutable"
+)
+
+// AssetFingerprint contains the fingerprinted path of a static resource and its Subresource Integrity hash
+type AssetFingerprint struct {
+	// the fingerprinted path (relative to AssetsDirPath), for example: js/app.3f2a9c01b4.js
+	Path string `json:"path"`
+	// the Subresource Integrity hash, for example: sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC
+	Integrity string `json:"integrity"`
+}
+
+// AssetsManifest maps the static resources paths (relative to AssetsDirPath) to their fingerprints.
+// It can be generated at startup or in a build step (the manifest can be serialized as JSON)
+type AssetsManifest map[string]AssetFingerprint
+
+// BuildAssetsManifest computes the fingerprints of all the files from the fs.FS.
+// The precompressed variants (.gz and .br files that have an uncompressed sibling) are skipped, because they are served in place of the original file
+func BuildAssetsManifest(fsys fs.FS) (AssetsManifest, error) {
+	manifest := AssetsManifest{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || isPrecompressedVariant(fsys, name) {
+			return nil
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		hash := sha512.New384()
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+		sum := hash.Sum(nil)
+		ext := pathpkg.Ext(name)
+		manifest[name] = AssetFingerprint{
+			Path:      name[:len(name)-len(ext)] + "." + hex.EncodeToString(sum)[:fingerprintLength] + ext,
+			Integrity: "sha384-" + base64.StdEncoding.EncodeToString(sum),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the assets manifest, err: %w", err)
+	}
+	return manifest, nil
+}
+
+// LoadAssetsManifest reads a JSON assets manifest (generated in a build step) from the fs.FS
+func LoadAssetsManifest(fsys fs.FS, name string) (AssetsManifest, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the assets manifest: %s, err: %w", name, err)
+	}
+	var manifest AssetsManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse the assets manifest: %s, err: %w", name, err)
+	}
+	return manifest, nil
+}
+
+func isPrecompressedVariant(fsys fs.FS, name string) bool {
+	for _, pe := range precompressedEncodings {
+		if strings.HasSuffix(name, pe.extension) {
+			if _, err := fs.Stat(fsys, strings.TrimSuffix(name, pe.extension)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reverse returns a map from the fingerprinted paths to the original paths
+func (m AssetsManifest) reverse() map[string]string {
+	reversed := make(map[string]string, len(m))
+	for name, fp := range m {
+		reversed[fp.Path] = name
+	}
+	return reversed
+}
+
+// AssetURL returns the URL path of a static resource. If the resources are fingerprinted (AssetsFingerprint is true), the URL path
+// contains the fingerprint
+func (c *ResourcesConfig) AssetURL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if fp, ok := c.assetFingerprint(name); ok {
+		name = fp.Path
+	}
+	return c.assetsURLPrefix() + name
+}
+
+// AssetIntegrity returns the Subresource Integrity hash of a static resource or an empty string if the resource is not fingerprinted
+func (c *ResourcesConfig) AssetIntegrity(name string) string {
+	fp, _ := c.assetFingerprint(strings.TrimPrefix(name, "/"))
+	return fp.Integrity
+}
+
+// assetFingerprint returns the fingerprint of a static resource from the AssetsManifest. The manifest is ignored if AssetsFingerprint
+// is false, because the fingerprinted paths are not served
+func (c *ResourcesConfig) assetFingerprint(name string) (AssetFingerprint, bool) {
+	if !c.AssetsFingerprint {
+		return AssetFingerprint{}, false
+	}
+	fp, ok := c.AssetsManifest[name]
+	return fp, ok
+}
+
+func (c *ResourcesConfig) assetsURLPrefix() string {
+	if len(c.assetsUrlPrefix) > 0 {
+		return c.assetsUrlPrefix
+	}
+	return "/" + c.AssetsMappingPath + "/"
+}
+
+// TemplateFuncMap returns the template functions that depend on the ResourcesConfig:
+//   - asset - returns the URL path of a static resource, for example: {{asset "app.js"}} -> /assets/app.3f2a9c01b4.js
+//   - assetIntegrity - returns the Subresource Integrity hash of a static resource
+//   - assetScript - returns a <script> tag for a static resource, including the integrity attribute if it is available
+//   - assetStylesheet - returns a <link rel="stylesheet"> tag for a static resource, including the integrity attribute if it is available
+//   - liveReload - returns the live reload script in development mode (see DevModeConfig) or an empty string
+//   - t - translates a message using the I18n catalog, for example: {{t locale "greeting" "name" .Name}} (see i18n.TemplateFunc)
+//   - csrfToken, csrfField, currentUser, locale, requestPath and routeName - return the values of the rendered request
+//
+// These functions are included in the default templates. If a custom template is used, the functions can be added with template.Funcs,
+// but the request scoped ones are bound only for the templates parsed by gofre, so they return empty values
+func (c *ResourcesConfig) TemplateFuncMap() template.FuncMap {
+	funcMap := template.FuncMap{
+		"asset":          c.AssetURL,
+		"assetIntegrity": c.AssetIntegrity,
+		"assetScript": func(name string) template.HTML {
+			return template.HTML(`<script src="` + template.HTMLEscapeString(c.AssetURL(name)) + `"` + c.integrityAttributes(name) + `></script>`)
+		},
+		"assetStylesheet": func(name string) template.HTML {
+			return template.HTML(`<link rel="stylesheet" href="` + template.HTMLEscapeString(c.AssetURL(name)) + `"` + c.integrityAttributes(name) + `>`)
+		},
+		"liveReload": c.liveReloadScript,
+		"t":          i18n.TemplateFunc(c.I18n),
+	}
+	// the request scoped functions are bound when a template response is rendered, see templateBinder
+	for name, fn := range c.requestTemplateFuncMap(&templateRequest{}) {
+		funcMap[name] = fn
+	}
+	return funcMap
+}
+
+func (c *ResourcesConfig) integrityAttributes(name string) string {
+	integrity := c.AssetIntegrity(name)
+	if len(integrity) == 0 {
+		return ""
+	}
+	return ` integrity="` + integrity + `" crossorigin="anonymous"`
+}
@@ -0,0 +1,171 @@
+package gofre
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func fingerprintOf(data string) AssetFingerprint {
+	sum := sha512.Sum384([]byte(data))
+	return AssetFingerprint{
+		Path:      hex.EncodeToString(sum[:])[:fingerprintLength],
+		Integrity: "sha384-" + base64.StdEncoding.EncodeToString(sum[:]),
+	}
+}
+
+func TestBuildAssetsManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":         &fstest.MapFile{Data: []byte("app()")},
+		"app.js.gz":      &fstest.MapFile{Data: []byte("gzip-content")},
+		"css/style.css":  &fstest.MapFile{Data: []byte("body{}")},
+		"archive.tar.gz": &fstest.MapFile{Data: []byte("archive")},
+		"LICENSE":        &fstest.MapFile{Data: []byte("MIT")},
+	}
+	got, err := BuildAssetsManifest(fsys)
+	if err != nil {
+		t.Fatalf("BuildAssetsManifest() unexpected error: %v", err)
+	}
+	appFp := fingerprintOf("app()")
+	styleFp := fingerprintOf("body{}")
+	archiveFp := fingerprintOf("archive")
+	licenseFp := fingerprintOf("MIT")
+	want := AssetsManifest{
+		"app.js":         {Path: "app." + appFp.Path + ".js", Integrity: appFp.Integrity},
+		"css/style.css":  {Path: "css/style." + styleFp.Path + ".css", Integrity: styleFp.Integrity},
+		"archive.tar.gz": {Path: "archive.tar." + archiveFp.Path + ".gz", Integrity: archiveFp.Integrity},
+		"LICENSE":        {Path: "LICENSE." + licenseFp.Path, Integrity: licenseFp.Integrity},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildAssetsManifest() got: %v, want: %v", got, want)
+	}
+}
+
+func TestLoadAssetsManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"manifest.json": &fstest.MapFile{Data: []byte(`{"app.js":{"path":"app.123.js","integrity":"sha384-abc"}}`)},
+		"invalid.json":  &fstest.MapFile{Data: []byte(`[`)},
+	}
+	got, err := LoadAssetsManifest(fsys, "manifest.json")
+	if err != nil {
+		t.Fatalf("LoadAssetsManifest() unexpected error: %v", err)
+	}
+	want := AssetsManifest{"app.js": {Path: "app.123.js", Integrity: "sha384-abc"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadAssetsManifest() got: %v, want: %v", got, want)
+	}
+	if _, err := LoadAssetsManifest(fsys, "invalid.json"); err == nil {
+		t.Errorf("LoadAssetsManifest() expected an error for an invalid manifest")
+	}
+	if _, err := LoadAssetsManifest(fsys, "missing.json"); err == nil {
+		t.Errorf("LoadAssetsManifest() expected an error for a missing manifest")
+	}
+}
+
+func TestMuxHandler_ServeFingerprintedAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"web/templates/index.html": &fstest.MapFile{Data: []byte(`{{define "index.html"}}{{asset "app.js"}}|{{asset "/missing.js"}}|{{assetScript "app.js"}}|{{assetStylesheet "style.css"}}{{end}}`)},
+		"web/assets/app.js":        &fstest.MapFile{Data: []byte("app()")},
+		"web/assets/app.js.gz":     &fstest.MapFile{Data: []byte("gzip-content")},
+		"web/assets/style.css":     &fstest.MapFile{Data: []byte("body{}")},
+	}
+	m, err := NewMuxHandler(&Config{
+		ContextPath: "/ctx",
+		ResourcesConfig: &ResourcesConfig{
+			FS:                   fsys,
+			TemplatesPathPattern: "web/templates/*.html",
+			AssetsDirPath:        "web/assets",
+			AssetsFingerprint:    true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+	appFp := fingerprintOf("app()")
+	styleFp := fingerprintOf("body{}")
+	appURL := "/ctx/assets/app." + appFp.Path + ".js"
+	styleURL := "/ctx/assets/style." + styleFp.Path + ".css"
+
+	var sb strings.Builder
+	if err := m.ExecutableTemplate().ExecuteTemplate(&sb, "index.html", nil); err != nil {
+		t.Fatalf("ExecuteTemplate() unexpected error: %v", err)
+	}
+	wantHTML := appURL + "|/ctx/assets/missing.js|" +
+		`<script src="` + appURL + `" integrity="` + appFp.Integrity + `" crossorigin="anonymous"></script>|` +
+		`<link rel="stylesheet" href="` + styleURL + `" integrity="` + styleFp.Integrity + `" crossorigin="anonymous">`
+	if sb.String() != wantHTML {
+		t.Errorf("ExecuteTemplate() got: %q, want: %q", sb.String(), wantHTML)
+	}
+
+	tests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		wantStatusCode   int
+		wantBody         string
+		wantCacheControl string
+	}{
+		{name: "fingerprinted path", path: appURL, wantStatusCode: http.StatusOK, wantBody: "app()", wantCacheControl: immutableAssetsCacheControl},
+		{name: "fingerprinted path with a precompressed variant", path: appURL, acceptEncoding: "gzip", wantStatusCode: http.StatusOK, wantBody: "gzip-content", wantCacheControl: immutableAssetsCacheControl},
+		{name: "original path", path: "/ctx/assets/style.css", wantStatusCode: http.StatusOK, wantBody: "body{}", wantCacheControl: "no-cache"},
+		{name: "stale fingerprint", path: "/ctx/assets/style.0123456789.css", wantStatusCode: http.StatusNotFound, wantBody: "404 page not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if len(tt.acceptEncoding) > 0 {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			recorder := httptest.NewRecorder()
+			m.ServeHTTP(recorder, req)
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ServeHTTP() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("ServeHTTP() Cache-Control got: %q, want: %q", got, tt.wantCacheControl)
+			}
+		})
+	}
+}
+
+func TestMuxHandler_AssetsManifestWithoutFingerprint(t *testing.T) {
+	fsys := fstest.MapFS{
+		"web/templates/index.html": &fstest.MapFile{Data: []byte(`{{define "index.html"}}{{asset "app.js"}}|{{assetIntegrity "app.js"}}|{{assetScript "app.js"}}{{end}}`)},
+		"web/assets/app.js":        &fstest.MapFile{Data: []byte("app()")},
+	}
+	manifest, err := BuildAssetsManifest(fstest.MapFS{"app.js": fsys["web/assets/app.js"]})
+	if err != nil {
+		t.Fatalf("BuildAssetsManifest() unexpected error: %v", err)
+	}
+	m, err := NewMuxHandler(&Config{
+		ResourcesConfig: &ResourcesConfig{
+			FS:                   fsys,
+			TemplatesPathPattern: "web/templates/*.html",
+			AssetsDirPath:        "web/assets",
+			AssetsManifest:       manifest,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+
+	// the manifest is ignored, because the fingerprinted paths are not served
+	var sb strings.Builder
+	if err := m.ExecutableTemplate().ExecuteTemplate(&sb, "index.html", nil); err != nil {
+		t.Fatalf("ExecuteTemplate() unexpected error: %v", err)
+	}
+	if want := `/assets/app.js||<script src="/assets/app.js"></script>`; sb.String() != want {
+		t.Errorf("ExecuteTemplate() got: %q, want: %q", sb.String(), want)
+	}
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/assets/app.js", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "app()" {
+		t.Errorf("ServeHTTP() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), http.StatusOK, "app()")
+	}
+}
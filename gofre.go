@@ -8,23 +8,37 @@ import (
 	"github.com/ixtendio/gofre/auth/oauth"
 	"github.com/ixtendio/gofre/errors"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/health"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/logging"
+	"github.com/ixtendio/gofre/metrics"
 	"github.com/ixtendio/gofre/middleware"
 	"github.com/ixtendio/gofre/router/path"
+	"github.com/ixtendio/gofre/tracing"
 
 	"github.com/ixtendio/gofre/response"
 	"github.com/ixtendio/gofre/router"
 	"html/template"
+	"io/fs"
 	"log"
 	"math/rand"
 	"net/http"
 	"net/http/pprof"
+	"os"
+	"strings"
 	"unsafe"
 )
 
-var defaultTemplateFunc = func(templatesPathPattern string) (*template.Template, error) {
-	return template.New("").Funcs(template.FuncMap{
-		"safe": func(s string) template.HTML { return template.HTML(s) }, //https://stackoverflow.com/questions/34348072/go-html-comments-are-not-rendered
-	}).ParseGlob(templatesPathPattern)
+var defaultTemplateFuncMap = template.FuncMap{
+	"safe": func(s string) template.HTML { return template.HTML(s) }, //https://stackoverflow.com/questions/34348072/go-html-comments-are-not-rendered
+}
+
+var defaultTemplateFunc = func(templatesPathPattern string, funcMap template.FuncMap) (*template.Template, error) {
+	return template.New("").Funcs(defaultTemplateFuncMap).Funcs(funcMap).ParseGlob(templatesPathPattern)
+}
+
+var defaultTemplateFSFunc = func(fsys fs.FS, templatesPathPattern string, funcMap template.FuncMap) (*template.Template, error) {
+	return template.New("").Funcs(defaultTemplateFuncMap).Funcs(funcMap).ParseFS(fsys, templatesPathPattern)
 }
 
 // ResourcesConfig contains the settings for static resources and templating.
@@ -38,6 +52,57 @@ type ResourcesConfig struct {
 	AssetsMappingPath string
 	//the Go templates. Default: template.HTML
 	Template response.ExecutableTemplate
+	//the file system (for example an embed.FS) from where the templates and the static resources are loaded. If nil, the OS file system is used. Default: nil
+	FS fs.FS
+	//the Cache-Control header value for the static resources. Default: "no-cache" (the clients should revalidate using the ETag)
+	AssetsCacheControl string
+	//if true, the content of the directories without an index.html file is listed. Default: false
+	AssetsDirListing bool
+	//the single-page-application settings. If not nil, the SPA index file is served for the unmatched GET requests that accept text/html. Default: nil
+	SPA *SPAConfig
+	//if true, the static resources are fingerprinted (the content hash is added to the file name) and the fingerprinted
+	//URLs, returned by the `asset` template function, are served with an immutable Cache-Control. Default: false
+	AssetsFingerprint bool
+	//the fingerprints of the static resources, for example loaded with LoadAssetsManifest from a build step output. It is used only
+	//if AssetsFingerprint is true and, if nil, the manifest is built at startup with BuildAssetsManifest. Default: nil
+	AssetsManifest AssetsManifest
+	//the layouts settings. If not nil, the Template is a response.LayoutTemplate built from the TemplatesLayout.PagesDir
+	//(relative to the FS root or the working dir) and the TemplatesPathPattern is ignored. Default: nil
+	TemplatesLayout *response.LayoutTemplateOptions
+	//the development mode settings. If not nil, the templates are parsed again when the files change (the Template
+	//field should be nil) and the pages can be refreshed automatically in the browser. Default: nil
+	DevMode *DevModeConfig
+	//the message catalog used by the `t` template function (see i18n.TemplateFunc), for example loaded with i18n.LoadCatalog. Default: nil
+	I18n *i18n.Catalog
+	//the URL path prefix of the static resources, including the context path
+	assetsUrlPrefix string
+	//the URL path of the live reload endpoint, including the context path
+	liveReloadUrl string
+	//the templates reloader, used in development mode
+	devReloader *devReloader
+	//binds the request scoped functions to the templates parsed by gofre
+	templateBinder *templateBinder
+}
+
+// SPAConfig contains the settings for serving a single-page-application, where the routing is done on the client side.
+// The index file is served for any unmatched GET request under PathPrefix that explicitly accepts text/html and doesn't
+// look like a file (the last path segment has no extension), so the missing .js, .css or image files still return 404
+type SPAConfig struct {
+	//the path prefix (relative to the context path) of the client side routes. Default: "/"
+	PathPrefix string
+	//the path of the SPA index file, relative to AssetsDirPath. Default: "index.html"
+	IndexFile string
+	//the path prefixes (relative to the context path) that are excluded, for example the API endpoints. Default: nil
+	ExcludedPathPrefixes []string
+}
+
+func (c *SPAConfig) setDefaults() {
+	if c.PathPrefix == "" {
+		c.PathPrefix = "/"
+	}
+	if c.IndexFile == "" {
+		c.IndexFile = indexFileName
+	}
 }
 
 func (c *ResourcesConfig) setDefaults() error {
@@ -50,16 +115,82 @@ func (c *ResourcesConfig) setDefaults() error {
 	if c.AssetsMappingPath == "" {
 		c.AssetsMappingPath = "assets"
 	}
+	if c.AssetsCacheControl == "" {
+		c.AssetsCacheControl = "no-cache"
+	}
+	if c.SPA != nil {
+		c.SPA.setDefaults()
+	}
+	if c.AssetsFingerprint && c.AssetsManifest == nil {
+		assetsFS, err := c.assetsFS()
+		if err != nil {
+			return fmt.Errorf("failed to open the static resources dir: %s, err: %w", c.AssetsDirPath, err)
+		}
+		if c.AssetsManifest, err = BuildAssetsManifest(assetsFS); err != nil {
+			return err
+		}
+	}
+	if c.DevMode != nil {
+		c.DevMode.setDefaults()
+	}
 	if c.Template == nil {
-		tmpl, err := defaultTemplateFunc(c.TemplatesPathPattern)
+		if c.DevMode != nil {
+			// the templates are parsed again when they change, and the parse errors are rendered in the browser
+			reloader := newDevReloader(c)
+			c.devReloader = reloader
+			c.Template = reloader
+			return nil
+		}
+		tmpl, err := c.parseTemplate()
 		if err != nil {
-			return fmt.Errorf("failed parsing the templates, err: %w", err)
+			return err
+		}
+		if c.templateBinder, err = newTemplateBinder(c, tmpl); err != nil {
+			return err
 		}
 		c.Template = tmpl
 	}
 	return nil
 }
 
+// parseTemplate parses the templates using the TemplatesLayout or, if nil, the TemplatesPathPattern
+func (c *ResourcesConfig) parseTemplate() (response.ExecutableTemplate, error) {
+	if c.TemplatesLayout != nil {
+		tmpl, err := c.layoutTemplate()
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing the templates, err: %w", err)
+		}
+		return tmpl, nil
+	}
+	var tmpl *template.Template
+	var err error
+	if c.FS != nil {
+		tmpl, err = defaultTemplateFSFunc(c.FS, strings.TrimPrefix(c.TemplatesPathPattern, "./"), c.TemplateFuncMap())
+	} else {
+		tmpl, err = defaultTemplateFunc(c.TemplatesPathPattern, c.TemplateFuncMap())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing the templates, err: %w", err)
+	}
+	return tmpl, nil
+}
+
+func (c *ResourcesConfig) layoutTemplate() (*response.LayoutTemplate, error) {
+	fsys := c.FS
+	if fsys == nil {
+		fsys = os.DirFS(".")
+	}
+	options := *c.TemplatesLayout
+	funcMap := template.FuncMap{}
+	for _, fm := range []template.FuncMap{defaultTemplateFuncMap, c.TemplateFuncMap(), options.Funcs} {
+		for name, fn := range fm {
+			funcMap[name] = fn
+		}
+	}
+	options.Funcs = funcMap
+	return response.NewLayoutTemplate(fsys, options)
+}
+
 // A Config is a type used to pass the configuration to the MuxHandler
 type Config struct {
 	//if the path match should be case-sensitive or not. Default false
@@ -70,12 +201,25 @@ type Config struct {
 	ResourcesConfig *ResourcesConfig
 	//a log function for critical errors. Default: defaultErrLogFunc
 	ErrLogFunc func(err error)
+	//the structured logger used by the framework components. If nil and the ErrLogFunc is provided, only the errors are logged
+	//with the ErrLogFunc. Default: logging.DefaultLogger
+	Logger logging.Logger
+	//the settings used to handle the panics raised while a request is handled or its response is written.
+	//Default: nil (the panics are logged with the ErrLogFunc and an http.StatusInternalServerError response is written)
+	PanicConfig *router.PanicConfig
 }
 
 func (c *Config) setDefaults() error {
 	if c.ContextPath == "" {
 		c.ContextPath = "/"
 	}
+	if c.Logger == nil {
+		if c.ErrLogFunc != nil {
+			c.Logger = logging.NewErrFuncLogger(c.ErrLogFunc)
+		} else {
+			c.Logger = logging.DefaultLogger
+		}
+	}
 	if c.ErrLogFunc == nil {
 		c.ErrLogFunc = func(err error) {
 			log.Printf("An error occured while handling the request, err: %v\n", err)
@@ -85,6 +229,14 @@ func (c *Config) setDefaults() error {
 		if err := c.ResourcesConfig.setDefaults(); err != nil {
 			return err
 		}
+		contextPath := c.ContextPath
+		if contextPath == "/" {
+			contextPath = ""
+		}
+		c.ResourcesConfig.assetsUrlPrefix = contextPath + "/" + c.ResourcesConfig.AssetsMappingPath + "/"
+		if c.ResourcesConfig.DevMode != nil {
+			c.ResourcesConfig.liveReloadUrl = contextPath + c.ResourcesConfig.DevMode.LiveReloadPath
+		}
 	}
 	return nil
 }
@@ -95,6 +247,7 @@ type MuxHandler struct {
 	router            *router.Router
 	commonMiddlewares []middleware.Middleware
 	webConfig         *Config
+	spaHandler        *spaHandler
 }
 
 // NewMuxHandlerWithDefaultConfig returns a new MuxHandler using a default configuration without templating support
@@ -121,19 +274,38 @@ func NewMuxHandler(config *Config) (*MuxHandler, error) {
 	if err := config.setDefaults(); err != nil {
 		return nil, err
 	}
-	r := router.NewRouter(config.CaseInsensitivePathMatch, config.ErrLogFunc)
+	r := router.NewRouter(config.CaseInsensitivePathMatch, config.ErrLogFunc).WithLogger(config.Logger)
+	if config.PanicConfig != nil {
+		r.HandlePanic(*config.PanicConfig)
+	}
+	var sh *spaHandler
 	if config.ResourcesConfig != nil {
 		contextPath := config.ContextPath
 		if contextPath == "/" {
 			contextPath = ""
 		}
 		assetsPath := config.ResourcesConfig.AssetsMappingPath
-		assetsDirPath := config.ResourcesConfig.AssetsDirPath
-		r.Handle(http.MethodGet, contextPath+"/"+assetsPath+"/**", handler.Handler2Handler(http.StripPrefix(contextPath+"/"+assetsPath+"/", http.FileServer(http.Dir(assetsDirPath)))))
+		assetsFS, err := config.ResourcesConfig.assetsFS()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the static resources dir: %s, err: %w", config.ResourcesConfig.AssetsDirPath, err)
+		}
+		ah := newAssetsHandler(assetsFS, contextPath+"/"+assetsPath+"/", config.ResourcesConfig.AssetsCacheControl, config.ResourcesConfig.AssetsDirListing)
+		if config.ResourcesConfig.AssetsFingerprint {
+			ah.fingerprinted = config.ResourcesConfig.AssetsManifest.reverse()
+		}
+		r.Handle(http.MethodGet, contextPath+"/"+assetsPath+"/**", ah.handle)
+		if spaConfig := config.ResourcesConfig.SPA; spaConfig != nil {
+			sh = newSPAHandler(ah, contextPath, *spaConfig)
+			r.HandleNotFound(sh.handle)
+		}
+		if reloader := config.ResourcesConfig.devReloader; reloader != nil && config.ResourcesConfig.DevMode.LiveReload {
+			r.Handle(http.MethodGet, config.ResourcesConfig.liveReloadUrl, reloader.handleLiveReload)
+		}
 	}
 	return &MuxHandler{
-		router:    r,
-		webConfig: config,
+		router:     r,
+		webConfig:  config,
+		spaHandler: sh,
 	}, nil
 }
 
@@ -150,6 +322,14 @@ func (m *MuxHandler) ExecutableTemplate() response.ExecutableTemplate {
 	return m.webConfig.ResourcesConfig.Template
 }
 
+// templateBinder returns the binder of the templates parsed by gofre or nil
+func (m *MuxHandler) templateBinder() *templateBinder {
+	if m.webConfig.ResourcesConfig == nil {
+		return nil
+	}
+	return m.webConfig.ResourcesConfig.templateBinder
+}
+
 // Clone creates a new MuxHandler that will inherit all the settings from the parent.
 // One important aspect to the new MuxHandler is that, the new added common middlewares will not be shared with the parent.
 func (m *MuxHandler) Clone() *MuxHandler {
@@ -158,6 +338,7 @@ func (m *MuxHandler) Clone() *MuxHandler {
 		router:            m.router,
 		commonMiddlewares: append([]middleware.Middleware(nil), m.commonMiddlewares...),
 		webConfig:         m.webConfig,
+		spaHandler:        m.spaHandler,
 	}
 }
 
@@ -172,6 +353,7 @@ func (m *MuxHandler) RouteUsingPathPrefix(pathPrefix string) *MuxHandler {
 		router:            m.router,
 		commonMiddlewares: append([]middleware.Middleware(nil), m.commonMiddlewares...),
 		webConfig:         m.webConfig,
+		spaHandler:        m.spaHandler,
 	}
 }
 
@@ -216,6 +398,7 @@ func (m *MuxHandler) HandleOAUTH2WithCustomPaths(initiatePath string,
 			provider = oauthConfig.GetProviderByName(providerName)
 		}
 		if provider == nil {
+			logging.GetLoggerFromContext(ctx).Warn("oauth provider not supported", "provider", mc.R.FormValue("provider"))
 			return nil, errors.NewBadRequestWithMessage("oauth provider not supported")
 		}
 		redirectUrl := oauthConfig.WebsiteUrl + m.resolvePath(authorizationFlowBasePath) + "/" + provider.Name()
@@ -232,25 +415,30 @@ func (m *MuxHandler) HandleOAUTH2WithCustomPaths(initiatePath string,
 	// authorize OAUTH flow handler
 	m.HandleGet(authorizationFlowBasePath+"/{providerName}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
 		providerName := mc.PathVar("providerName")
+		logger := logging.GetLoggerFromContext(ctx)
 		provider := oauthConfig.GetProviderByName(providerName)
 		if provider == nil {
+			logger.Warn("oauth provider not supported", "provider", providerName)
 			return nil, errors.NewBadRequestWithMessage("oauth provider not supported")
 		}
 
 		redirectUrl := oauthConfig.WebsiteUrl + m.resolvePath(authorizationFlowBasePath) + "/" + provider.Name()
 		errCode := mc.R.FormValue("error")
 		if errCode != "" {
+			logger.Warn("oauth authorization denied by the provider", "provider", providerName, "error", errCode)
 			return nil, errors.ErrUnauthorizedRequest
 		}
 
 		state := mc.R.FormValue("state")
 		if cache != nil && !cache.Contains(state) {
+			logger.Warn("oauth state is missing or expired", "provider", providerName)
 			return nil, errors.ErrUnauthorizedRequest
 
 		}
 		code := mc.R.FormValue("code")
 		accessToken, err := provider.FetchAccessToken(ctx, redirectUrl, code)
 		if err != nil {
+			logger.Error("failed to fetch the oauth access token", "provider", providerName, logging.FieldError, err)
 			return nil, err
 		}
 		ctx = context.WithValue(ctx, oauth.AccessTokenCtxKey, accessToken)
@@ -258,9 +446,11 @@ func (m *MuxHandler) HandleOAUTH2WithCustomPaths(initiatePath string,
 		if oauthConfig.FetchUserDetails {
 			user, err := provider.FetchAuthenticatedUser(ctx, accessToken)
 			if err != nil {
+				logger.Error("failed to fetch the oauth authenticated user", "provider", providerName, logging.FieldError, err)
 				return nil, err
 			}
 			ctx = context.WithValue(ctx, auth.SecurityPrincipalCtxKey, &user)
+			ctx = logging.WithPrincipal(ctx, &user)
 		}
 
 		return handler(ctx, mc)
@@ -294,10 +484,44 @@ func (m *MuxHandler) HandleDelete(path string, handler handler.Handler, middlewa
 
 // HandleRequest registers a handler with custom middlewares for the specified HTTP method
 func (m *MuxHandler) HandleRequest(httpMethod string, path string, h handler.Handler, middlewares ...middleware.Middleware) {
-	h = wrapMiddleware(wrapMiddleware(h, middlewares...), m.commonMiddlewares...)
+	h = wrapMiddleware(wrapMiddleware(bindTemplateContext(h, m.templateBinder()), middlewares...), m.commonMiddlewares...)
 	m.router.Handle(httpMethod, m.resolvePath(path), h)
 }
 
+// HandleNotFound registers a handler with custom middlewares for the requests that don't match any route (for example, a handler
+// that returns errors.ErrObjectNotFound, with the ErrorPages middleware). If a SPA is configured, the handler is called only for the
+// requests that are not client side routes
+func (m *MuxHandler) HandleNotFound(h handler.Handler, middlewares ...middleware.Middleware) {
+	h = wrapMiddleware(wrapMiddleware(bindTemplateContext(h, m.templateBinder()), middlewares...), m.commonMiddlewares...)
+	if m.spaHandler != nil {
+		m.spaHandler.notFound = h
+		return
+	}
+	m.router.HandleNotFound(h)
+}
+
+// ObserveWrites adds the observers called after each request is served, when its response is completely written to the client,
+// with the status code, the number of bytes written and the latency (see middleware.AccessLog).
+// The observers are shared by all the MuxHandler clones, because they share the router
+func (m *MuxHandler) ObserveWrites(observers ...router.WriteObserver) {
+	m.router.ObserveWrites(observers...)
+}
+
+// ErrorPages returns a middleware that renders the error pages (errors/404.html, errors/500.html and so on) from the configured
+// templates, or a JSON error for the clients that prefer it (see middleware.ErrHtmlResponse). The error details are shown only in DevMode
+func (m *MuxHandler) ErrorPages() middleware.Middleware {
+	config := middleware.ErrorPagesConfig{}
+	if rc := m.webConfig.ResourcesConfig; rc != nil {
+		config.Template = rc.Template
+		config.Development = rc.DevMode != nil
+		// the error responses are not bound by the MuxHandler, so the binder is passed to render the request scoped template functions
+		if binder := m.templateBinder(); binder != nil && rc.DevMode == nil {
+			config.Template = binder
+		}
+	}
+	return middleware.ErrHtmlResponse(config)
+}
+
 func (m *MuxHandler) resolvePath(path string) string {
 	pathPrefix := m.pathPrefix
 	if len(pathPrefix) == 0 {
@@ -316,21 +540,115 @@ func (m *MuxHandler) resolvePath(path string) string {
 	}
 }
 
+// EnableMetrics records the HTTP metrics of all the requests (see metrics.HttpMetrics) in the registry (metrics.DefaultRegistry if nil)
+// and serves the registry metrics, in the Prometheus text exposition format, on the path (for example /metrics), with the custom middlewares.
+// The applications can register their own metrics in the same registry
+func (m *MuxHandler) EnableMetrics(path string, registry *metrics.Registry, middlewares ...middleware.Middleware) (*metrics.HttpMetrics, error) {
+	if registry == nil {
+		registry = metrics.DefaultRegistry
+	}
+	httpMetrics, err := metrics.NewHttpMetrics(registry, nil)
+	if err != nil {
+		return nil, err
+	}
+	m.router.ObserveRequests(httpMetrics)
+	m.HandleGet(path, metrics.Handler(registry), middlewares...)
+	return httpMetrics, nil
+}
+
+// EnableTracing starts a server span for each request (see middleware.Tracing) and propagates the trace context on the requests
+// sent with the oauth.HttpClient. The tracing middleware runs before the other common middlewares, but, like them, it is applied
+// only to the handlers registered after this call
+func (m *MuxHandler) EnableTracing(tracer *tracing.Tracer) {
+	m.commonMiddlewares = append([]middleware.Middleware{middleware.Tracing(tracer)}, m.commonMiddlewares...)
+	tracing.InstrumentClient(oauth.HttpClient)
+}
+
+// DebugEndpointsConfig contains the settings of the debug endpoints.
+// If no custom values are provided for the struct fields then, the default one are used
+type DebugEndpointsConfig struct {
+	// the path prefix of the endpoints: the pprof endpoints are registered under PathPrefix/pprof/ and expvar under PathPrefix/vars. Default: "/debug"
+	PathPrefix string
+	// the middlewares applied to the debug endpoints, for example middleware.AuthorizeAll or middleware.AllowNetworks.
+	// The common middlewares are not applied. Default: nil
+	Middlewares []middleware.Middleware
+	// if true, each handler runs under pprof.Do with the route pattern and the method labels (see router.Router.EnableProfilerLabels). Default: false
+	ProfilerLabels bool
+}
+
+func (c *DebugEndpointsConfig) setDefaults() {
+	if c.PathPrefix == "" {
+		c.PathPrefix = "/debug"
+	}
+	c.PathPrefix = strings.TrimSuffix(c.PathPrefix, "/")
+}
+
 // EnableDebugEndpoints enable debug endpoints
+// The endpoints are registered without any protection, see EnableDebugEndpointsWithConfig to restrict the access to them
 func (m MuxHandler) EnableDebugEndpoints() {
+	m.EnableDebugEndpointsWithConfig(DebugEndpointsConfig{})
+}
+
+// EnableDebugEndpointsWithConfig registers the standard library debug endpoints (pprof and expvar) under a custom path prefix,
+// with custom middlewares, and optionally labels the profiles of the handlers with their route pattern and method
+func (m *MuxHandler) EnableDebugEndpointsWithConfig(config DebugEndpointsConfig) {
+	config.setDefaults()
+	if config.ProfilerLabels {
+		m.router.EnableProfilerLabels()
+	}
+	handle := func(path string, h handler.Handler) {
+		m.router.Handle(http.MethodGet, m.resolvePath(config.PathPrefix+path), wrapMiddleware(h, config.Middlewares...))
+	}
 	// Register all the standard library debug endpoints.
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/"), handler.HandlerFunc2Handler(pprof.Index))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/allocs"), handler.HandlerFunc2Handler(pprof.Index))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/block"), handler.HandlerFunc2Handler(pprof.Index))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/goroutine"), handler.HandlerFunc2Handler(pprof.Index))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/heap"), handler.HandlerFunc2Handler(pprof.Index))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/mutex"), handler.HandlerFunc2Handler(pprof.Index))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/threadcreate"), handler.HandlerFunc2Handler(pprof.Index))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/cmdline"), handler.HandlerFunc2Handler(pprof.Cmdline))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/profile"), handler.HandlerFunc2Handler(pprof.Profile))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/symbol"), handler.HandlerFunc2Handler(pprof.Symbol))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/pprof/trace"), handler.HandlerFunc2Handler(pprof.Trace))
-	m.router.Handle(http.MethodGet, m.resolvePath("/debug/vars"), handler.Handler2Handler(expvar.Handler()))
+	// The named profiles are served by pprof.Handler, because pprof.Index resolves them only under the /debug/pprof/ path
+	handle("/pprof/", handler.HandlerFunc2Handler(pprof.Index))
+	for _, profile := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		handle("/pprof/"+profile, handler.Handler2Handler(pprof.Handler(profile)))
+	}
+	handle("/pprof/cmdline", handler.HandlerFunc2Handler(pprof.Cmdline))
+	handle("/pprof/profile", handler.HandlerFunc2Handler(pprof.Profile))
+	handle("/pprof/symbol", handler.HandlerFunc2Handler(pprof.Symbol))
+	handle("/pprof/trace", handler.HandlerFunc2Handler(pprof.Trace))
+	handle("/vars", handler.Handler2Handler(expvar.Handler()))
+}
+
+// HealthEndpointsConfig contains the settings of the health endpoints.
+// If no custom values are provided for the struct fields then, the default one are used
+type HealthEndpointsConfig struct {
+	// the path of the liveness probe. Default: "/healthz"
+	LivenessPath string
+	// the path of the readiness probe. Default: "/readyz"
+	ReadinessPath string
+	// the path of the JSON details of all the checks. Default: "/health"
+	DetailsPath string
+	// the middlewares applied to the details endpoint, for example middleware.AuthorizeAll or middleware.AllowNetworks.
+	// The details endpoint is registered only if at least one middleware is provided, because the check errors can expose
+	// the internals of the service. Default: nil
+	DetailsMiddlewares []middleware.Middleware
+}
+
+func (c *HealthEndpointsConfig) setDefaults() {
+	if c.LivenessPath == "" {
+		c.LivenessPath = "/healthz"
+	}
+	if c.ReadinessPath == "" {
+		c.ReadinessPath = "/readyz"
+	}
+	if c.DetailsPath == "" {
+		c.DetailsPath = "/health"
+	}
+}
+
+// EnableHealthEndpoints registers the liveness and the readiness probes of the registry checks and, if protected by middlewares,
+// the JSON details of the checks. The common middlewares are not applied, so the probes don't require authentication.
+// Use health.Registry.Shutdown to fail the readiness probe during the graceful shutdown of the server
+func (m *MuxHandler) EnableHealthEndpoints(registry *health.Registry, config HealthEndpointsConfig) {
+	config.setDefaults()
+	m.router.Handle(http.MethodGet, m.resolvePath(config.LivenessPath), health.LivenessHandler(registry))
+	m.router.Handle(http.MethodGet, m.resolvePath(config.ReadinessPath), health.ReadinessHandler(registry))
+	if len(config.DetailsMiddlewares) > 0 {
+		m.router.Handle(http.MethodGet, m.resolvePath(config.DetailsPath), wrapMiddleware(health.DetailsHandler(registry), config.DetailsMiddlewares...))
+	}
 }
 
 func (m *MuxHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -1,26 +1,35 @@
 package gofre
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/ixtendio/gofre/auth"
 	"github.com/ixtendio/gofre/auth/oauth"
 	"github.com/ixtendio/gofre/cache"
+	"github.com/ixtendio/gofre/errors"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/health"
+	"github.com/ixtendio/gofre/metrics"
 	"github.com/ixtendio/gofre/middleware"
 	"github.com/ixtendio/gofre/router/path"
+	"github.com/ixtendio/gofre/tracing"
 
 	"github.com/ixtendio/gofre/response"
+	"github.com/ixtendio/gofre/router"
 	"html/template"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"net/url"
 	"reflect"
 	"runtime"
+	"runtime/pprof"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/fstest"
 	text "text/template"
 	"time"
 )
@@ -50,7 +59,9 @@ func (p captureOAUTHProvider) FetchAuthenticatedUser(ctx context.Context, access
 func TestConfig_setDefaults(t *testing.T) {
 	tmpl := template.New("")
 	errLogFunc := func(err error) {}
-	defaultTemplateFunc = func(templatesPathPattern string) (*template.Template, error) { return template.New(""), nil }
+	defaultTemplateFunc = func(templatesPathPattern string, funcMap template.FuncMap) (*template.Template, error) {
+		return template.New(""), nil
+	}
 	type fields struct {
 		ContextPath    string
 		TemplateConfig *ResourcesConfig
@@ -200,7 +211,7 @@ func TestNewMuxHandlerWithDefaultConfig(t *testing.T) {
 }
 
 func TestNewMuxHandlerWithDefaultConfigAndTemplateSupport(t *testing.T) {
-	defaultTemplateFunc = func(templatesPathPattern string) (*template.Template, error) {
+	defaultTemplateFunc = func(templatesPathPattern string, funcMap template.FuncMap) (*template.Template, error) {
 		return &template.Template{}, nil
 	}
 	tests := []struct {
@@ -258,7 +269,7 @@ func TestNewMuxHandlerWithDefaultConfigAndTemplateSupport(t *testing.T) {
 }
 
 func TestNewDefaultResourcesConfig(t *testing.T) {
-	defaultTemplateFunc = func(templatesPathPattern string) (*template.Template, error) {
+	defaultTemplateFunc = func(templatesPathPattern string, funcMap template.FuncMap) (*template.Template, error) {
 		return &template.Template{}, nil
 	}
 	tests := []struct {
@@ -416,7 +427,7 @@ func TestMuxHandler_Config(t *testing.T) {
 
 func TestMuxHandler_ExecutableTemplate(t *testing.T) {
 	tmpl := &template.Template{}
-	defaultTemplateFunc = func(templatesPathPattern string) (*template.Template, error) {
+	defaultTemplateFunc = func(templatesPathPattern string, funcMap template.FuncMap) (*template.Template, error) {
 		return tmpl, nil
 	}
 	type args struct {
@@ -1098,6 +1109,156 @@ func TestMuxHandler_EnableDebugEndpoints(t *testing.T) {
 	}
 }
 
+func TestMuxHandler_EnableDebugEndpointsWithConfig(t *testing.T) {
+	m, _ := NewMuxHandlerWithDefaultConfig()
+	m.EnableDebugEndpointsWithConfig(DebugEndpointsConfig{
+		PathPrefix:     "/internal/debug/",
+		Middlewares:    []middleware.Middleware{middleware.ErrJsonResponse(), middleware.AllowNetworks(netip.MustParsePrefix("10.0.0.0/8"))},
+		ProfilerLabels: true,
+	})
+	var gotRoute string
+	m.HandleGet("/users/{id}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		gotRoute, _ = pprof.Label(ctx, router.ProfilerLabelRoute)
+		return response.PlainTextHttpResponseOK("ok"), nil
+	})
+	tests := []struct {
+		name           string
+		path           string
+		remoteAddr     string
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "named profile from an allowed network",
+			path:           "/internal/debug/pprof/heap?debug=1",
+			remoteAddr:     "10.1.2.3:51234",
+			wantStatusCode: http.StatusOK,
+			wantBody:       "heap profile",
+		},
+		{
+			name:           "index from an allowed network",
+			path:           "/internal/debug/pprof/",
+			remoteAddr:     "10.1.2.3:51234",
+			wantStatusCode: http.StatusOK,
+			wantBody:       "goroutine",
+		},
+		{
+			name:           "expvar from an allowed network",
+			path:           "/internal/debug/vars",
+			remoteAddr:     "10.1.2.3:51234",
+			wantStatusCode: http.StatusOK,
+			wantBody:       "memstats",
+		},
+		{
+			name:           "denied network",
+			path:           "/internal/debug/vars",
+			remoteAddr:     "203.0.113.10:51234",
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name:           "default prefix is not registered",
+			path:           "/debug/vars",
+			remoteAddr:     "10.1.2.3:51234",
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, req)
+			if w.Code != tt.wantStatusCode {
+				t.Fatalf("EnableDebugEndpointsWithConfig() status code got: %d, want: %d", w.Code, tt.wantStatusCode)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("EnableDebugEndpointsWithConfig() body doesn't contain: %s, got: %s", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/10", nil))
+	if gotRoute != "/users/{id}" {
+		t.Errorf("EnableDebugEndpointsWithConfig() route profiler label got: %q, want: /users/{id}", gotRoute)
+	}
+}
+
+func TestMuxHandler_EnableHealthEndpoints(t *testing.T) {
+	registry := health.NewRegistry(health.Config{})
+	registry.MustRegister(health.Check{
+		Name: "db",
+		Func: func(ctx context.Context) error {
+			return fmt.Errorf("connection refused")
+		},
+	})
+	m, _ := NewMuxHandlerWithDefaultConfig()
+	m.CommonMiddlewares(func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			return nil, errors.ErrUnauthorizedRequest
+		}
+	})
+	m.EnableHealthEndpoints(registry, HealthEndpointsConfig{
+		DetailsMiddlewares: []middleware.Middleware{middleware.ErrJsonResponse(), middleware.AllowNetworks(netip.MustParsePrefix("10.0.0.0/8"))},
+	})
+	tests := []struct {
+		name           string
+		path           string
+		remoteAddr     string
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "liveness",
+			path:           "/healthz",
+			remoteAddr:     "203.0.113.10:51234",
+			wantStatusCode: http.StatusOK,
+			wantBody:       `{"status":"up"}`,
+		},
+		{
+			name:           "readiness",
+			path:           "/readyz",
+			remoteAddr:     "203.0.113.10:51234",
+			wantStatusCode: http.StatusServiceUnavailable,
+			wantBody:       `{"status":"down"}`,
+		},
+		{
+			name:           "details from an allowed network",
+			path:           "/health",
+			remoteAddr:     "10.1.2.3:51234",
+			wantStatusCode: http.StatusServiceUnavailable,
+			wantBody:       `"error":"connection refused"`,
+		},
+		{
+			name:           "details from a denied network",
+			path:           "/health",
+			remoteAddr:     "203.0.113.10:51234",
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, req)
+			if w.Code != tt.wantStatusCode {
+				t.Fatalf("EnableHealthEndpoints() status code got: %d, want: %d", w.Code, tt.wantStatusCode)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("EnableHealthEndpoints() body doesn't contain: %s, got: %s", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+
+	m, _ = NewMuxHandlerWithDefaultConfig()
+	m.EnableHealthEndpoints(registry, HealthEndpointsConfig{})
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("EnableHealthEndpoints() details without middlewares status code got: %d, want: %d", w.Code, http.StatusNotFound)
+	}
+}
+
 func TestMuxHandler_GenerateUniqueId(t *testing.T) {
 	tests := []struct {
 		name string
@@ -1145,3 +1306,114 @@ func compareMiddlewares(m1 []middleware.Middleware, m2 []middleware.Middleware)
 	}
 	return nil
 }
+
+func TestMuxHandler_ErrorPages(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/errors.html": &fstest.MapFile{Data: []byte(`{{define "errors/404.html"}}<h1>Not found: {{.Path}}</h1>{{end}}{{define "errors/error.html"}}<h1>{{.StatusCode}}</h1>{{.Message}} at {{requestPath}}{{end}}`)},
+		"assets/index.html":     &fstest.MapFile{Data: []byte("<div id=app></div>")},
+	}
+	notFoundHandler := func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return nil, errors.NewObjectNotFoundWithMessage("page not found")
+	}
+	newMuxHandler := func(resourcesConfig *ResourcesConfig) *MuxHandler {
+		m, err := NewMuxHandler(&Config{ResourcesConfig: resourcesConfig})
+		if err != nil {
+			t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+		}
+		m.CommonMiddlewares(m.ErrorPages())
+		m.HandleGet("/fail", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			return nil, fmt.Errorf("internal failure")
+		})
+		m.HandleNotFound(notFoundHandler)
+		return m
+	}
+	m := newMuxHandler(&ResourcesConfig{FS: fsys, TemplatesPathPattern: "templates/*.html", AssetsDirPath: "assets"})
+	spa := newMuxHandler(&ResourcesConfig{FS: fsys, TemplatesPathPattern: "templates/*.html", AssetsDirPath: "assets", SPA: &SPAConfig{PathPrefix: "/app"}})
+	const htmlAccept = "text/html,application/xhtml+xml,*/*;q=0.8"
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		path           string
+		accept         string
+		wantStatusCode int
+		wantBody       string
+	}{
+		{name: "handler error", handler: m, path: "/fail", accept: htmlAccept, wantStatusCode: http.StatusInternalServerError, wantBody: "<h1>500</h1>Internal Server Error at /fail"},
+		{name: "unmatched route", handler: m, path: "/missing", accept: htmlAccept, wantStatusCode: http.StatusNotFound, wantBody: "<h1>Not found: /missing</h1>"},
+		{name: "unmatched route as json", handler: m, path: "/missing", accept: "application/json", wantStatusCode: http.StatusNotFound, wantBody: `{"error":"page not found"}`},
+		{name: "spa client side route", handler: spa, path: "/app/settings", accept: htmlAccept, wantStatusCode: http.StatusOK, wantBody: "<div id=app></div>"},
+		{name: "spa unmatched route", handler: spa, path: "/missing", accept: htmlAccept, wantStatusCode: http.StatusNotFound, wantBody: "<h1>Not found: /missing</h1>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			recorder := httptest.NewRecorder()
+			tt.handler.ServeHTTP(recorder, req)
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ServeHTTP() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMuxHandler_EnableMetrics(t *testing.T) {
+	m, err := NewMuxHandlerWithDefaultConfig()
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+	registry := metrics.NewRegistry()
+	orders := metrics.NewCounter("orders_total", "The number of orders.")
+	registry.MustRegister(orders)
+	if _, err := m.EnableMetrics("/metrics", registry); err != nil {
+		t.Fatalf("EnableMetrics() unexpected error: %v", err)
+	}
+	if _, err := m.EnableMetrics("/metrics2", registry); err == nil {
+		t.Fatalf("EnableMetrics() expected error when the metrics are already registered")
+	}
+	m.HandleGet("/orders/{id}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		orders.Inc()
+		return response.PlainTextHttpResponseOK("order"), nil
+	})
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/10", nil))
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("EnableMetrics() status code = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	for _, line := range []string{
+		"orders_total 1\n",
+		"http_requests_total{method=\"GET\",route=\"/orders/{id}\",status=\"2xx\"} 1\n",
+	} {
+		if !strings.Contains(recorder.Body.String(), line) {
+			t.Errorf("EnableMetrics() body doesn't contain: %s, got:\n%s", line, recorder.Body.String())
+		}
+	}
+}
+
+func TestMuxHandler_EnableTracing(t *testing.T) {
+	originalTransport := oauth.HttpClient.Transport
+	defer func() { oauth.HttpClient.Transport = originalTransport }()
+	m, err := NewMuxHandlerWithDefaultConfig()
+	if err != nil {
+		t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	tracer := tracing.NewTracer(tracing.TracerConfig{ServiceName: "orders", Exporter: tracing.JSONLinesExporter(&buf)})
+	m.EnableTracing(tracer)
+	m.HandleGet("/orders/{id}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.PlainTextHttpResponseOK("order"), nil
+	})
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/10", nil))
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() unexpected error: %v", err)
+	}
+	for _, field := range []string{`"service":"orders"`, `"name":"GET /orders/{id}"`, `"kind":"server"`} {
+		if !strings.Contains(buf.String(), field) {
+			t.Errorf("EnableTracing() exported span doesn't contain: %s, got:\n%s", field, buf.String())
+		}
+	}
+	if _, ok := oauth.HttpClient.Transport.(*tracing.Transport); !ok {
+		t.Errorf("EnableTracing() oauth.HttpClient transport = %T, want *tracing.Transport", oauth.HttpClient.Transport)
+	}
+}
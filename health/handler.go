@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+)
+
+// LivenessHandler returns a handler for the liveness probe that responds with 200 OK if the liveness checks pass or
+// 503 Service Unavailable, otherwise. The response contains only the status, the details of the checks are served by DetailsHandler
+func LivenessHandler(registry *Registry) handler.Handler {
+	return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return probeResponse(registry.Liveness()), nil
+	}
+}
+
+// ReadinessHandler returns a handler for the readiness probe that responds with 200 OK if the readiness checks pass or
+// 503 Service Unavailable, otherwise, including during the graceful shutdown.
+// The response contains only the status, the details of the checks are served by DetailsHandler
+func ReadinessHandler(registry *Registry) handler.Handler {
+	return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return probeResponse(registry.Readiness()), nil
+	}
+}
+
+// Details is the payload of the DetailsHandler
+type Details struct {
+	Status    Status `json:"status"`
+	Liveness  Report `json:"liveness"`
+	Readiness Report `json:"readiness"`
+}
+
+// DetailsHandler returns a handler that responds with the results of all the checks, as JSON. The check errors can
+// expose the internals of the service, so the handler should be protected, for example with middleware.AuthorizeAll
+func DetailsHandler(registry *Registry) handler.Handler {
+	return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		details := Details{
+			Status:    StatusUp,
+			Liveness:  registry.Liveness(),
+			Readiness: registry.Readiness(),
+		}
+		if details.Liveness.Status == StatusDown || details.Readiness.Status == StatusDown {
+			details.Status = StatusDown
+		}
+		return jsonResponse(details.Status, details), nil
+	}
+}
+
+func probeResponse(report Report) response.HttpResponse {
+	return jsonResponse(report.Status, Report{Status: report.Status, ShuttingDown: report.ShuttingDown})
+}
+
+// jsonResponse returns a not cacheable JSON response: the Registry already caches the check results
+func jsonResponse(status Status, payload any) response.HttpResponse {
+	statusCode := http.StatusOK
+	if status != StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+	return response.JsonHttpResponseWithHeaders(statusCode, payload, response.HttpHeaders{"Cache-Control": "no-store"})
+}
@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/router/path"
+
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestHandlers(t *testing.T) {
+	r := NewRegistry(Config{})
+	r.MustRegister(
+		Check{Name: "db", Func: up},
+		Check{Name: "cache", Func: down, Optional: true},
+		Check{Name: "deadlock", Kind: Liveness, Func: up},
+	)
+	tests := []struct {
+		name           string
+		handler        handler.Handler
+		shutdown       bool
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "liveness",
+			handler:        LivenessHandler(r),
+			wantStatusCode: http.StatusOK,
+			wantBody:       `^{"status":"up"}$`,
+		},
+		{
+			name:           "readiness",
+			handler:        ReadinessHandler(r),
+			wantStatusCode: http.StatusOK,
+			wantBody:       `^{"status":"up"}$`,
+		},
+		{
+			name:           "details",
+			handler:        DetailsHandler(r),
+			wantStatusCode: http.StatusOK,
+			wantBody: `^{"status":"up","liveness":{"status":"up","checks":\[{"name":"deadlock","status":"up","durationMs":[0-9.]+,"checkedAt":"[^"]+"}\]},` +
+				`"readiness":{"status":"up","checks":\[{"name":"db","status":"up","durationMs":[0-9.]+,"checkedAt":"[^"]+"},` +
+				`{"name":"cache","status":"down","optional":true,"error":"connection refused","durationMs":[0-9.]+,"checkedAt":"[^"]+"}\]}}$`,
+		},
+		{
+			name:           "readiness during shutdown",
+			handler:        ReadinessHandler(r),
+			shutdown:       true,
+			wantStatusCode: http.StatusServiceUnavailable,
+			wantBody:       `^{"status":"down","shuttingDown":true}$`,
+		},
+		{
+			name:           "details during shutdown",
+			handler:        DetailsHandler(r),
+			shutdown:       true,
+			wantStatusCode: http.StatusServiceUnavailable,
+			wantBody:       `^{"status":"down",.*"readiness":{"status":"down","shuttingDown":true}}$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.shutdown {
+				r.StartShutdown()
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			mc := path.MatchingContext{R: req}
+			resp, err := tt.handler(context.Background(), mc)
+			if err != nil {
+				t.Fatalf("handler unexpected error: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, mc); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if recorder.Code != tt.wantStatusCode {
+				t.Errorf("status code got: %d, want: %d", recorder.Code, tt.wantStatusCode)
+			}
+			if got := recorder.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control header got: %q, want: no-store", got)
+			}
+			if got := recorder.Body.String(); !regexp.MustCompile(tt.wantBody).MatchString(got) {
+				t.Errorf("body got: %s, want to match: %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the status of a check or of a probe
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Kind is the probe that runs a check
+type Kind int
+
+const (
+	// Readiness checks verify if the service can handle requests, for example if the database is reachable.
+	// When they fail, the service should be removed from the load balancer, but not restarted
+	Readiness Kind = iota
+	// Liveness checks verify if the process is healthy, for example if it is not deadlocked. When they fail, the process
+	// should be restarted, so they shouldn't depend on the external services
+	Liveness
+)
+
+// ErrShuttingDown is reported by the readiness probe during the graceful shutdown
+var ErrShuttingDown = errors.New("the service is shutting down")
+
+// CheckFunc verifies a component of the service and returns an error if it is not healthy. It should return when the context is done
+type CheckFunc func(ctx context.Context) error
+
+// A Check is a named verification of a component of the service
+type Check struct {
+	// the unique name of the check, for example "postgres"
+	Name string
+	// the probe that runs the check. Default: Readiness
+	Kind Kind
+	// the verification function
+	Func CheckFunc
+	// the maximum duration of the check, after which it fails. Default: Config.Timeout
+	Timeout time.Duration
+	// if true, a failing check is reported in the details, but it doesn't fail the probe. Default: false
+	Optional bool
+}
+
+// Config contains the Registry settings.
+// If no custom values are provided for the struct fields then, the default one are used
+type Config struct {
+	// the duration a check result is reused, so the probes can't overload the dependencies. Default: 1s
+	CacheTTL time.Duration
+	// the timeout of the checks without a custom one. Default: 2s
+	Timeout time.Duration
+}
+
+func (c *Config) setDefaults() {
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 2 * time.Second
+	}
+}
+
+// A Result is the outcome of a check
+type Result struct {
+	Name      string
+	Status    Status
+	Optional  bool
+	Error     string
+	Duration  time.Duration
+	CheckedAt time.Time
+}
+
+func (r Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name       string    `json:"name"`
+		Status     Status    `json:"status"`
+		Optional   bool      `json:"optional,omitempty"`
+		Error      string    `json:"error,omitempty"`
+		DurationMs float64   `json:"durationMs"`
+		CheckedAt  time.Time `json:"checkedAt"`
+	}{
+		Name:       r.Name,
+		Status:     r.Status,
+		Optional:   r.Optional,
+		Error:      r.Error,
+		DurationMs: float64(r.Duration.Microseconds()) / 1000,
+		CheckedAt:  r.CheckedAt,
+	})
+}
+
+// A Report is the outcome of a probe: it is down if at least one of its non-optional checks is down
+type Report struct {
+	Status       Status   `json:"status"`
+	ShuttingDown bool     `json:"shuttingDown,omitempty"`
+	Checks       []Result `json:"checks,omitempty"`
+}
+
+// A Registry contains the checks of the service and runs them for the liveness and readiness probes.
+// The check results are cached for Config.CacheTTL and the concurrent probes wait for the running check instead of starting a new one
+type Registry struct {
+	config       Config
+	mu           sync.RWMutex
+	checks       []*check
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry creates a Registry without checks
+func NewRegistry(config Config) *Registry {
+	config.setDefaults()
+	return &Registry{config: config}
+}
+
+// Register adds the checks to the registry or returns an error if a check is invalid or a check with the same name is already registered
+func (r *Registry) Register(checks ...Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make(map[string]bool, len(r.checks)+len(checks))
+	for _, c := range r.checks {
+		names[c.Name] = true
+	}
+	for _, c := range checks {
+		if c.Name == "" {
+			return errors.New("the check name can not be empty")
+		}
+		if c.Func == nil {
+			return fmt.Errorf("check %s has no function", c.Name)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("check %s is already registered", c.Name)
+		}
+		names[c.Name] = true
+	}
+	for _, c := range checks {
+		if c.Timeout <= 0 {
+			c.Timeout = r.config.Timeout
+		}
+		r.checks = append(r.checks, &check{Check: c})
+	}
+	return nil
+}
+
+// MustRegister adds the checks to the registry and panics if an error occurs
+func (r *Registry) MustRegister(checks ...Check) {
+	if err := r.Register(checks...); err != nil {
+		panic(err)
+	}
+}
+
+// Liveness runs the liveness checks. Without liveness checks the process is considered alive
+func (r *Registry) Liveness() Report {
+	return r.report(Liveness)
+}
+
+// Readiness runs the readiness checks. During the graceful shutdown the report is down and the checks are not run
+func (r *Registry) Readiness() Report {
+	if r.ShuttingDown() {
+		return Report{Status: StatusDown, ShuttingDown: true}
+	}
+	return r.report(Readiness)
+}
+
+func (r *Registry) report(kind Kind) Report {
+	r.mu.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if c.Kind == kind {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp}
+	if len(checks) == 0 {
+		return report
+	}
+	report.Checks = make([]Result, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i, c := range checks {
+		go func(i int, c *check) {
+			defer wg.Done()
+			report.Checks[i] = c.result(r.config.CacheTTL)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		if result.Status == StatusDown && !result.Optional {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// StartShutdown marks the service as shutting down, so the readiness probe fails and the load balancers stop routing new requests to it
+func (r *Registry) StartShutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown returns true after StartShutdown was called
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// NotifyOnShutdown calls StartShutdown when the server shutdown starts (see http.Server.RegisterOnShutdown).
+// The server stops accepting connections at the same time, so, to give the load balancers the time to observe
+// the failing readiness probe before, use Shutdown
+func (r *Registry) NotifyOnShutdown(server *http.Server) {
+	server.RegisterOnShutdown(r.StartShutdown)
+}
+
+// Shutdown gracefully shuts down the server: the readiness probe starts failing, then, after the drain delay (or earlier if the context is done),
+// the server stops accepting new connections and waits for the active requests to complete (see http.Server.Shutdown)
+func (r *Registry) Shutdown(ctx context.Context, server *http.Server, drainDelay time.Duration) error {
+	r.StartShutdown()
+	if drainDelay > 0 {
+		timer := time.NewTimer(drainDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	return server.Shutdown(ctx)
+}
+
+// check is a registered Check with its last result
+type check struct {
+	Check
+	// serializes the runs, so the concurrent probes share the same result
+	mu        sync.Mutex
+	last      Result
+	expiresAt time.Time
+}
+
+// result returns the cached result, if not expired, or runs the check
+func (c *check) result(ttl time.Duration) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.expiresAt) {
+		return c.last
+	}
+	start := time.Now()
+	err := c.run()
+	c.last = Result{
+		Name:      c.Name,
+		Status:    StatusUp,
+		Optional:  c.Optional,
+		Duration:  time.Since(start),
+		CheckedAt: start,
+	}
+	if err != nil {
+		c.last.Status = StatusDown
+		c.last.Error = err.Error()
+	}
+	c.expiresAt = time.Now().Add(ttl)
+	return c.last
+}
+
+// run calls the check function with a timeout. The check runs with its own context, not with the one of the probe request,
+// because its result is shared with the other probes. If the function ignores the context, it is abandoned after the timeout
+func (c *check) run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errCh <- fmt.Errorf("check panic: %v", p)
+			}
+		}()
+		errCh <- c.Func(ctx)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timeout after %v", c.Timeout)
+	}
+}
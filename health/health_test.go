@@ -0,0 +1,236 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func up(ctx context.Context) error {
+	return nil
+}
+
+func down(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  []Check
+		wantErr bool
+	}{
+		{
+			name:   "valid checks",
+			checks: []Check{{Name: "db", Func: up}, {Name: "deadlock", Kind: Liveness, Func: up}},
+		},
+		{
+			name:    "empty name",
+			checks:  []Check{{Func: up}},
+			wantErr: true,
+		},
+		{
+			name:    "no function",
+			checks:  []Check{{Name: "db"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			checks:  []Check{{Name: "db", Func: up}, {Name: "db", Kind: Liveness, Func: up}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(Config{})
+			err := r.Register(tt.checks...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && len(r.checks) > 0 {
+				t.Errorf("Register() registered %d checks on error", len(r.checks))
+			}
+		})
+	}
+
+	r := NewRegistry(Config{})
+	r.MustRegister(Check{Name: "db", Func: up})
+	if err := r.Register(Check{Name: "db", Func: up}); err == nil {
+		t.Errorf("Register() an already registered check, want error")
+	}
+}
+
+func TestRegistry_Probes(t *testing.T) {
+	tests := []struct {
+		name          string
+		checks        []Check
+		wantLiveness  Status
+		wantReadiness Status
+		wantErrors    map[string]string
+	}{
+		{
+			name:          "no checks",
+			wantLiveness:  StatusUp,
+			wantReadiness: StatusUp,
+		},
+		{
+			name:          "passing checks",
+			checks:        []Check{{Name: "db", Func: up}, {Name: "deadlock", Kind: Liveness, Func: up}},
+			wantLiveness:  StatusUp,
+			wantReadiness: StatusUp,
+		},
+		{
+			name:          "failing readiness check",
+			checks:        []Check{{Name: "db", Func: down}, {Name: "deadlock", Kind: Liveness, Func: up}},
+			wantLiveness:  StatusUp,
+			wantReadiness: StatusDown,
+			wantErrors:    map[string]string{"db": "connection refused"},
+		},
+		{
+			name:          "failing liveness check",
+			checks:        []Check{{Name: "db", Func: up}, {Name: "deadlock", Kind: Liveness, Func: down}},
+			wantLiveness:  StatusDown,
+			wantReadiness: StatusUp,
+			wantErrors:    map[string]string{"deadlock": "connection refused"},
+		},
+		{
+			name:          "failing optional check",
+			checks:        []Check{{Name: "db", Func: up}, {Name: "cache", Func: down, Optional: true}},
+			wantLiveness:  StatusUp,
+			wantReadiness: StatusUp,
+			wantErrors:    map[string]string{"cache": "connection refused"},
+		},
+		{
+			name: "check timeout",
+			checks: []Check{{Name: "db", Timeout: 10 * time.Millisecond, Func: func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}}},
+			wantLiveness:  StatusUp,
+			wantReadiness: StatusDown,
+			wantErrors:    map[string]string{"db": "check timeout after 10ms"},
+		},
+		{
+			name: "check panic",
+			checks: []Check{{Name: "db", Func: func(ctx context.Context) error {
+				panic("nil connection")
+			}}},
+			wantLiveness:  StatusUp,
+			wantReadiness: StatusDown,
+			wantErrors:    map[string]string{"db": "check panic: nil connection"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(Config{})
+			r.MustRegister(tt.checks...)
+			liveness := r.Liveness()
+			if liveness.Status != tt.wantLiveness {
+				t.Errorf("Liveness() status got: %s, want: %s", liveness.Status, tt.wantLiveness)
+			}
+			readiness := r.Readiness()
+			if readiness.Status != tt.wantReadiness {
+				t.Errorf("Readiness() status got: %s, want: %s", readiness.Status, tt.wantReadiness)
+			}
+			gotErrors := map[string]string{}
+			for _, result := range append(liveness.Checks, readiness.Checks...) {
+				if result.Error != "" {
+					gotErrors[result.Name] = result.Error
+				}
+			}
+			if len(gotErrors) != len(tt.wantErrors) {
+				t.Fatalf("check errors got: %v, want: %v", gotErrors, tt.wantErrors)
+			}
+			for name, want := range tt.wantErrors {
+				if gotErrors[name] != want {
+					t.Errorf("check %s error got: %q, want: %q", name, gotErrors[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	var calls int32
+	r := NewRegistry(Config{CacheTTL: 50 * time.Millisecond})
+	r.MustRegister(Check{Name: "db", Func: func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}})
+
+	// the concurrent probes share the same run
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Readiness()
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("Readiness() check calls got: %d, want: 1", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	r.Readiness()
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Readiness() check calls after the cache expiration got: %d, want: 2", got)
+	}
+}
+
+func TestRegistry_Shutdown(t *testing.T) {
+	var calls int32
+	r := NewRegistry(Config{})
+	r.MustRegister(Check{Name: "db", Func: func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}})
+	server := httptest.NewServer(http.NotFoundHandler())
+	if got := r.Readiness(); got.Status != StatusUp {
+		t.Fatalf("Readiness() before shutdown status got: %s, want: %s", got.Status, StatusUp)
+	}
+
+	start := time.Now()
+	if err := r.Shutdown(context.Background(), server.Config, 20*time.Millisecond); err != nil {
+		t.Fatalf("Shutdown() unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Shutdown() returned after %v, before the drain delay", elapsed)
+	}
+	if !r.ShuttingDown() {
+		t.Errorf("ShuttingDown() got: false, want: true")
+	}
+	if got := r.Readiness(); got.Status != StatusDown || !got.ShuttingDown || len(got.Checks) > 0 {
+		t.Errorf("Readiness() during shutdown got: %+v, want down without checks", got)
+	}
+	if got := r.Liveness(); got.Status != StatusUp {
+		t.Errorf("Liveness() during shutdown status got: %s, want: %s", got.Status, StatusUp)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("check calls got: %d, want: 1", got)
+	}
+}
+
+func TestRegistry_NotifyOnShutdown(t *testing.T) {
+	r := NewRegistry(Config{})
+	server := httptest.NewServer(http.NotFoundHandler())
+	r.NotifyOnShutdown(server.Config)
+	if err := server.Config.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() unexpected error: %v", err)
+	}
+	// the shutdown hooks run in their own goroutines
+	deadline := time.Now().Add(time.Second)
+	for !r.ShuttingDown() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := r.Readiness(); got.Status != StatusDown {
+		t.Errorf("Readiness() after the server shutdown status got: %s, want: %s", got.Status, StatusDown)
+	}
+}
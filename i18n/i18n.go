@@ -0,0 +1,389 @@
+package i18n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	pathpkg "path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type ctxKey int
+
+// LocaleCtxKey is used to pass the request Locale to the request context.Context
+const LocaleCtxKey ctxKey = 1
+
+// CountArgName is the name of the argument that selects the plural form of a message. A count with a fractional part selects the "other" form
+const CountArgName = "count"
+
+// GetLocaleFromContext returns the Locale from the request context.Context or nil
+func GetLocaleFromContext(ctx context.Context) *Locale {
+	if ctx == nil {
+		return nil
+	}
+	if l, ok := ctx.Value(LocaleCtxKey).(*Locale); ok {
+		return l
+	}
+	return nil
+}
+
+// Localize translates a message using the Locale from the request context.Context.
+// The args are the placeholders values, as name-value pairs (for example: "name", "John", "count", 3) or as a single map[string]any.
+// If there is no Locale in the context or the message is missing, the key is returned, with the placeholders replaced
+func Localize(ctx context.Context, key string, args ...any) string {
+	if l := GetLocaleFromContext(ctx); l != nil {
+		return l.Localize(key, args...)
+	}
+	return interpolate(key, parseArgs(args))
+}
+
+// message is a catalog entry, either a simple text or a text with plural forms
+type message struct {
+	text   string
+	plural map[PluralCategory]string
+}
+
+// Catalog contains the messages for all the supported locales. A Catalog should be fully loaded before it is used,
+// because the methods that add messages are not safe for concurrent use.
+//
+// The messages are defined in JSON files, one per locale, where a message is a string or an object with the plural forms
+// (selected by the "count" argument). The nested objects are flattened, so that the keys are joined with dots:
+//
+//	{
+//	  "greeting": "Hello, {name}!",
+//	  "cart": {
+//	    "items": {"one": "{count} item", "other": "{count} items"}
+//	  }
+//	}
+type Catalog struct {
+	defaultLocale string
+	// the supported locales, as they were added
+	locales []string
+	// lower case locale -> message key -> message
+	messages map[string]map[string]message
+}
+
+// NewCatalog creates an empty Catalog. The default locale is used when no supported locale matches a request
+func NewCatalog(defaultLocale string) *Catalog {
+	return &Catalog{
+		defaultLocale: normalizeTag(defaultLocale),
+		messages:      map[string]map[string]message{},
+	}
+}
+
+// LoadCatalog creates a Catalog from the JSON files of a directory, where each file name (without extension) is a locale,
+// for example: locales/en.json, locales/pt-BR.json
+func LoadCatalog(fsys fs.FS, dir string, defaultLocale string) (*Catalog, error) {
+	names, err := fs.Glob(fsys, pathpkg.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the message catalogs from: %s, err: %w", dir, err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no message catalog found in: %s", dir)
+	}
+	c := NewCatalog(defaultLocale)
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the message catalog: %s, err: %w", name, err)
+		}
+		if err := c.AddJSON(strings.TrimSuffix(pathpkg.Base(name), ".json"), data); err != nil {
+			return nil, fmt.Errorf("failed to parse the message catalog: %s, err: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+// AddJSON adds the messages of a locale from a JSON document
+func (c *Catalog) AddJSON(locale string, data []byte) error {
+	var messages map[string]any
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return err
+	}
+	return c.AddMessages(locale, messages)
+}
+
+// AddMessages adds the messages of a locale. A value can be a string, a map with the plural forms or a map with nested messages
+func (c *Catalog) AddMessages(locale string, messages map[string]any) error {
+	locale = normalizeTag(locale)
+	lowerLocale := strings.ToLower(locale)
+	localeMessages, ok := c.messages[lowerLocale]
+	if !ok {
+		localeMessages = map[string]message{}
+		c.messages[lowerLocale] = localeMessages
+		c.locales = append(c.locales, locale)
+	}
+	return addMessages(localeMessages, "", messages)
+}
+
+func addMessages(dst map[string]message, prefix string, messages map[string]any) error {
+	for key, value := range messages {
+		fullKey := prefix + key
+		switch v := value.(type) {
+		case string:
+			dst[fullKey] = message{text: v}
+		case map[string]any:
+			if plural, ok := pluralForms(v); ok {
+				dst[fullKey] = message{plural: plural}
+			} else if err := addMessages(dst, fullKey+".", v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("the message: %s should be a string or an object, got: %T", fullKey, value)
+		}
+	}
+	return nil
+}
+
+// pluralForms returns the plural forms if all the map keys are plural categories and the "other" form exists
+func pluralForms(m map[string]any) (map[PluralCategory]string, bool) {
+	if _, ok := m[string(PluralOther)]; !ok {
+		return nil, false
+	}
+	plural := make(map[PluralCategory]string, len(m))
+	for k, v := range m {
+		switch PluralCategory(k) {
+		case PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther:
+		default:
+			return nil, false
+		}
+		text, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		plural[PluralCategory(k)] = text
+	}
+	return plural, true
+}
+
+// DefaultLocale returns the default locale
+func (c *Catalog) DefaultLocale() string {
+	return c.defaultLocale
+}
+
+// Locales returns the supported locales, sorted
+func (c *Catalog) Locales() []string {
+	locales := append([]string(nil), c.locales...)
+	sort.Strings(locales)
+	return locales
+}
+
+// Match returns the first supported locale that matches one of the tags, exactly or by the language (for example, "en-US"
+// matches "en", and "en" matches "en-GB" if "en" is not supported)
+func (c *Catalog) Match(tags ...string) (string, bool) {
+	for _, tag := range tags {
+		tag = strings.ToLower(normalizeTag(tag))
+		if len(tag) == 0 {
+			continue
+		}
+		if _, ok := c.messages[tag]; ok {
+			return c.canonicalLocale(tag), true
+		}
+		lang := baseLanguage(tag)
+		if _, ok := c.messages[lang]; ok {
+			return c.canonicalLocale(lang), true
+		}
+		for _, locale := range c.locales {
+			if baseLanguage(strings.ToLower(locale)) == lang {
+				return locale, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (c *Catalog) canonicalLocale(lowerLocale string) string {
+	for _, locale := range c.locales {
+		if strings.ToLower(locale) == lowerLocale {
+			return locale
+		}
+	}
+	return lowerLocale
+}
+
+// Locale returns the Locale for a tag. If the tag is not supported, the default locale is used
+func (c *Catalog) Locale(tag string) *Locale {
+	locale, ok := c.Match(tag)
+	if !ok {
+		locale = c.defaultLocale
+	}
+	return &Locale{
+		tag:     locale,
+		catalog: c,
+		rule:    pluralRuleFor(locale),
+	}
+}
+
+// lookup finds a message in the locale, in its language and in the default locale
+func (c *Catalog) lookup(locale string, key string) (message, bool) {
+	lowerLocale := strings.ToLower(locale)
+	for _, l := range []string{lowerLocale, baseLanguage(lowerLocale), strings.ToLower(c.defaultLocale)} {
+		if msg, ok := c.messages[l][key]; ok {
+			return msg, true
+		}
+	}
+	return message{}, false
+}
+
+// Locale translates the messages for a locale
+type Locale struct {
+	tag     string
+	catalog *Catalog
+	rule    PluralRule
+}
+
+// Tag returns the locale tag, for example "en" or "pt-BR"
+func (l *Locale) Tag() string {
+	return l.tag
+}
+
+// Localize translates a message, replacing the {name} placeholders with the args values. The args are name-value pairs
+// (for example: "name", "John", "count", 3) or a single map[string]any. If the message is missing, the key is returned
+func (l *Locale) Localize(key string, args ...any) string {
+	text, _ := l.Lookup(key, args...)
+	return text
+}
+
+// Lookup is like Localize but also returns false if the message is missing
+func (l *Locale) Lookup(key string, args ...any) (string, bool) {
+	values := parseArgs(args)
+	if l == nil || l.catalog == nil {
+		return interpolate(key, values), false
+	}
+	msg, ok := l.catalog.lookup(l.tag, key)
+	if !ok {
+		return interpolate(key, values), false
+	}
+	text := msg.text
+	if msg.plural != nil {
+		category := PluralOther
+		if count, ok := toInt64(values[CountArgName]); ok {
+			category = l.rule(count)
+		}
+		var found bool
+		if text, found = msg.plural[category]; !found {
+			text = msg.plural[PluralOther]
+		}
+	}
+	return interpolate(text, values), true
+}
+
+// Error is an error with a localizable message. The Error() method returns the key with the placeholders replaced,
+// and the message is translated by Localize when the error is written to the client, for example by middleware.ErrJsonResponse
+type Error struct {
+	Key  string
+	Args []any
+}
+
+// NewError creates an error with a localizable message
+func NewError(key string, args ...any) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return interpolate(e.Key, parseArgs(e.Args))
+}
+
+// Localize translates the error message for a Locale
+func (e *Error) Localize(l *Locale) string {
+	return l.Localize(e.Key, e.Args...)
+}
+
+// TemplateFunc returns the `t` template function that translates a message: {{t .Locale "greeting" "name" .User.Name}}
+// The first argument can be a *Locale, a context.Context that contains a Locale or a locale tag (resolved with the catalog, that can be nil otherwise)
+func TemplateFunc(catalog *Catalog) func(locale any, key string, args ...any) string {
+	return func(locale any, key string, args ...any) string {
+		switch l := locale.(type) {
+		case *Locale:
+			return l.Localize(key, args...)
+		case context.Context:
+			return Localize(l, key, args...)
+		case string:
+			if catalog != nil {
+				return catalog.Locale(l).Localize(key, args...)
+			}
+		}
+		return interpolate(key, parseArgs(args))
+	}
+}
+
+// parseArgs converts the name-value pairs or a single map[string]any to a map
+func parseArgs(args []any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	if len(args) == 1 {
+		if m, ok := args[0].(map[string]any); ok {
+			return m
+		}
+	}
+	values := make(map[string]any, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		if name, ok := args[i].(string); ok {
+			values[name] = args[i+1]
+		}
+	}
+	return values
+}
+
+// interpolate replaces the {name} placeholders. The unknown placeholders are kept as they are
+func interpolate(text string, values map[string]any) string {
+	if len(values) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		value, ok := values[text[start+1:end]]
+		if !ok {
+			sb.WriteString(text[:start+1])
+			text = text[start+1:]
+			continue
+		}
+		sb.WriteString(text[:start])
+		sb.WriteString(fmt.Sprint(value))
+		text = text[end+1:]
+	}
+	sb.WriteString(text)
+	return sb.String()
+}
+
+// toInt64 converts an integer count. A float count is converted only if it has no fractional part,
+// so that, for example, 1.5 selects the PluralOther form instead of the PluralOne one
+func toInt64(value any) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return int64(f), true
+		}
+	}
+	return 0, false
+}
+
+// normalizeTag replaces the underscores with hyphens, for example pt_BR -> pt-BR
+func normalizeTag(tag string) string {
+	return strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+}
+
+// baseLanguage returns the language subtag, for example "pt" for "pt-br"
+func baseLanguage(tag string) string {
+	lang, _, _ := strings.Cut(tag, "-")
+	return lang
+}
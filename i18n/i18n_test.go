@@ -0,0 +1,176 @@
+package i18n
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestCatalog(t *testing.T) *Catalog {
+	fsys := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{
+			"greeting": "Hello, {name}!",
+			"cart": {"items": {"zero": "empty", "one": "{count} item", "other": "{count} items"}},
+			"only.en": "english only"
+		}`)},
+		"locales/fr.json":    {Data: []byte(`{"greeting": "Bonjour, {name} !", "cart": {"items": {"one": "{count} article", "other": "{count} articles"}}}`)},
+		"locales/pt_BR.json": {Data: []byte(`{"greeting": "Olá, {name}!"}`)},
+		"locales/ru.json":    {Data: []byte(`{"files": {"one": "{count} файл", "few": "{count} файла", "many": "{count} файлов", "other": "{count} файла"}}`)},
+		"locales/README.md":  {Data: []byte(`not a catalog`)},
+	}
+	c, err := LoadCatalog(fsys, "locales", "en")
+	if err != nil {
+		t.Fatalf("LoadCatalog() unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestLoadCatalog(t *testing.T) {
+	c := newTestCatalog(t)
+	if got, want := c.Locales(), []string{"en", "fr", "pt-BR", "ru"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Locales() got: %v, want: %v", got, want)
+	}
+	if c.DefaultLocale() != "en" {
+		t.Errorf("DefaultLocale() got: %q, want: en", c.DefaultLocale())
+	}
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{name: "no catalog", fsys: fstest.MapFS{"locales/en.txt": {Data: []byte(`{}`)}}},
+		{name: "invalid json", fsys: fstest.MapFS{"locales/en.json": {Data: []byte(`{`)}}},
+		{name: "invalid message", fsys: fstest.MapFS{"locales/en.json": {Data: []byte(`{"count": 1}`)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadCatalog(tt.fsys, "locales", "en"); err == nil {
+				t.Errorf("LoadCatalog() want error but got nil")
+			}
+		})
+	}
+}
+
+func TestLocale_Localize(t *testing.T) {
+	c := newTestCatalog(t)
+	tests := []struct {
+		name   string
+		locale string
+		key    string
+		args   []any
+		want   string
+		found  bool
+	}{
+		{name: "simple message", locale: "en", key: "greeting", args: []any{"name", "John"}, want: "Hello, John!", found: true},
+		{name: "args as map", locale: "fr", key: "greeting", args: []any{map[string]any{"name": "Jean"}}, want: "Bonjour, Jean !", found: true},
+		{name: "region locale", locale: "pt-BR", key: "greeting", args: []any{"name", "João"}, want: "Olá, João!", found: true},
+		{name: "missing placeholder value", locale: "en", key: "greeting", args: []any{"other", 1}, want: "Hello, {name}!", found: true},
+		{name: "plural one", locale: "en", key: "cart.items", args: []any{"count", 1}, want: "1 item", found: true},
+		{name: "plural other", locale: "en", key: "cart.items", args: []any{"count", 5}, want: "5 items", found: true},
+		{name: "plural float one", locale: "en", key: "cart.items", args: []any{"count", 1.0}, want: "1 item", found: true},
+		{name: "plural float with fraction", locale: "en", key: "cart.items", args: []any{"count", 1.5}, want: "1.5 items", found: true},
+		{name: "plural category not defined", locale: "en", key: "cart.items", args: []any{"count", 0}, want: "0 items", found: true},
+		{name: "plural without count", locale: "en", key: "cart.items", want: "{count} items", found: true},
+		{name: "plural french zero", locale: "fr", key: "cart.items", args: []any{"count", 0}, want: "0 article", found: true},
+		{name: "plural russian few", locale: "ru", key: "files", args: []any{"count", 22}, want: "22 файла", found: true},
+		{name: "plural russian many", locale: "ru", key: "files", args: []any{"count", uint8(11)}, want: "11 файлов", found: true},
+		{name: "fallback to the default locale", locale: "fr", key: "only.en", want: "english only", found: true},
+		{name: "unsupported locale", locale: "de", key: "greeting", args: []any{"name", "Hans"}, want: "Hello, Hans!", found: true},
+		{name: "missing message", locale: "fr", key: "missing {name}", args: []any{"name", "key"}, want: "missing key", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := c.Locale(tt.locale).Lookup(tt.key, tt.args...)
+			if got != tt.want || found != tt.found {
+				t.Errorf("Lookup() got: %q %v, want: %q %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	c := newTestCatalog(t)
+	ctx := context.WithValue(context.Background(), LocaleCtxKey, c.Locale("fr"))
+	if got := Localize(ctx, "greeting", "name", "Jean"); got != "Bonjour, Jean !" {
+		t.Errorf("Localize() got: %q", got)
+	}
+	if got := Localize(context.Background(), "greeting", "name", "Jean"); got != "greeting" {
+		t.Errorf("Localize() without locale got: %q", got)
+	}
+	if GetLocaleFromContext(nil) != nil {
+		t.Errorf("GetLocaleFromContext(nil) want nil")
+	}
+}
+
+func TestTemplateFunc(t *testing.T) {
+	c := newTestCatalog(t)
+	tf := TemplateFunc(c)
+	ctx := context.WithValue(context.Background(), LocaleCtxKey, c.Locale("pt-br"))
+	tests := []struct {
+		name   string
+		locale any
+		want   string
+	}{
+		{name: "locale", locale: c.Locale("fr"), want: "Bonjour, Ana !"},
+		{name: "context", locale: ctx, want: "Olá, Ana!"},
+		{name: "tag", locale: "en-US", want: "Hello, Ana!"},
+		{name: "nil", locale: nil, want: "greeting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tf(tt.locale, "greeting", "name", "Ana"); got != tt.want {
+				t.Errorf("t() got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	c := newTestCatalog(t)
+	err := NewError("greeting", "name", "Jean")
+	if err.Error() != "greeting" {
+		t.Errorf("Error() got: %q", err.Error())
+	}
+	if got := err.Localize(c.Locale("fr")); got != "Bonjour, Jean !" {
+		t.Errorf("Localize() got: %q", got)
+	}
+}
+
+func TestRegisterPluralRule(t *testing.T) {
+	RegisterPluralRule("XX", func(n int64) PluralCategory {
+		return PluralMany
+	})
+	if got := pluralRuleFor("xx-YY")(1); got != PluralMany {
+		t.Errorf("pluralRuleFor() got: %v, want: %v", got, PluralMany)
+	}
+	tests := []struct {
+		locale string
+		n      int64
+		want   PluralCategory
+	}{
+		{locale: "en", n: 1, want: PluralOne},
+		{locale: "en-GB", n: 2, want: PluralOther},
+		{locale: "pt-PT", n: 0, want: PluralOther},
+		{locale: "pt-BR", n: 0, want: PluralOne},
+		{locale: "ja", n: 1, want: PluralOther},
+		{locale: "pl", n: 22, want: PluralFew},
+		{locale: "pl", n: 21, want: PluralMany},
+		{locale: "cs", n: 3, want: PluralFew},
+		{locale: "ru", n: 5, want: PluralMany},
+		{locale: "sr", n: 21, want: PluralOne},
+		{locale: "hr", n: 23, want: PluralFew},
+		{locale: "bs", n: 5, want: PluralOther},
+		{locale: "sr-Latn", n: 11, want: PluralOther},
+		{locale: "ro", n: 101, want: PluralOther},
+		{locale: "ro", n: 112, want: PluralFew},
+		{locale: "ar", n: 2, want: PluralTwo},
+		{locale: "ar", n: 111, want: PluralMany},
+		{locale: "unknown", n: 1, want: PluralOne},
+	}
+	for _, tt := range tests {
+		if got := pluralRuleFor(tt.locale)(tt.n); got != tt.want {
+			t.Errorf("pluralRuleFor(%q)(%d) got: %v, want: %v", tt.locale, tt.n, got, tt.want)
+		}
+	}
+}
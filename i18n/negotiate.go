@@ -0,0 +1,137 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocaleSource is a place from where the request locale can be resolved
+type LocaleSource int
+
+const (
+	// PathPrefix resolves the locale from the first path segment, for example /fr/products
+	PathPrefix LocaleSource = iota
+	// QueryParam resolves the locale from a query parameter, for example /products?lang=fr
+	QueryParam
+	// Cookie resolves the locale from a cookie
+	Cookie
+	// AcceptLanguage resolves the locale from the Accept-Language header
+	AcceptLanguage
+)
+
+const defaultLocaleParamName = "lang"
+
+// ResolverConfig configures how the request locale is resolved
+type ResolverConfig struct {
+	// Sources are checked in order, until a supported locale is found. Default: QueryParam, Cookie, AcceptLanguage
+	Sources []LocaleSource
+	// QueryParamName is the name of the query parameter. Default: lang
+	QueryParamName string
+	// CookieName is the name of the cookie. Default: lang
+	CookieName string
+}
+
+func (c *ResolverConfig) setDefaults() {
+	if len(c.Sources) == 0 {
+		c.Sources = []LocaleSource{QueryParam, Cookie, AcceptLanguage}
+	}
+	if len(c.QueryParamName) == 0 {
+		c.QueryParamName = defaultLocaleParamName
+	}
+	if len(c.CookieName) == 0 {
+		c.CookieName = defaultLocaleParamName
+	}
+}
+
+// ResolveLocale returns the request Locale, from the first source that contains a supported locale, or the default locale
+func (c *Catalog) ResolveLocale(req *http.Request, config ResolverConfig) *Locale {
+	config.setDefaults()
+	for _, source := range config.Sources {
+		var tags []string
+		switch source {
+		case PathPrefix:
+			if segment, _, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/"); len(segment) > 0 {
+				tags = []string{segment}
+			}
+		case QueryParam:
+			if lang := req.URL.Query().Get(config.QueryParamName); len(lang) > 0 {
+				tags = []string{lang}
+			}
+		case Cookie:
+			if cookie, err := req.Cookie(config.CookieName); err == nil && len(cookie.Value) > 0 {
+				tags = []string{cookie.Value}
+			}
+		case AcceptLanguage:
+			tags = ParseAcceptLanguage(req.Header.Get("Accept-Language"))
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		if source == PathPrefix {
+			// a path segment should match exactly, otherwise a path like /english/... would be considered a locale
+			if _, ok := c.messages[strings.ToLower(normalizeTag(tags[0]))]; ok {
+				return c.Locale(tags[0])
+			}
+			continue
+		}
+		if locale, ok := c.Match(tags...); ok {
+			return c.Locale(locale)
+		}
+	}
+	return c.Locale(c.defaultLocale)
+}
+
+// VaryHeaders returns the request headers used to resolve the locale, that should be added to the response Vary header
+func (c ResolverConfig) VaryHeaders() []string {
+	c.setDefaults()
+	var headers []string
+	for _, source := range c.Sources {
+		switch source {
+		case Cookie:
+			headers = append(headers, "Cookie")
+		case AcceptLanguage:
+			headers = append(headers, "Accept-Language")
+		}
+	}
+	return headers
+}
+
+// ParseAcceptLanguage returns the language tags from an Accept-Language header, sorted by their quality value.
+// The wildcard and the tags with a zero quality value are ignored
+func ParseAcceptLanguage(header string) []string {
+	type weightedTag struct {
+		tag string
+		q   float64
+	}
+	var weightedTags []weightedTag
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if len(tag) == 0 || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.TrimSpace(name) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		weightedTags = append(weightedTags, weightedTag{tag: tag, q: q})
+	}
+	sort.SliceStable(weightedTags, func(i, j int) bool {
+		return weightedTags[i].q > weightedTags[j].q
+	})
+	tags := make([]string, len(weightedTags))
+	for i, wt := range weightedTags {
+		tags[i] = wt.tag
+	}
+	return tags
+}
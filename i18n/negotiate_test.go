@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5", want: []string{"fr-CH", "fr", "en", "de"}},
+		{header: "en;q=0.5, ro, de;q=0", want: []string{"ro", "en"}},
+		{header: "en;q=invalid, fr;q=0.1", want: []string{"en", "fr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAcceptLanguage() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalog_ResolveLocale(t *testing.T) {
+	c := newTestCatalog(t)
+	tests := []struct {
+		name   string
+		url    string
+		cookie string
+		header string
+		config ResolverConfig
+		want   string
+	}{
+		{name: "default locale", url: "/", want: "en"},
+		{name: "query param", url: "/?lang=fr", cookie: "ru", header: "pt-BR", want: "fr"},
+		{name: "unsupported query param", url: "/?lang=de", cookie: "ru", want: "ru"},
+		{name: "cookie", url: "/", cookie: "pt_br", header: "fr", want: "pt-BR"},
+		{name: "accept language", url: "/", header: "de-DE, pt;q=0.8, fr;q=0.5", want: "pt-BR"},
+		{name: "custom query param", url: "/?locale=fr&lang=ru", config: ResolverConfig{QueryParamName: "locale"}, want: "fr"},
+		{name: "custom cookie", url: "/", cookie: "fr", config: ResolverConfig{CookieName: "locale"}, want: "en"},
+		{name: "path prefix", url: "/fr/products", header: "ru", config: ResolverConfig{Sources: []LocaleSource{PathPrefix, AcceptLanguage}}, want: "fr"},
+		{name: "path prefix is not a locale", url: "/english/products", header: "ru", config: ResolverConfig{Sources: []LocaleSource{PathPrefix, AcceptLanguage}}, want: "ru"},
+		{name: "path prefix with a language only", url: "/pt/products", config: ResolverConfig{Sources: []LocaleSource{PathPrefix}}, want: "en"},
+		{name: "accept language before query param", url: "/?lang=fr", header: "ru", config: ResolverConfig{Sources: []LocaleSource{AcceptLanguage, QueryParam}}, want: "ru"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if len(tt.cookie) > 0 {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			if len(tt.header) > 0 {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+			if got := c.ResolveLocale(req, tt.config).Tag(); got != tt.want {
+				t.Errorf("ResolveLocale() got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolverConfig_VaryHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ResolverConfig
+		want   []string
+	}{
+		{name: "default sources", want: []string{"Cookie", "Accept-Language"}},
+		{name: "url sources", config: ResolverConfig{Sources: []LocaleSource{PathPrefix, QueryParam}}},
+		{name: "custom sources", config: ResolverConfig{Sources: []LocaleSource{AcceptLanguage, PathPrefix}}, want: []string{"Accept-Language"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.VaryHeaders(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VaryHeaders() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
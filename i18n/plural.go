@@ -0,0 +1,172 @@
+package i18n
+
+import (
+	"strings"
+	"sync"
+)
+
+// PluralCategory is a CLDR plural category
+type PluralCategory string
+
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// A PluralRule returns the plural category of a count
+type PluralRule func(n int64) PluralCategory
+
+var (
+	pluralRulesMu sync.RWMutex
+	pluralRules   = map[string]PluralRule{}
+)
+
+func init() {
+	for _, lang := range []string{"en", "de", "nl", "sv", "da", "nb", "nn", "no", "fi", "et", "it", "es", "ca", "el", "hu", "bg", "tr", "pt-pt", "sq", "eu", "gl"} {
+		pluralRules[lang] = pluralRuleOneOther
+	}
+	for _, lang := range []string{"fr", "pt", "hy"} {
+		pluralRules[lang] = pluralRuleZeroOneOther
+	}
+	for _, lang := range []string{"ja", "zh", "ko", "vi", "th", "id", "ms"} {
+		pluralRules[lang] = pluralRuleOther
+	}
+	for _, lang := range []string{"ru", "uk", "be"} {
+		pluralRules[lang] = pluralRuleEastSlavic
+	}
+	for _, lang := range []string{"sr", "hr", "bs"} {
+		pluralRules[lang] = pluralRuleSerboCroatian
+	}
+	pluralRules["pl"] = pluralRulePolish
+	pluralRules["cs"] = pluralRuleCzech
+	pluralRules["sk"] = pluralRuleCzech
+	pluralRules["ro"] = pluralRuleRomanian
+	pluralRules["ar"] = pluralRuleArabic
+}
+
+// RegisterPluralRule registers (or replaces) the plural rule for a language (for example "en") or a locale (for example "pt-PT")
+func RegisterPluralRule(lang string, rule PluralRule) {
+	pluralRulesMu.Lock()
+	defer pluralRulesMu.Unlock()
+	pluralRules[strings.ToLower(lang)] = rule
+}
+
+// pluralRuleFor returns the plural rule of a locale, of its language or, if none is registered, the one/other rule
+func pluralRuleFor(locale string) PluralRule {
+	pluralRulesMu.RLock()
+	defer pluralRulesMu.RUnlock()
+	locale = strings.ToLower(locale)
+	if rule, ok := pluralRules[locale]; ok {
+		return rule
+	}
+	if rule, ok := pluralRules[baseLanguage(locale)]; ok {
+		return rule
+	}
+	return pluralRuleOneOther
+}
+
+func pluralRuleOther(n int64) PluralCategory {
+	return PluralOther
+}
+
+func pluralRuleOneOther(n int64) PluralCategory {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralRuleZeroOneOther(n int64) PluralCategory {
+	if n == 0 || n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralRuleEastSlavic(n int64) PluralCategory {
+	n = abs(n)
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+func pluralRuleSerboCroatian(n int64) PluralCategory {
+	n = abs(n)
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	default:
+		return PluralOther
+	}
+}
+
+func pluralRulePolish(n int64) PluralCategory {
+	n = abs(n)
+	switch {
+	case n == 1:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+func pluralRuleCzech(n int64) PluralCategory {
+	switch {
+	case n == 1:
+		return PluralOne
+	case n >= 2 && n <= 4:
+		return PluralFew
+	default:
+		return PluralOther
+	}
+}
+
+func pluralRuleRomanian(n int64) PluralCategory {
+	n = abs(n)
+	switch {
+	case n == 1:
+		return PluralOne
+	case n == 0 || (n%100 >= 2 && n%100 <= 19):
+		return PluralFew
+	default:
+		return PluralOther
+	}
+}
+
+func pluralRuleArabic(n int64) PluralCategory {
+	n = abs(n)
+	switch {
+	case n == 0:
+		return PluralZero
+	case n == 1:
+		return PluralOne
+	case n == 2:
+		return PluralTwo
+	case n%100 >= 3 && n%100 <= 10:
+		return PluralFew
+	case n%100 >= 11 && n%100 <= 99:
+		return PluralMany
+	default:
+		return PluralOther
+	}
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
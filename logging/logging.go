@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/auth"
+)
+
+type ctxKey int
+
+// LoggerCtxKey is used to pass the request scoped Logger to the request context.Context
+const LoggerCtxKey ctxKey = 1
+
+// The names of the fields added by the framework to the request scoped Logger
+const (
+	FieldMethod    = "method"
+	FieldRoute     = "route"
+	FieldRequestId = "requestId"
+	FieldPrincipal = "principal"
+	FieldError     = "err"
+)
+
+// A Level is the importance of a log message
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// A Logger logs messages with a level and key/value fields, for example:
+//
+//	logger.Warn("payment declined", "orderId", 10, "amount", 99.5)
+//
+// The keys should be strings, and a key without a value is logged with the "!MISSING" value
+type Logger interface {
+	Debug(msg string, keyvals ...any)
+	Info(msg string, keyvals ...any)
+	Warn(msg string, keyvals ...any)
+	Error(msg string, keyvals ...any)
+	// With returns a Logger that adds the key/value fields to all the messages
+	With(keyvals ...any) Logger
+}
+
+// DefaultLogger is the Logger used when no Logger is configured. It writes the messages, starting with the LevelInfo,
+// to the standard log package
+var DefaultLogger Logger = NewStdLogger(nil, LevelInfo)
+
+// GetLoggerFromContext returns the request scoped Logger from the context.Context or, if missing, the DefaultLogger
+func GetLoggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(LoggerCtxKey).(Logger); ok {
+		return logger
+	}
+	return DefaultLogger
+}
+
+// WithFields returns a copy of the context.Context where the request scoped Logger has the key/value fields
+func WithFields(ctx context.Context, keyvals ...any) context.Context {
+	return context.WithValue(ctx, LoggerCtxKey, GetLoggerFromContext(ctx).With(keyvals...))
+}
+
+// WithPrincipal returns a copy of the context.Context where the request scoped Logger has the identity of the auth.SecurityPrincipal
+func WithPrincipal(ctx context.Context, principal auth.SecurityPrincipal) context.Context {
+	if principal == nil {
+		return ctx
+	}
+	return WithFields(ctx, FieldPrincipal, principal.Identity())
+}
+
+type nopLogger struct{}
+
+func (n nopLogger) Debug(msg string, keyvals ...any) {}
+func (n nopLogger) Info(msg string, keyvals ...any)  {}
+func (n nopLogger) Warn(msg string, keyvals ...any)  {}
+func (n nopLogger) Error(msg string, keyvals ...any) {}
+func (n nopLogger) With(keyvals ...any) Logger {
+	return n
+}
+
+// NopLogger returns a Logger that discards all the messages
+func NopLogger() Logger {
+	return nopLogger{}
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"github.com/ixtendio/gofre/auth"
+	"log"
+	"testing"
+)
+
+func TestGetLoggerFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), LevelInfo)
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "default logger",
+			ctx:  context.Background(),
+		},
+		{
+			name: "request scoped logger",
+			ctx:  context.WithValue(context.Background(), LoggerCtxKey, logger.With(FieldMethod, "GET")),
+			want: "level=INFO msg=message method=GET\n",
+		},
+		{
+			name: "with fields",
+			ctx:  WithFields(context.WithValue(context.Background(), LoggerCtxKey, logger), FieldRequestId, "abc"),
+			want: "level=INFO msg=message requestId=abc\n",
+		},
+		{
+			name: "with principal",
+			ctx:  WithPrincipal(context.WithValue(context.Background(), LoggerCtxKey, logger), &auth.User{Id: "10"}),
+			want: "level=INFO msg=message principal=10\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			got := GetLoggerFromContext(tt.ctx)
+			if tt.want == "" {
+				if got != DefaultLogger {
+					t.Fatalf("GetLoggerFromContext() got: %v, want: DefaultLogger", got)
+				}
+				return
+			}
+			got.Info("message")
+			if buf.String() != tt.want {
+				t.Errorf("GetLoggerFromContext() logged: %q, want: %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
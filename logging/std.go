@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const missingValue = "!MISSING"
+
+var builderPool = sync.Pool{
+	New: func() interface{} {
+		return &strings.Builder{}
+	},
+}
+
+// StdLogger is a Logger adapter for the standard log package, that writes the messages in the logfmt format, for example:
+//
+//	level=WARN msg="payment declined" orderId=10 amount=99.5
+type StdLogger struct {
+	out    *log.Logger
+	level  Level
+	fields []any
+}
+
+// NewStdLogger creates a StdLogger that writes the messages, starting with the minimum level, to a log.Logger.
+// If the log.Logger is nil, the standard logger (log.Default()) is used
+func NewStdLogger(out *log.Logger, level Level) *StdLogger {
+	return &StdLogger{
+		out:   out,
+		level: level,
+	}
+}
+
+func (l *StdLogger) Debug(msg string, keyvals ...any) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+func (l *StdLogger) Info(msg string, keyvals ...any) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+func (l *StdLogger) Warn(msg string, keyvals ...any) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+func (l *StdLogger) Error(msg string, keyvals ...any) {
+	l.log(LevelError, msg, keyvals)
+}
+
+func (l *StdLogger) With(keyvals ...any) Logger {
+	return &StdLogger{
+		out:    l.out,
+		level:  l.level,
+		fields: appendFields(l.fields, keyvals),
+	}
+}
+
+func (l *StdLogger) log(level Level, msg string, keyvals []any) {
+	if level < l.level {
+		return
+	}
+	sb := builderPool.Get().(*strings.Builder)
+	defer func() {
+		sb.Reset()
+		builderPool.Put(sb)
+	}()
+	sb.WriteString("level=")
+	sb.WriteString(level.String())
+	sb.WriteString(" msg=")
+	writeValue(sb, msg)
+	writeFields(sb, l.fields)
+	writeFields(sb, keyvals)
+	out := l.out
+	if out == nil {
+		out = log.Default()
+	}
+	out.Output(3, sb.String())
+}
+
+// ErrFuncLogger is a Logger adapter for the error log functions, like the gofre.Config.ErrLogFunc.
+// Only the LevelError messages are logged, as errors that wrap the first error field value, if any
+type ErrFuncLogger struct {
+	errLogFunc func(err error)
+	fields     []any
+}
+
+// NewErrFuncLogger creates an ErrFuncLogger for an error log function
+func NewErrFuncLogger(errLogFunc func(err error)) *ErrFuncLogger {
+	return &ErrFuncLogger{errLogFunc: errLogFunc}
+}
+
+func (l *ErrFuncLogger) Debug(msg string, keyvals ...any) {}
+func (l *ErrFuncLogger) Info(msg string, keyvals ...any)  {}
+func (l *ErrFuncLogger) Warn(msg string, keyvals ...any)  {}
+
+func (l *ErrFuncLogger) Error(msg string, keyvals ...any) {
+	var sb strings.Builder
+	sb.WriteString(msg)
+	writeFields(&sb, l.fields)
+	writeFields(&sb, keyvals)
+	var cause error
+	for _, fields := range [][]any{keyvals, l.fields} {
+		for i := 1; i < len(fields) && cause == nil; i += 2 {
+			cause, _ = fields[i].(error)
+		}
+	}
+	if cause != nil {
+		l.errLogFunc(&fieldsError{msg: sb.String(), cause: cause})
+		return
+	}
+	l.errLogFunc(errors.New(sb.String()))
+}
+
+func (l *ErrFuncLogger) With(keyvals ...any) Logger {
+	return &ErrFuncLogger{
+		errLogFunc: l.errLogFunc,
+		fields:     appendFields(l.fields, keyvals),
+	}
+}
+
+// fieldsError is the error logged by the ErrFuncLogger, that wraps the error field value
+type fieldsError struct {
+	msg   string
+	cause error
+}
+
+func (e *fieldsError) Error() string {
+	return e.msg
+}
+
+func (e *fieldsError) Unwrap() error {
+	return e.cause
+}
+
+func appendFields(fields []any, keyvals []any) []any {
+	result := make([]any, 0, len(fields)+len(keyvals)+1)
+	result = append(result, fields...)
+	result = append(result, keyvals...)
+	if len(keyvals)%2 != 0 {
+		result = append(result, missingValue)
+	}
+	return result
+}
+
+func writeFields(sb *strings.Builder, keyvals []any) {
+	for i := 0; i < len(keyvals); i += 2 {
+		sb.WriteByte(' ')
+		sb.WriteString(fmt.Sprint(keyvals[i]))
+		sb.WriteByte('=')
+		if i+1 < len(keyvals) {
+			writeValue(sb, keyvals[i+1])
+		} else {
+			sb.WriteString(missingValue)
+		}
+	}
+}
+
+// writeValue writes a value, quoting it if it contains spaces, quotes, an equal sign or control chars
+func writeValue(sb *strings.Builder, value any) {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case error:
+		str = v.Error()
+	case fmt.Stringer:
+		str = v.String()
+	default:
+		str = fmt.Sprint(v)
+	}
+	if len(str) == 0 || strings.IndexFunc(str, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f
+	}) >= 0 {
+		sb.WriteString(strconv.Quote(str))
+		return
+	}
+	sb.WriteString(str)
+}
@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   Level
+		logFunc func(logger Logger)
+		want    string
+	}{
+		{
+			name:  "message with fields",
+			level: LevelInfo,
+			logFunc: func(logger Logger) {
+				logger.Warn("payment declined", "orderId", 10, "amount", 99.5)
+			},
+			want: "level=WARN msg=\"payment declined\" orderId=10 amount=99.5\n",
+		},
+		{
+			name:  "logger fields",
+			level: LevelDebug,
+			logFunc: func(logger Logger) {
+				logger.With(FieldMethod, "GET", FieldRoute, "/users/{id}").Debug("user loaded", "id", "10")
+			},
+			want: "level=DEBUG msg=\"user loaded\" method=GET route=/users/{id} id=10\n",
+		},
+		{
+			name:  "quoted values",
+			level: LevelInfo,
+			logFunc: func(logger Logger) {
+				logger.Error("failed", FieldError, errors.New(`open "a.txt": no such file`), "empty", "", "eq", "a=b")
+			},
+			want: "level=ERROR msg=failed err=\"open \\\"a.txt\\\": no such file\" empty=\"\" eq=\"a=b\"\n",
+		},
+		{
+			name:  "missing value",
+			level: LevelInfo,
+			logFunc: func(logger Logger) {
+				logger.With("key").Info("message", "other")
+			},
+			want: "level=INFO msg=message key=!MISSING other=!MISSING\n",
+		},
+		{
+			name:  "messages below the level are discarded",
+			level: LevelWarn,
+			logFunc: func(logger Logger) {
+				logger.Info("message")
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.logFunc(NewStdLogger(log.New(&buf, "", 0), tt.level))
+			if got := buf.String(); got != tt.want {
+				t.Errorf("StdLogger got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrFuncLogger(t *testing.T) {
+	cause := errors.New("connection refused")
+	tests := []struct {
+		name      string
+		logFunc   func(logger Logger)
+		wantMsg   string
+		wantCause error
+	}{
+		{
+			name: "error with cause",
+			logFunc: func(logger Logger) {
+				logger.With(FieldMethod, "POST").Error("uncaught error", FieldError, fmt.Errorf("save: %w", cause))
+			},
+			wantMsg:   "uncaught error method=POST err=\"save: connection refused\"",
+			wantCause: cause,
+		},
+		{
+			name: "error without cause",
+			logFunc: func(logger Logger) {
+				logger.Error("uncaught error", "code", 10)
+			},
+			wantMsg: "uncaught error code=10",
+		},
+		{
+			name: "the other levels are discarded",
+			logFunc: func(logger Logger) {
+				logger.Debug("debug")
+				logger.Info("info")
+				logger.Warn("warn")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got error
+			tt.logFunc(NewErrFuncLogger(func(err error) {
+				got = err
+			}))
+			if tt.wantMsg == "" {
+				if got != nil {
+					t.Fatalf("ErrFuncLogger got: %v, want: nil", got)
+				}
+				return
+			}
+			if got == nil || got.Error() != tt.wantMsg {
+				t.Fatalf("ErrFuncLogger got: %v, want: %s", got, tt.wantMsg)
+			}
+			if tt.wantCause != nil && !errors.Is(got, tt.wantCause) {
+				t.Errorf("ErrFuncLogger got: %v, want to wrap: %v", got, tt.wantCause)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/router"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ContentType is the Content-Type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// the route label value of the requests that don't match any route, to limit the number of series
+const unmatchedRoute = "unmatched"
+
+const eventStreamMediaType = "text/event-stream"
+
+// HttpMetrics records the RED (rate, errors, duration) metrics of the HTTP requests, labelled by the method, the route pattern
+// (not the raw URL) and the status class (2xx, 4xx and so on), as well as the number of the in-flight requests, SSE and hijacked connections.
+// It implements router.RequestObserver
+type HttpMetrics struct {
+	Requests          *Counter
+	Errors            *Counter
+	Duration          *Histogram
+	InFlight          *Gauge
+	SSEConnections    *Gauge
+	HijackedRequests  *Counter
+	ResponseBodyBytes *Counter
+}
+
+// NewHttpMetrics creates the HTTP metrics and registers them in the registry, with the duration buckets (DefaultBuckets if empty)
+func NewHttpMetrics(registry *Registry, buckets []float64) (*HttpMetrics, error) {
+	m := &HttpMetrics{
+		Requests:          NewCounter("http_requests_total", "The number of HTTP requests served.", "method", "route", "status"),
+		Errors:            NewCounter("http_request_errors_total", "The number of HTTP requests that failed with a server error (5xx) or a panic.", "method", "route", "status"),
+		Duration:          NewHistogram("http_request_duration_seconds", "The HTTP request duration, up to the last byte of the response.", buckets, "method", "route", "status"),
+		InFlight:          NewGauge("http_requests_in_flight", "The number of HTTP requests being served."),
+		SSEConnections:    NewGauge("http_sse_connections", "The number of open server-sent events connections."),
+		HijackedRequests:  NewCounter("http_hijacked_connections_total", "The number of hijacked HTTP connections, for example by WebSockets."),
+		ResponseBodyBytes: NewCounter("http_response_body_bytes_total", "The number of HTTP response body bytes written.", "method", "route"),
+	}
+	if err := registry.Register(m.Requests, m.Errors, m.Duration, m.InFlight, m.SSEConnections, m.HijackedRequests, m.ResponseBodyBytes); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *HttpMetrics) RequestReceived(req *http.Request) {
+	m.InFlight.Inc()
+}
+
+func (m *HttpMetrics) ResponseStarted(req *http.Request, statusCode int, header http.Header) {
+	if isEventStream(header.Get(response.HeaderContentType)) {
+		m.SSEConnections.Inc()
+	}
+}
+
+func (m *HttpMetrics) RequestServed(req *http.Request, stats router.WriteStats) {
+	m.InFlight.Dec()
+	if isEventStream(stats.ContentType) {
+		m.SSEConnections.Dec()
+	}
+	if stats.Hijacked {
+		m.HijackedRequests.Inc()
+	}
+	method := methodLabel(req.Method)
+	route := stats.Route
+	if route == "" {
+		route = unmatchedRoute
+	}
+	status := statusClass(stats.StatusCode)
+	m.Requests.Inc(method, route, status)
+	if stats.Panicked || stats.StatusCode >= http.StatusInternalServerError {
+		m.Errors.Inc(method, route, status)
+	}
+	m.Duration.Observe(stats.Duration.Seconds(), method, route, status)
+	if stats.BytesWritten > 0 {
+		m.ResponseBodyBytes.Add(float64(stats.BytesWritten), method, route)
+	}
+}
+
+// Handler returns a handler that writes the registry metrics in the Prometheus text exposition format
+func Handler(registry *Registry) handler.Handler {
+	return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		var buf bytes.Buffer
+		if _, err := registry.WriteTo(&buf); err != nil {
+			return nil, err
+		}
+		return response.RawWriterHttpResponse(ContentType, func(w io.Writer) error {
+			_, err := buf.WriteTo(w)
+			return err
+		}), nil
+	}
+}
+
+func isEventStream(contentType string) bool {
+	if len(contentType) == 0 {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == eventStreamMediaType
+}
+
+// statusClass returns the status class of a status code, for example 2xx, or "aborted" if no status code was written
+func statusClass(statusCode int) string {
+	switch {
+	case statusCode >= 100 && statusCode < 200:
+		return "1xx"
+	case statusCode < 300 && statusCode >= 200:
+		return "2xx"
+	case statusCode < 400 && statusCode >= 300:
+		return "3xx"
+	case statusCode < 500 && statusCode >= 400:
+		return "4xx"
+	case statusCode < 600 && statusCode >= 500:
+		return "5xx"
+	default:
+		return "aborted"
+	}
+}
+
+// methodLabel returns the method or "OTHER" for the non-standard methods, to limit the number of series
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "OTHER"
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/router"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpMetrics(t *testing.T) {
+	registry := NewRegistry()
+	httpMetrics, err := NewHttpMetrics(registry, []float64{0.1, 1})
+	if err != nil {
+		t.Fatalf("NewHttpMetrics() unexpected error: %v", err)
+	}
+	var sseInFlight, sseOpen float64
+	r := router.NewRouter(false, func(err error) {}).ObserveRequests(httpMetrics)
+	r.Handle(http.MethodGet, "/users/{id}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.PlainTextHttpResponseOK("user"), nil
+	})
+	r.Handle(http.MethodGet, "/panic", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		panic("boom")
+	})
+	r.Handle(http.MethodGet, "/events", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.RawWriterHttpResponse("text/event-stream", func(w io.Writer) error {
+			_, err := w.Write([]byte("data: 1\n\n"))
+			sseInFlight = httpMetrics.InFlight.Value()
+			sseOpen = httpMetrics.SSEConnections.Value()
+			return err
+		}), nil
+	})
+	r.Handle(http.MethodGet, "/metrics", Handler(registry))
+	for _, target := range []string{"/users/1", "/users/2", "/missing", "/panic", "/events"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if sseInFlight != 1 || sseOpen != 1 {
+		t.Errorf("while streaming, in flight: %v, SSE connections: %v, want: 1, 1", sseInFlight, sseOpen)
+	}
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "requests by route", got: httpMetrics.Requests.Value("GET", "/users/{id}", "2xx"), want: 2},
+		{name: "unmatched requests", got: httpMetrics.Requests.Value("GET", "unmatched", "4xx"), want: 1},
+		{name: "errors", got: httpMetrics.Errors.Value("GET", "/panic", "5xx"), want: 1},
+		{name: "no errors for client errors", got: httpMetrics.Errors.Value("GET", "unmatched", "4xx"), want: 0},
+		{name: "duration count", got: float64(httpMetrics.Duration.Count("GET", "/users/{id}", "2xx")), want: 2},
+		{name: "response body bytes", got: httpMetrics.ResponseBodyBytes.Value("GET", "/users/{id}"), want: 8},
+		{name: "in flight", got: httpMetrics.InFlight.Value(), want: 0},
+		{name: "SSE connections", got: httpMetrics.SSEConnections.Value(), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got: %v, want: %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := recorder.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Handler() Content-Type = %s, want %s", got, ContentType)
+	}
+	for _, line := range []string{
+		"# TYPE http_requests_total counter\n",
+		"http_requests_total{method=\"GET\",route=\"/users/{id}\",status=\"2xx\"} 2\n",
+		"http_request_duration_seconds_bucket{method=\"GET\",route=\"/panic\",status=\"5xx\",le=\"+Inf\"} 1\n",
+		"http_requests_in_flight 1\n",
+	} {
+		if !strings.Contains(recorder.Body.String(), line) {
+			t.Errorf("Handler() body doesn't contain: %s, got:\n%s", line, recorder.Body.String())
+		}
+	}
+}
+
+func Test_statusClass(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       string
+	}{
+		{statusCode: 101, want: "1xx"},
+		{statusCode: 204, want: "2xx"},
+		{statusCode: 304, want: "3xx"},
+		{statusCode: 429, want: "4xx"},
+		{statusCode: 503, want: "5xx"},
+		{statusCode: 0, want: "aborted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := statusClass(tt.statusCode); got != tt.want {
+				t.Errorf("statusClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
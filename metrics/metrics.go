@@ -0,0 +1,459 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const labelValuesSeparator = "\xff"
+
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// DefaultBuckets are the default histogram buckets, in seconds, suited for the HTTP request durations
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultRegistry is the registry used when no registry is provided
+var DefaultRegistry = NewRegistry()
+
+// A Metric is a named collection of samples, written in the Prometheus text exposition format
+type Metric interface {
+	// Name returns the metric name
+	Name() string
+	// write writes the metric samples
+	write(w *bufio.Writer)
+}
+
+// A Registry contains the metrics exposed by an endpoint (see Handler)
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]Metric
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]Metric{}}
+}
+
+// Register adds the metrics to the registry or returns an error if a metric with the same name is already registered
+func (r *Registry) Register(metrics ...Metric) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range metrics {
+		if _, found := r.metrics[m.Name()]; found {
+			return fmt.Errorf("metric %s is already registered", m.Name())
+		}
+	}
+	for _, m := range metrics {
+		r.metrics[m.Name()] = m
+	}
+	return nil
+}
+
+// MustRegister adds the metrics to the registry or panic if a metric can not be registered
+func (r *Registry) MustRegister(metrics ...Metric) {
+	if err := r.Register(metrics...); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes a metric from the registry
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.metrics, name)
+	r.mu.Unlock()
+}
+
+// WriteTo writes the metrics, sorted by name, in the Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	metrics := make([]Metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.RUnlock()
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name() < metrics[j].Name()
+	})
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// desc contains the metric name, help, type and label names
+type desc struct {
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+}
+
+func newDesc(name string, help string, metricType string, labelNames []string) desc {
+	if !metricNameRegexp.MatchString(name) {
+		panic(fmt.Sprintf("invalid metric name: %s", name))
+	}
+	for _, labelName := range labelNames {
+		if !labelNameRegexp.MatchString(labelName) || strings.HasPrefix(labelName, "__") {
+			panic(fmt.Sprintf("invalid label name: %s for metric: %s", labelName, name))
+		}
+	}
+	return desc{name: name, help: help, metricType: metricType, labelNames: labelNames}
+}
+
+func (d desc) Name() string {
+	return d.name
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	if len(d.help) > 0 {
+		w.WriteString("# HELP ")
+		w.WriteString(d.name)
+		w.WriteByte(' ')
+		w.WriteString(escapeHelp(d.help))
+		w.WriteByte('\n')
+	}
+	w.WriteString("# TYPE ")
+	w.WriteString(d.name)
+	w.WriteByte(' ')
+	w.WriteString(d.metricType)
+	w.WriteByte('\n')
+}
+
+// writeSample writes a sample line. The extra label (for example the histogram "le") is added if its name is not empty
+func (d desc) writeSample(w *bufio.Writer, suffix string, labelValues []string, extraLabelName string, extraLabelValue string, value float64) {
+	w.WriteString(d.name)
+	w.WriteString(suffix)
+	if len(labelValues) > 0 || len(extraLabelName) > 0 {
+		w.WriteByte('{')
+		for i, labelValue := range labelValues {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, d.labelNames[i], labelValue)
+		}
+		if len(extraLabelName) > 0 {
+			if len(labelValues) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraLabelName, extraLabelValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func writeLabel(w *bufio.Writer, name string, value string) {
+	w.WriteString(name)
+	w.WriteString(`="`)
+	w.WriteString(escapeLabelValue(value))
+	w.WriteByte('"')
+}
+
+// series contains the samples of each label values
+type series[T any] struct {
+	mu     sync.RWMutex
+	values map[string]*labeledValue[T]
+}
+
+type labeledValue[T any] struct {
+	labelValues []string
+	value       *T
+}
+
+// get returns the samples of the label values, creating them if missing
+func (s *series[T]) get(d desc, labelValues []string, newFunc func() *T) *T {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", d.name, len(d.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, labelValuesSeparator)
+	s.mu.RLock()
+	lv, found := s.values[key]
+	s.mu.RUnlock()
+	if found {
+		return lv.value
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lv, found = s.values[key]; !found {
+		if s.values == nil {
+			s.values = map[string]*labeledValue[T]{}
+		}
+		lv = &labeledValue[T]{labelValues: append([]string(nil), labelValues...), value: newFunc()}
+		s.values[key] = lv
+	}
+	return lv.value
+}
+
+// lookup returns the samples of the label values or nil if missing
+func (s *series[T]) lookup(labelValues []string) *T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if lv, found := s.values[strings.Join(labelValues, labelValuesSeparator)]; found {
+		return lv.value
+	}
+	return nil
+}
+
+// sorted calls the function for each label values, in order
+func (s *series[T]) sorted(f func(labelValues []string, value *T)) {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]*labeledValue[T], len(keys))
+	for i, key := range keys {
+		values[i] = s.values[key]
+	}
+	s.mu.RUnlock()
+	for _, lv := range values {
+		f(lv.labelValues, lv.value)
+	}
+}
+
+// atomicFloat is a float64 that can be updated concurrently
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		if atomic.CompareAndSwapUint64(&f.bits, old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(value float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(value))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+// A Counter is a metric whose value can only increase, for example the number of the requests served
+type Counter struct {
+	desc
+	series series[atomicFloat]
+}
+
+// NewCounter creates a Counter. The label values are passed, in the same order as the label names, when the counter is updated
+func NewCounter(name string, help string, labelNames ...string) *Counter {
+	return &Counter{desc: newDesc(name, help, "counter", labelNames)}
+}
+
+// Inc increments the counter of the label values by 1
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter of the label values. It panics if the value is negative
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("counter %s can not be decreased", c.name))
+	}
+	c.series.get(c.desc, labelValues, newAtomicFloat).add(value)
+}
+
+// Value returns the counter value of the label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	if value := c.series.lookup(labelValues); value != nil {
+		return value.load()
+	}
+	return 0
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	c.series.sorted(func(labelValues []string, value *atomicFloat) {
+		c.writeSample(w, "", labelValues, "", "", value.load())
+	})
+}
+
+// A Gauge is a metric whose value can increase and decrease, for example the number of the in-flight requests
+type Gauge struct {
+	desc
+	series series[atomicFloat]
+}
+
+// NewGauge creates a Gauge. The label values are passed, in the same order as the label names, when the gauge is updated
+func NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return &Gauge{desc: newDesc(name, help, "gauge", labelNames)}
+}
+
+// Set sets the gauge value of the label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.series.get(g.desc, labelValues, newAtomicFloat).set(value)
+}
+
+// Add adds the value (that can be negative) to the gauge of the label values
+func (g *Gauge) Add(value float64, labelValues ...string) {
+	g.series.get(g.desc, labelValues, newAtomicFloat).add(value)
+}
+
+// Inc increments the gauge of the label values by 1
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec decrements the gauge of the label values by 1
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Value returns the gauge value of the label values
+func (g *Gauge) Value(labelValues ...string) float64 {
+	if value := g.series.lookup(labelValues); value != nil {
+		return value.load()
+	}
+	return 0
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	g.series.sorted(func(labelValues []string, value *atomicFloat) {
+		g.writeSample(w, "", labelValues, "", "", value.load())
+	})
+}
+
+// A GaugeFunc is a gauge without labels, whose value is returned by a function when the metrics are written,
+// for example the number of goroutines
+type GaugeFunc struct {
+	desc
+	valueFunc func() float64
+}
+
+// NewGaugeFunc creates a GaugeFunc
+func NewGaugeFunc(name string, help string, valueFunc func() float64) *GaugeFunc {
+	return &GaugeFunc{desc: newDesc(name, help, "gauge", nil), valueFunc: valueFunc}
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	g.writeSample(w, "", nil, "", "", g.valueFunc())
+}
+
+// histogramValues contains the bucket counts, the sum and the count of the observed values
+type histogramValues struct {
+	buckets []uint64
+	count   uint64
+	sum     atomicFloat
+}
+
+// A Histogram is a metric that counts the observed values in buckets, for example the request durations
+type Histogram struct {
+	desc
+	buckets []float64
+	series  series[histogramValues]
+}
+
+// NewHistogram creates a Histogram with the buckets upper bounds (DefaultBuckets if empty). The label values are passed,
+// in the same order as the label names, when a value is observed
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	if math.IsInf(buckets[len(buckets)-1], 1) {
+		buckets = buckets[:len(buckets)-1]
+	}
+	for _, labelName := range labelNames {
+		if labelName == "le" {
+			panic(errors.New("the histogram label name le is reserved"))
+		}
+	}
+	return &Histogram{desc: newDesc(name, help, "histogram", labelNames), buckets: buckets}
+}
+
+// Observe adds a value to the histogram of the label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	values := h.series.get(h.desc, labelValues, func() *histogramValues {
+		return &histogramValues{buckets: make([]uint64, len(h.buckets))}
+	})
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		atomic.AddUint64(&values.buckets[i], 1)
+	}
+	values.sum.add(value)
+	atomic.AddUint64(&values.count, 1)
+}
+
+// Count returns the number of the observed values of the label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	if values := h.series.lookup(labelValues); values != nil {
+		return atomic.LoadUint64(&values.count)
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.series.sorted(func(labelValues []string, values *histogramValues) {
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += atomic.LoadUint64(&values.buckets[i])
+			h.writeSample(w, "_bucket", labelValues, "le", formatFloat(upperBound), float64(cumulative))
+		}
+		count := atomic.LoadUint64(&values.count)
+		h.writeSample(w, "_bucket", labelValues, "le", "+Inf", float64(count))
+		h.writeSample(w, "_sum", labelValues, "", "", values.sum.load())
+		h.writeSample(w, "_count", labelValues, "", "", float64(count))
+	})
+}
+
+func newAtomicFloat() *atomicFloat {
+	return &atomicFloat{}
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
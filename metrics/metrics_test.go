@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics func() []Metric
+		want    string
+	}{
+		{
+			name: "counter with labels",
+			metrics: func() []Metric {
+				c := NewCounter("orders_total", "The number of orders.", "country", "status")
+				c.Inc("ro", "paid")
+				c.Add(2.5, "de", "paid")
+				c.Inc("ro", "paid")
+				return []Metric{c}
+			},
+			want: "# HELP orders_total The number of orders.\n" +
+				"# TYPE orders_total counter\n" +
+				"orders_total{country=\"de\",status=\"paid\"} 2.5\n" +
+				"orders_total{country=\"ro\",status=\"paid\"} 2\n",
+		},
+		{
+			name: "gauge without labels and escaped help",
+			metrics: func() []Metric {
+				g := NewGauge("queue_size", "The queue size\nin items \\ messages.")
+				g.Set(10)
+				g.Inc()
+				g.Dec()
+				g.Dec()
+				return []Metric{g}
+			},
+			want: "# HELP queue_size The queue size\\nin items \\\\ messages.\n" +
+				"# TYPE queue_size gauge\n" +
+				"queue_size 9\n",
+		},
+		{
+			name: "escaped label values and gauge func",
+			metrics: func() []Metric {
+				g := NewGauge("info", "", "path")
+				g.Set(1, "C:\\dir\n\"quoted\"")
+				return []Metric{g, NewGaugeFunc("goroutines", "", func() float64 { return math.Inf(1) })}
+			},
+			want: "# TYPE goroutines gauge\n" +
+				"goroutines +Inf\n" +
+				"# TYPE info gauge\n" +
+				"info{path=\"C:\\\\dir\\n\\\"quoted\\\"\"} 1\n",
+		},
+		{
+			name: "histogram",
+			metrics: func() []Metric {
+				h := NewHistogram("latency_seconds", "The latency.", []float64{1, 0.1, 0.5}, "route")
+				h.Observe(0.05, "/users")
+				h.Observe(0.1, "/users")
+				h.Observe(0.7, "/users")
+				h.Observe(3, "/users")
+				return []Metric{h}
+			},
+			want: "# HELP latency_seconds The latency.\n" +
+				"# TYPE latency_seconds histogram\n" +
+				"latency_seconds_bucket{route=\"/users\",le=\"0.1\"} 2\n" +
+				"latency_seconds_bucket{route=\"/users\",le=\"0.5\"} 2\n" +
+				"latency_seconds_bucket{route=\"/users\",le=\"1\"} 3\n" +
+				"latency_seconds_bucket{route=\"/users\",le=\"+Inf\"} 4\n" +
+				"latency_seconds_sum{route=\"/users\"} 3.85\n" +
+				"latency_seconds_count{route=\"/users\"} 4\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.MustRegister(tt.metrics()...)
+			var buf bytes.Buffer
+			n, err := registry.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("WriteTo() unexpected error: %v", err)
+			}
+			if got := buf.String(); got != tt.want || n != int64(len(tt.want)) {
+				t.Errorf("WriteTo() got: %d\n%s\nwant:\n%s", n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(NewCounter("requests_total", "")); err != nil {
+		t.Fatalf("Register() unexpected error: %v", err)
+	}
+	if err := registry.Register(NewGauge("requests_total", "")); err == nil {
+		t.Errorf("Register() expected error for a duplicated metric")
+	}
+	registry.Unregister("requests_total")
+	if err := registry.Register(NewGauge("requests_total", "")); err != nil {
+		t.Errorf("Register() unexpected error after Unregister: %v", err)
+	}
+}
+
+func TestNewMetric_panics(t *testing.T) {
+	tests := []struct {
+		name    string
+		newFunc func()
+	}{
+		{name: "invalid metric name", newFunc: func() { NewCounter("http-requests", "") }},
+		{name: "invalid label name", newFunc: func() { NewGauge("requests", "", "__reserved") }},
+		{name: "histogram le label", newFunc: func() { NewHistogram("latency", "", nil, "le") }},
+		{name: "negative counter", newFunc: func() { NewCounter("requests", "").Add(-1) }},
+		{name: "wrong label values", newFunc: func() { NewCounter("requests", "", "method").Inc() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			tt.newFunc()
+		})
+	}
+}
+
+func TestCounter_concurrent(t *testing.T) {
+	c := NewCounter("requests_total", "", "method")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Inc("GET")
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Value("GET"); got != 10000 {
+		t.Errorf("Value() = %v, want 10000", got)
+	}
+	if got := c.Value("POST"); got != 0 {
+		t.Errorf("Value() = %v, want 0", got)
+	}
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ixtendio/gofre/router"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// An AccessLogFormat is the format of the access log lines
+type AccessLogFormat int
+
+const (
+	// AccessLogCombined is the Apache combined log format: %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"
+	AccessLogCombined AccessLogFormat = iota
+	// AccessLogCommon is the Apache common log format: %h %l %u %t "%r" %>s %b
+	AccessLogCommon
+	// AccessLogJSON writes a JSON object per line, with the AccessLogConfig.Fields
+	AccessLogJSON
+)
+
+// The fields of the AccessLogJSON format
+const (
+	AccessLogFieldTime       = "time"
+	AccessLogFieldRemoteAddr = "remoteAddr"
+	AccessLogFieldUser       = "user"
+	AccessLogFieldMethod     = "method"
+	AccessLogFieldUri        = "uri"
+	AccessLogFieldProto      = "proto"
+	AccessLogFieldRoute      = "route"
+	AccessLogFieldStatus     = "status"
+	AccessLogFieldBytes      = "bytes"
+	AccessLogFieldDuration   = "durationMs"
+	AccessLogFieldReferer    = "referer"
+	AccessLogFieldUserAgent  = "userAgent"
+	AccessLogFieldRequestId  = "requestId"
+	AccessLogFieldHijacked   = "hijacked"
+	AccessLogFieldPanicked   = "panicked"
+)
+
+const accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+var defaultAccessLogFields = []string{
+	AccessLogFieldTime,
+	AccessLogFieldRemoteAddr,
+	AccessLogFieldMethod,
+	AccessLogFieldUri,
+	AccessLogFieldProto,
+	AccessLogFieldRoute,
+	AccessLogFieldStatus,
+	AccessLogFieldBytes,
+	AccessLogFieldDuration,
+	AccessLogFieldReferer,
+	AccessLogFieldUserAgent,
+	AccessLogFieldRequestId,
+}
+
+// the sampling random number generator, replaced in tests
+var accessLogSampler = rand.Float64
+
+// AccessLogConfig contains the settings of the access log.
+// If no custom values are provided for the struct fields then, the default one are used
+type AccessLogConfig struct {
+	// the format of the log lines. Default: AccessLogCombined
+	Format AccessLogFormat
+	// where the log lines are written. Default: os.Stdout
+	Output io.Writer
+	// the fields written by the AccessLogJSON format. The hijacked and panicked fields are written only if true.
+	// Default: all the fields except the user, hijacked and panicked ones
+	Fields []string
+	// the ratio, between 0 and 1, of the successful requests (with a status code lower than 400) that are logged.
+	// The failed requests are always logged. Default: 1 (all the requests are logged)
+	SampleRate float64
+	// if not nil and returns true, the request is not logged, for example the health checks. Default: nil
+	Skip func(req *http.Request) bool
+}
+
+func (c *AccessLogConfig) setDefaults() {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if len(c.Fields) == 0 {
+		c.Fields = defaultAccessLogFields
+	}
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		c.SampleRate = 1
+	}
+}
+
+// AccessLog returns a router.WriteObserver that writes a line for each request, after its response was written to the client,
+// with the real status code, the number of bytes written and the latency (time to last byte). Because it observes the router,
+// the not found requests and the requests that panicked are logged as well. It can be registered with MuxHandler.ObserveWrites
+func AccessLog(config AccessLogConfig) router.WriteObserver {
+	config.setDefaults()
+	var mu sync.Mutex
+	return func(req *http.Request, stats router.WriteStats) {
+		if config.Skip != nil && config.Skip(req) {
+			return
+		}
+		if stats.StatusCode < http.StatusBadRequest && stats.StatusCode > 0 && !stats.Panicked &&
+			config.SampleRate < 1 && accessLogSampler() >= config.SampleRate {
+			return
+		}
+		var buf bytes.Buffer
+		if config.Format == AccessLogJSON {
+			writeJSONAccessLog(&buf, req, stats, config.Fields)
+		} else {
+			writeApacheAccessLog(&buf, req, stats, config.Format == AccessLogCombined)
+		}
+		buf.WriteByte('\n')
+		mu.Lock()
+		config.Output.Write(buf.Bytes())
+		mu.Unlock()
+	}
+}
+
+func writeApacheAccessLog(buf *bytes.Buffer, req *http.Request, stats router.WriteStats, combined bool) {
+	buf.WriteString(apacheValue(remoteHost(req)))
+	buf.WriteString(" - ")
+	buf.WriteString(apacheValue(basicAuthUser(req)))
+	buf.WriteString(" [")
+	buf.WriteString(stats.Start.Format(accessLogTimeLayout))
+	buf.WriteString("] \"")
+	buf.WriteString(apacheQuote(req.Method + " " + req.RequestURI + " " + req.Proto))
+	buf.WriteString("\" ")
+	buf.WriteString(strconv.Itoa(stats.StatusCode))
+	buf.WriteByte(' ')
+	if stats.BytesWritten > 0 {
+		buf.WriteString(strconv.FormatInt(stats.BytesWritten, 10))
+	} else {
+		buf.WriteByte('-')
+	}
+	if combined {
+		buf.WriteString(" \"")
+		buf.WriteString(apacheQuote(apacheValue(req.Referer())))
+		buf.WriteString("\" \"")
+		buf.WriteString(apacheQuote(apacheValue(req.UserAgent())))
+		buf.WriteByte('"')
+	}
+}
+
+func writeJSONAccessLog(buf *bytes.Buffer, req *http.Request, stats router.WriteStats, fields []string) {
+	entry := make(map[string]any, len(fields))
+	for _, field := range fields {
+		switch field {
+		case AccessLogFieldTime:
+			entry[field] = stats.Start.Format(time.RFC3339Nano)
+		case AccessLogFieldRemoteAddr:
+			entry[field] = remoteHost(req)
+		case AccessLogFieldUser:
+			entry[field] = basicAuthUser(req)
+		case AccessLogFieldMethod:
+			entry[field] = req.Method
+		case AccessLogFieldUri:
+			entry[field] = req.RequestURI
+		case AccessLogFieldProto:
+			entry[field] = req.Proto
+		case AccessLogFieldRoute:
+			entry[field] = stats.Route
+		case AccessLogFieldStatus:
+			entry[field] = stats.StatusCode
+		case AccessLogFieldBytes:
+			entry[field] = stats.BytesWritten
+		case AccessLogFieldDuration:
+			entry[field] = float64(stats.Duration.Microseconds()) / 1000
+		case AccessLogFieldReferer:
+			entry[field] = req.Referer()
+		case AccessLogFieldUserAgent:
+			entry[field] = req.UserAgent()
+		case AccessLogFieldRequestId:
+			entry[field] = stats.RequestId
+		}
+	}
+	if stats.Hijacked {
+		entry[AccessLogFieldHijacked] = true
+	}
+	if stats.Panicked {
+		entry[AccessLogFieldPanicked] = true
+	}
+	// the map keys are sorted by the json encoder
+	data, _ := json.Marshal(entry)
+	buf.Write(data)
+}
+
+// remoteHost returns the host of the request remote address
+func remoteHost(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+func basicAuthUser(req *http.Request) string {
+	if user, _, ok := req.BasicAuth(); ok {
+		return user
+	}
+	return ""
+}
+
+// apacheValue returns "-" for the missing values
+func apacheValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// apacheQuote escapes the quotes, the backslashes and the control chars of a quoted value
+func apacheQuote(value string) string {
+	quoted := strconv.Quote(value)
+	return quoted[1 : len(quoted)-1]
+}
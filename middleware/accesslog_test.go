@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/ixtendio/gofre/router"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAccessLog(t *testing.T) {
+	start := time.Date(2023, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/users/10?tab=\"a\"", nil)
+		req.RemoteAddr = "127.0.0.1:51234"
+		req.Header.Set("Referer", "https://example.com/")
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		req.Header.Set("X-Request-Id", "spoofed")
+		req.SetBasicAuth("frank", "secret")
+		return req
+	}
+	okStats := router.WriteStats{StatusCode: http.StatusOK, BytesWritten: 2326, Start: start, Duration: 1500 * time.Microsecond, Route: "/users/{id}", RequestId: "4bf92f35"}
+	tests := []struct {
+		name    string
+		config  AccessLogConfig
+		stats   router.WriteStats
+		sampler float64
+		want    string
+	}{
+		{
+			name:   "combined format",
+			config: AccessLogConfig{},
+			stats:  okStats,
+			want:   `127.0.0.1 - frank [10/Oct/2023:13:55:36 -0700] "GET /users/10?tab=\"a\" HTTP/1.1" 200 2326 "https://example.com/" "Mozilla/5.0"` + "\n",
+		},
+		{
+			name:   "common format without bytes",
+			config: AccessLogConfig{Format: AccessLogCommon},
+			stats:  router.WriteStats{StatusCode: http.StatusNotFound, Start: start},
+			want:   `127.0.0.1 - frank [10/Oct/2023:13:55:36 -0700] "GET /users/10?tab=\"a\" HTTP/1.1" 404 -` + "\n",
+		},
+		{
+			name:   "json format with the default fields",
+			config: AccessLogConfig{Format: AccessLogJSON},
+			stats:  okStats,
+			want:   `{"bytes":2326,"durationMs":1.5,"method":"GET","proto":"HTTP/1.1","referer":"https://example.com/","remoteAddr":"127.0.0.1","requestId":"4bf92f35","route":"/users/{id}","status":200,"time":"2023-10-10T13:55:36-07:00","uri":"/users/10?tab=\"a\"","userAgent":"Mozilla/5.0"}` + "\n",
+		},
+		{
+			name:   "json format with custom fields and a panic",
+			config: AccessLogConfig{Format: AccessLogJSON, Fields: []string{AccessLogFieldUser, AccessLogFieldStatus}},
+			stats:  router.WriteStats{StatusCode: http.StatusInternalServerError, Start: start, Panicked: true},
+			want:   `{"panicked":true,"status":500,"user":"frank"}` + "\n",
+		},
+		{
+			name:    "sampled out",
+			config:  AccessLogConfig{SampleRate: 0.1},
+			stats:   okStats,
+			sampler: 0.5,
+			want:    "",
+		},
+		{
+			name:    "sampled in",
+			config:  AccessLogConfig{Format: AccessLogJSON, Fields: []string{AccessLogFieldStatus}, SampleRate: 0.1},
+			stats:   okStats,
+			sampler: 0.05,
+			want:    `{"status":200}` + "\n",
+		},
+		{
+			name:    "the failed requests are not sampled",
+			config:  AccessLogConfig{Format: AccessLogJSON, Fields: []string{AccessLogFieldStatus}, SampleRate: 0.1},
+			stats:   router.WriteStats{StatusCode: http.StatusBadGateway, Start: start},
+			sampler: 0.5,
+			want:    `{"status":502}` + "\n",
+		},
+		{
+			name: "skipped",
+			config: AccessLogConfig{Skip: func(req *http.Request) bool {
+				return req.URL.Path == "/users/10"
+			}},
+			stats: okStats,
+			want:  "",
+		},
+	}
+	defaultSampler := accessLogSampler
+	defer func() {
+		accessLogSampler = defaultSampler
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessLogSampler = func() float64 {
+				return tt.sampler
+			}
+			var buf bytes.Buffer
+			tt.config.Output = &buf
+			AccessLog(tt.config)(newRequest(), tt.stats)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("AccessLog() got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/ixtendio/gofre/auth"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
@@ -11,7 +12,7 @@ import (
 
 type SecurityPrincipalSupplierFunc func(ctx context.Context, mc path.MatchingContext) (auth.SecurityPrincipal, error)
 
-// SecurityPrincipalSupplier extracts the auth.SecurityPrincipal and propagate it to the context.Context
+// SecurityPrincipalSupplier extracts the auth.SecurityPrincipal and propagate it to the context.Context and to the request scoped logger
 func SecurityPrincipalSupplier(sps SecurityPrincipalSupplierFunc) Middleware {
 	return func(handler handler.Handler) handler.Handler {
 		return func(ctx context.Context, mc path.MatchingContext) (resp response.HttpResponse, err error) {
@@ -21,7 +22,8 @@ func SecurityPrincipalSupplier(sps SecurityPrincipalSupplierFunc) Middleware {
 				if securityPrincipal == nil {
 					return handler(ctx, mc)
 				}
-				return handler(context.WithValue(ctx, auth.SecurityPrincipalCtxKey, securityPrincipal), mc)
+				ctx = context.WithValue(ctx, auth.SecurityPrincipalCtxKey, securityPrincipal)
+				return handler(logging.WithPrincipal(ctx, securityPrincipal), mc)
 			}
 		}
 	}
@@ -8,6 +8,8 @@ import (
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"net/netip"
 )
 
 type PermissionsSupplierFunc func(ctx context.Context, mc path.MatchingContext) ([]auth.Permission, error)
@@ -63,3 +65,32 @@ func Authorize(supplier PermissionsSupplierFunc, matchAllPermissions bool) Middl
 		}
 	}
 }
+
+// AllowNetworks checks if the request remote address belongs to one of the networks, for example 10.0.0.0/8 or ::1/128
+// An errors.ErrAccessDenied error is returned for the requests from the other networks.
+// The remote address is the one of the connection, so the clients behind a proxy are not checked individually
+func AllowNetworks(networks ...netip.Prefix) Middleware {
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (resp response.HttpResponse, err error) {
+			if !isRemoteAddrAllowed(mc.R, networks) {
+				return nil, errors.ErrAccessDenied
+			}
+			return handler(ctx, mc)
+		}
+	}
+}
+
+// isRemoteAddrAllowed returns true if the request remote address belongs to one of the networks
+func isRemoteAddrAllowed(req *http.Request, networks []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(remoteHost(req))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
 	"testing"
 )
 
@@ -167,3 +170,47 @@ func TestAuthorizeAny(t *testing.T) {
 		})
 	}
 }
+
+func TestAllowNetworks(t *testing.T) {
+	networks := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       error
+	}{
+		{
+			name:       "allowed ipv4",
+			remoteAddr: "10.1.2.3:51234",
+		},
+		{
+			name:       "allowed ipv4 mapped ipv6",
+			remoteAddr: "[::ffff:10.1.2.3]:51234",
+		},
+		{
+			name:       "allowed ipv6",
+			remoteAddr: "[::1]:51234",
+		},
+		{
+			name:       "denied ipv4",
+			remoteAddr: "203.0.113.10:51234",
+			want:       errors.ErrAccessDenied,
+		},
+		{
+			name:       "invalid remote address",
+			remoteAddr: "unknown",
+			want:       errors.ErrAccessDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			req.RemoteAddr = tt.remoteAddr
+			_, err := AllowNetworks(networks...)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.PlainTextHttpResponseOK("ok"), nil
+			})(context.Background(), path.MatchingContext{R: req})
+			if err != tt.want {
+				t.Errorf("AllowNetworks() got: %v, want: %v", err, tt.want)
+			}
+		})
+	}
+}
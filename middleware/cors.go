@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/ixtendio/gofre/errors"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
@@ -97,11 +98,13 @@ func Cors(config CorsConfig) Middleware {
 			switch getRequestType(mc.R) {
 			case simpleCorsRequestType, actualCorsRequestType:
 				if err := addSimpleCorsHeaders(mc.R, httpResponse.Headers(), config); err != nil {
+					logCorsDenial(ctx, mc.R, "simple")
 					return nil, err
 				}
 				return httpResponse, nil
 			case preFlightCorsRequestType:
 				if err := addPreFlightCorsHeaders(mc.R, httpResponse.Headers(), config); err != nil {
+					logCorsDenial(ctx, mc.R, "preflight")
 					return nil, err
 				}
 				return httpResponse, nil
@@ -109,12 +112,21 @@ func Cors(config CorsConfig) Middleware {
 				addStandardCorsHeaders(mc.R, httpResponse.Headers(), config)
 				return httpResponse, nil
 			default:
+				logCorsDenial(ctx, mc.R, "invalid")
 				return nil, errors.ErrAccessDenied
 			}
 		}
 	}
 }
 
+// logCorsDenial logs a denied CORS request with the request scoped logger
+func logCorsDenial(ctx context.Context, r *http.Request, requestType string) {
+	logging.GetLoggerFromContext(ctx).Warn("cors request denied",
+		"corsRequestType", requestType,
+		"origin", r.Header.Get(requestHeaderOrigin),
+		"requestMethod", r.Header.Get(requestHeaderAccessControlRequestMethod))
+}
+
 func addSimpleCorsHeaders(r *http.Request, responseHeaders response.HttpHeaders, config CorsConfig) error {
 	method := r.Method
 	origin := r.Header.Get(requestHeaderOrigin)
@@ -7,6 +7,7 @@ import (
 	"github.com/ixtendio/gofre/cache"
 	"github.com/ixtendio/gofre/errors"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
@@ -50,6 +51,7 @@ func CSRFPreventionWithCustomParamAndHeaderName(nonceCache cache.Cache, csrfNonc
 					previousNonce = mc.R.Form.Get(csrfNonceRequestParamName)
 				}
 				if previousNonce == "" || !nonceCache.Contains(previousNonce) {
+					logging.GetLoggerFromContext(ctx).Warn("csrf nonce is missing or expired")
 					return nil, errors.ErrAccessDenied
 				}
 				nonceCache.Remove(previousNonce)
@@ -13,7 +13,9 @@ import (
 )
 
 // RequestDumper dumps the request (before processing) and the corresponding response in JSON format.
-// It is especially useful in debugging problems.
+// It is especially useful in debugging problems. The dumped status code is the one returned by the handler, that can differ from
+// the one written to the client (for example for the SSE or hijacked responses), see AccessLog for the real one.
+// The request id (see RequestId) is dumped as well.
 func RequestDumper(logger func(val string)) Middleware {
 	return func(handler handler.Handler) handler.Handler {
 		return func(ctx context.Context, mc path.MatchingContext) (resp response.HttpResponse, err error) {
@@ -58,12 +60,16 @@ func RequestDumper(logger func(val string)) Middleware {
 				responseMap["cookies"] = cookies
 			}
 
-			data, err := json.Marshal(map[string]any{
+			dump := map[string]any{
 				"startTimeMs": startTime,
 				"endTimeMs":   time.Now().UnixMilli(),
 				"request":     requestMap,
 				"response":    responseMap,
-			})
+			}
+			if requestId := GetRequestIdFromContext(ctx); len(requestId) > 0 {
+				dump["requestId"] = requestId
+			}
+			data, err := json.Marshal(dump)
 			if err != nil {
 				return nil, fmt.Errorf("failed to dump the request, err: %w", err)
 			}
@@ -8,6 +8,7 @@ import (
 	"github.com/ixtendio/gofre/response"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -27,8 +28,9 @@ func TestRequestDumper(t *testing.T) {
 		logger func(val string)
 	}
 	tests := []struct {
-		name string
-		args args
+		name      string
+		args      args
+		requestId string
 	}{
 		{
 			name: "call",
@@ -39,18 +41,35 @@ func TestRequestDumper(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "call with request id",
+			args: args{
+				req: r,
+				logger: func(val string) {
+					result = val
+				},
+			},
+			requestId: "7f3c2a9e",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(tt.requestId) > 0 {
+				ctx = context.WithValue(ctx, RequestIdCtxKey, tt.requestId)
+			}
 			_, err := RequestDumper(tt.args.logger)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
 				return response.PlainTextHttpResponseOK(""), nil
-			})(context.Background(), path.MatchingContext{R: tt.args.req})
+			})(ctx, path.MatchingContext{R: tt.args.req})
 			if err != nil {
 				t.Fatalf("RequestDumper() got err: %v", err)
 			}
 			if len(result) == 0 {
 				t.Errorf("RequestDumper() got: %v, want not empty", result)
 			}
+			if len(tt.requestId) > 0 && !strings.Contains(result, `"requestId":"`+tt.requestId+`"`) {
+				t.Errorf("RequestDumper() got: %v, want to contain the request id: %v", result, tt.requestId)
+			}
 		})
 	}
 }
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	goerrors "errors"
+	"fmt"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/router/path"
+	html "html/template"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+)
+
+const (
+	htmlMediaType = "text/html"
+	jsonMediaType = "application/json"
+
+	defaultErrorPageNamePattern = "errors/%d.html"
+	defaultErrorFallbackPage    = "errors/error.html"
+)
+
+// ErrorPagesConfig contains the settings used to render the error pages.
+// If no custom values are provided for the struct fields then, the default one are used
+type ErrorPagesConfig struct {
+	// the template that contains the error pages. If nil, only the built-in error page is rendered. Default: nil
+	Template response.ExecutableTemplate
+	// the name of the page rendered for a status code, where %d is replaced with the status code. Default: "errors/%d.html"
+	PageNamePattern string
+	// the name of the page rendered when there is no page for the status code. Default: "errors/error.html"
+	FallbackPage string
+	// if true, the error details and the panic stack traces are rendered too. Should be used only in development. Default: false
+	Development bool
+}
+
+func (c *ErrorPagesConfig) setDefaults() {
+	if c.PageNamePattern == "" {
+		c.PageNamePattern = defaultErrorPageNamePattern
+	}
+	if c.FallbackPage == "" {
+		c.FallbackPage = defaultErrorFallbackPage
+	}
+}
+
+// ErrorPageData is the data passed to the error page templates
+type ErrorPageData struct {
+	StatusCode int
+	// the status text, for example "Not Found"
+	StatusText string
+	// the message that can be shown to the users: the (localized) error message for the client errors (4xx) and the errors.PublicError,
+	// and, for the other server errors (5xx), the status text or, in development, the error message
+	Message string
+	// the machine-readable code of an errors.CodedError
+	Code string
+	// the error message, only in development
+	Detail string
+	// the panic stack trace (see PanicError), only in development
+	Stack string
+	// the request URL path
+	Path string
+	// the request id (see RequestId), that the users can report to the support team
+	RequestId string
+}
+
+// ErrHtmlResponse translates an error to an HTML error page or, if the client prefers JSON (based on the Accept header), to a JSON response.
+// The page for the status code (for example errors/404.html) is rendered or, if it can not be rendered, the fallback page and then a built-in page.
+// In production, the internal details of the server errors are hidden
+func ErrHtmlResponse(config ErrorPagesConfig) Middleware {
+	config.setDefaults()
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			resp, err := handler(ctx, mc)
+			if err != nil {
+				return config.errorResponse(ctx, mc, Error2HttpStatusCode(err), err), nil
+			}
+			return resp, err
+		}
+	}
+}
+
+func (c *ErrorPagesConfig) errorResponse(ctx context.Context, mc path.MatchingContext, statusCode int, err error) response.HttpResponse {
+	data := c.pageData(ctx, mc, statusCode, err)
+	headers := response.NewHttpHeaders()
+	headers[response.HeaderVary] = response.HeaderAccept
+	var accept string
+	if mc.R != nil {
+		accept = mc.R.Header.Get("Accept")
+	}
+	if response.NegotiateContentType(accept, htmlMediaType, jsonMediaType) == jsonMediaType {
+		payload := map[string]string{"error": data.Message}
+		if len(data.Code) > 0 {
+			payload["code"] = data.Code
+		}
+		if len(data.RequestId) > 0 {
+			payload["requestId"] = data.RequestId
+		}
+		if c.Development {
+			payload["detail"] = data.Detail
+			if len(data.Stack) > 0 {
+				payload["stack"] = data.Stack
+			}
+		}
+		return response.JsonHttpResponseWithHeaders(statusCode, payload, headers)
+	}
+	return response.HtmlHttpResponseWithHeaders(statusCode, c.renderPage(ctx, mc, data), headers)
+}
+
+func (c *ErrorPagesConfig) pageData(ctx context.Context, mc path.MatchingContext, statusCode int, err error) ErrorPageData {
+	data := ErrorPageData{
+		StatusCode: statusCode,
+		StatusText: http.StatusText(statusCode),
+	}
+	if mc.R != nil {
+		data.Path = mc.R.URL.Path
+	}
+	data.Code = errorCode(err)
+	data.RequestId = GetRequestIdFromContext(ctx)
+	if statusCode < http.StatusInternalServerError || isPublicError(err) {
+		data.Message = LocalizeError(ctx, err)
+	} else {
+		data.Message = i18n.Localize(ctx, data.StatusText)
+	}
+	if c.Development {
+		data.Message = LocalizeError(ctx, err)
+		data.Detail = err.Error()
+		var panicErr *PanicError
+		if goerrors.As(err, &panicErr) {
+			data.Stack = string(panicErr.Stack)
+		}
+	}
+	return data
+}
+
+// renderPage renders the page of the status code, the fallback page or the built-in page
+func (c *ErrorPagesConfig) renderPage(ctx context.Context, mc path.MatchingContext, data ErrorPageData) string {
+	if tmpl := c.Template; tmpl != nil {
+		if ct, ok := tmpl.(response.ContextTemplate); ok {
+			if t, release, err := ct.WithContext(ctx, mc); err == nil {
+				defer release()
+				tmpl = t
+			}
+		}
+		var buf bytes.Buffer
+		for _, name := range []string{fmt.Sprintf(c.PageNamePattern, data.StatusCode), c.FallbackPage} {
+			buf.Reset()
+			if err := tmpl.ExecuteTemplate(&buf, name, data); err == nil {
+				return buf.String()
+			}
+		}
+	}
+	title := html.HTMLEscapeString(fmt.Sprintf("%d %s", data.StatusCode, data.StatusText))
+	page := "<!DOCTYPE html><html><head><title>" + title + "</title></head><body><h1>" + title + "</h1>"
+	if data.Message != data.StatusText {
+		page += "<p>" + html.HTMLEscapeString(data.Message) + "</p>"
+	}
+	if len(data.RequestId) > 0 {
+		page += "<p>Request ID: " + html.HTMLEscapeString(data.RequestId) + "</p>"
+	}
+	if len(data.Stack) > 0 {
+		page += "<pre>" + html.HTMLEscapeString(data.Stack) + "</pre>"
+	}
+	return page + "</body></html>"
+}
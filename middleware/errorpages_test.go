@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	goerrors "errors"
+	"github.com/ixtendio/gofre/errors"
+	"github.com/ixtendio/gofre/router/path"
+	html "html/template"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrHtmlResponse(t *testing.T) {
+	tmpl := html.Must(html.New("").Parse(`{{define "errors/404.html"}}<h1>{{.StatusCode}}</h1>{{.Message}} {{.Path}}{{end}}` +
+		`{{define "errors/error.html"}}<h1>{{.StatusCode}} {{.StatusText}}</h1>{{.Message}}|{{.Detail}}|{{if .Stack}}stack{{end}}{{end}}` +
+		`{{define "errors/400.html"}}{{.Missing.Field}}{{end}}`))
+	panicErr := &PanicError{Value: "boom", Stack: []byte("goroutine 1 [running]:")}
+	tests := []struct {
+		name           string
+		config         ErrorPagesConfig
+		err            error
+		accept         string
+		requestId      string
+		wantStatusCode int
+		wantType       string
+		wantBody       string
+	}{
+		{
+			name:           "status page",
+			config:         ErrorPagesConfig{Template: tmpl},
+			err:            errors.NewObjectNotFoundWithMessage("user not found"),
+			accept:         "text/html,application/xhtml+xml,*/*;q=0.8",
+			wantStatusCode: http.StatusNotFound,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<h1>404</h1>user not found /users/1`,
+		},
+		{
+			name:           "fallback page hides the internal details in production",
+			config:         ErrorPagesConfig{Template: tmpl},
+			err:            goerrors.New("db connection refused"),
+			wantStatusCode: http.StatusInternalServerError,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<h1>500 Internal Server Error</h1>Internal Server Error||`,
+		},
+		{
+			name:           "fallback page when the status page fails",
+			config:         ErrorPagesConfig{Template: tmpl},
+			err:            errors.NewBadRequestWithMessage("invalid email"),
+			wantStatusCode: http.StatusBadRequest,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<h1>400 Bad Request</h1>invalid email||`,
+		},
+		{
+			name:           "fallback page shows the details in development",
+			config:         ErrorPagesConfig{Template: tmpl, Development: true},
+			err:            panicErr,
+			wantStatusCode: http.StatusInternalServerError,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<h1>500 Internal Server Error</h1>` + html.HTMLEscapeString(panicErr.Error()) + `|` + html.HTMLEscapeString(panicErr.Error()) + `|stack`,
+		},
+		{
+			name:           "custom page names",
+			config:         ErrorPagesConfig{Template: tmpl, PageNamePattern: "missing/%d", FallbackPage: "errors/404.html"},
+			err:            errors.ErrAccessDenied,
+			wantStatusCode: http.StatusForbidden,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<h1>403</h1>access denied /users/1`,
+		},
+		{
+			name:           "built-in page without template",
+			config:         ErrorPagesConfig{Development: true},
+			err:            &PanicError{Value: "<boom>", Stack: []byte("<stack>")},
+			wantStatusCode: http.StatusInternalServerError,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<!DOCTYPE html><html><head><title>500 Internal Server Error</title></head><body><h1>500 Internal Server Error</h1><p>recover from panic: &lt;boom&gt;</p><pre>&lt;stack&gt;</pre></body></html>`,
+		},
+		{
+			name:           "built-in page in production",
+			config:         ErrorPagesConfig{},
+			err:            goerrors.New("secret"),
+			wantStatusCode: http.StatusInternalServerError,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<!DOCTYPE html><html><head><title>500 Internal Server Error</title></head><body><h1>500 Internal Server Error</h1></body></html>`,
+		},
+		{
+			name:           "built-in page with request id",
+			config:         ErrorPagesConfig{},
+			err:            goerrors.New("secret"),
+			requestId:      "7f3c2a9e",
+			wantStatusCode: http.StatusInternalServerError,
+			wantType:       "text/html; charset=utf-8",
+			wantBody:       `<!DOCTYPE html><html><head><title>500 Internal Server Error</title></head><body><h1>500 Internal Server Error</h1><p>Request ID: 7f3c2a9e</p></body></html>`,
+		},
+		{
+			name:           "json with request id",
+			config:         ErrorPagesConfig{Template: tmpl},
+			err:            goerrors.New("secret"),
+			accept:         "application/json",
+			requestId:      "7f3c2a9e",
+			wantStatusCode: http.StatusInternalServerError,
+			wantType:       "application/json",
+			wantBody:       `{"error":"Internal Server Error","requestId":"7f3c2a9e"}`,
+		},
+		{
+			name:           "json in production",
+			config:         ErrorPagesConfig{Template: tmpl},
+			err:            goerrors.New("secret"),
+			accept:         "application/json",
+			wantStatusCode: http.StatusInternalServerError,
+			wantType:       "application/json",
+			wantBody:       `{"error":"Internal Server Error"}`,
+		},
+		{
+			name:           "json in development",
+			config:         ErrorPagesConfig{Template: tmpl, Development: true},
+			err:            errors.NewBadRequestWithMessage("invalid email"),
+			accept:         "application/json, text/html;q=0.5",
+			wantStatusCode: http.StatusBadRequest,
+			wantType:       "application/json",
+			wantBody:       `{"detail":"invalid email","error":"invalid email"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if len(tt.accept) > 0 {
+				req.Header.Set("Accept", tt.accept)
+			}
+			mc := path.MatchingContext{R: req}
+			h := ErrHtmlResponse(tt.config)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return nil, tt.err
+			})
+			ctx := context.Background()
+			if len(tt.requestId) > 0 {
+				ctx = context.WithValue(ctx, RequestIdCtxKey, tt.requestId)
+			}
+			resp, err := h(ctx, mc)
+			if err != nil {
+				t.Fatalf("ErrHtmlResponse() unexpected error: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, mc); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ErrHtmlResponse() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantType) {
+				t.Errorf("ErrHtmlResponse() Content-Type got: %q, want: %q", got, tt.wantType)
+			}
+			if got := recorder.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("ErrHtmlResponse() Vary got: %q, want: Accept", got)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"context"
+	goerrors "errors"
 	"github.com/ixtendio/gofre/errors"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/i18n"
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
@@ -12,30 +14,78 @@ import (
 
 type ResponseSupplier func(statusCode int, err error) response.HttpResponse
 
-// Error2HttpStatusCode translates an error to an HTTP status code
-var Error2HttpStatusCode = func(err error) int {
-	if _, ok := err.(errors.ErrBadRequest); ok {
-		return http.StatusBadRequest
-	} else if _, ok := err.(errors.ErrObjectNotFound); ok {
-		return http.StatusNotFound
-	} else if err == errors.ErrUnauthorizedRequest {
-		return http.StatusUnauthorized
-	} else if err == errors.ErrWrongCredentials ||
-		err == errors.ErrAccessDenied {
-		return http.StatusForbidden
-	}
-	return http.StatusInternalServerError
-}
+// ContextResponseSupplier is like ResponseSupplier but receives the request context.Context too, for example to localize the error message
+type ContextResponseSupplier func(ctx context.Context, statusCode int, err error) response.HttpResponse
 
-// ErrJsonResponse translates an error to a JSON response
+// Error2HttpStatusCode translates an error, that can be wrapped, to an HTTP status code. By default, errors.StatusCode is used
+var Error2HttpStatusCode = errors.StatusCode
+
+// ErrJsonResponse translates an error to a JSON response. If the request context.Context contains an i18n.Locale
+// (see the Localization middleware, that must be added before this one), the error message is localized, and if it contains a request id (see the RequestId middleware),
+// the "requestId" member is added. For the server errors, the message of the errors that are not an errors.PublicError
+// is replaced with the status text, so that the internal details are not exposed
 func ErrJsonResponse() Middleware {
-	return ErrResponse(func(statusCode int, err error) response.HttpResponse {
-		return response.JsonHttpResponse(statusCode, map[string]string{
-			"error": err.Error(),
-		})
+	return ErrResponseWithContext(func(ctx context.Context, statusCode int, err error) response.HttpResponse {
+		message := i18n.Localize(ctx, http.StatusText(statusCode))
+		if statusCode < http.StatusInternalServerError || isPublicError(err) {
+			message = LocalizeError(ctx, err)
+		}
+		payload := map[string]string{
+			"error": message,
+		}
+		if code := errorCode(err); len(code) > 0 {
+			payload["code"] = code
+		}
+		if requestId := GetRequestIdFromContext(ctx); len(requestId) > 0 {
+			payload["requestId"] = requestId
+		}
+		return response.JsonHttpResponse(statusCode, payload)
 	})
 }
 
+// LocalizeError returns the error message translated with the i18n.Locale from the context.Context. An i18n.Error (that can be wrapped)
+// is translated by its key, while the public message of an errors.PublicError (that hides the internal errors) or the error message is used as the key.
+// If there is no translation, the message is returned as it is
+func LocalizeError(ctx context.Context, err error) string {
+	locale := i18n.GetLocaleFromContext(ctx)
+	if locale == nil {
+		return errorMessage(err)
+	}
+	var publicErr errors.PublicError
+	if goerrors.As(err, &publicErr) {
+		return locale.Localize(publicErr.PublicMessage())
+	}
+	var localizableErr *i18n.Error
+	if goerrors.As(err, &localizableErr) {
+		return localizableErr.Localize(locale)
+	}
+	return locale.Localize(err.Error())
+}
+
+// isPublicError returns true if the error (that can be wrapped) is an errors.PublicError, whose message can be shown even for the server errors
+func isPublicError(err error) bool {
+	var publicErr errors.PublicError
+	return goerrors.As(err, &publicErr)
+}
+
+// errorCode returns the machine-readable code of an errors.CodedError (that can be wrapped) or an empty string
+func errorCode(err error) string {
+	var codedErr errors.CodedError
+	if goerrors.As(err, &codedErr) {
+		return codedErr.Code()
+	}
+	return ""
+}
+
+// errorMessage returns the public message of an errors.PublicError (that can be wrapped) or the error message
+func errorMessage(err error) string {
+	var publicErr errors.PublicError
+	if goerrors.As(err, &publicErr) {
+		return publicErr.PublicMessage()
+	}
+	return err.Error()
+}
+
 // ErrResponse translates an error to an response.HttpResponse
 func ErrResponse(responseSupplier ResponseSupplier) Middleware {
 	return func(handler handler.Handler) handler.Handler {
@@ -49,3 +99,17 @@ func ErrResponse(responseSupplier ResponseSupplier) Middleware {
 		}
 	}
 }
+
+// ErrResponseWithContext translates an error to an response.HttpResponse, using the request context.Context
+func ErrResponseWithContext(responseSupplier ContextResponseSupplier) Middleware {
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			resp, err := handler(ctx, mc)
+			if err != nil {
+				statusCode := Error2HttpStatusCode(err)
+				return responseSupplier(ctx, statusCode, err), nil
+			}
+			return resp, err
+		}
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	goerrors "errors"
+	"fmt"
 	"github.com/ixtendio/gofre/errors"
 	"github.com/ixtendio/gofre/handler"
 	"github.com/ixtendio/gofre/router/path"
@@ -70,6 +71,33 @@ func TestErrResponse(t *testing.T) {
 			},
 			want: response.HtmlHttpResponse(http.StatusBadRequest, "invalid request"),
 		},
+		{
+			name: "wrapped ErrBadRequest => StatusBadRequest",
+			args: args{
+				handler: func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+					return nil, fmt.Errorf("create user: %w", errors.NewBadRequestWithMessage("invalid request"))
+				},
+			},
+			want: response.HtmlHttpResponse(http.StatusBadRequest, "create user: invalid request"),
+		},
+		{
+			name: "wrapped ErrAccessDenied => StatusForbidden",
+			args: args{
+				handler: func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+					return nil, fmt.Errorf("delete user: %w", errors.ErrAccessDenied)
+				},
+			},
+			want: response.HtmlHttpResponse(http.StatusForbidden, "delete user: access denied"),
+		},
+		{
+			name: "ErrHttpStatus => its status code",
+			args: args{
+				handler: func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+					return nil, errors.NewConflict("email already used")
+				},
+			},
+			want: response.HtmlHttpResponse(http.StatusConflict, "email already used"),
+		},
 		{
 			name: "custom error => StatusInternalServerError",
 			args: args{
@@ -95,21 +123,59 @@ func TestErrResponse(t *testing.T) {
 
 func TestErrJsonResponse(t *testing.T) {
 	tests := []struct {
-		name string
-		want response.HttpResponse
+		name      string
+		err       error
+		requestId string
+		want      response.HttpResponse
 	}{
 		{
 			name: "check json",
+			err:  errors.ErrUnauthorizedRequest,
 			want: response.JsonHttpResponse(http.StatusUnauthorized, map[string]string{
 				"error": "unauthorized request",
 			}),
 		},
+		{
+			name: "coded error",
+			err:  fmt.Errorf("transfer: %w", errors.NewUnprocessableEntity("insufficient funds").WithCode("insufficient_funds")),
+			want: response.JsonHttpResponse(http.StatusUnprocessableEntity, map[string]string{
+				"error": "insufficient funds",
+				"code":  "insufficient_funds",
+			}),
+		},
+		{
+			name: "public message hides the internal error",
+			err:  errors.NewServiceUnavailable("try again later").Wrap(goerrors.New("dial tcp 10.0.0.1:5432: connection refused")),
+			want: response.JsonHttpResponse(http.StatusServiceUnavailable, map[string]string{
+				"error": "try again later",
+			}),
+		},
+		{
+			name: "internal error is hidden",
+			err:  fmt.Errorf("query users: %w", goerrors.New("dial tcp 10.0.0.1:5432: connection refused")),
+			want: response.JsonHttpResponse(http.StatusInternalServerError, map[string]string{
+				"error": "Internal Server Error",
+			}),
+		},
+		{
+			name:      "request id",
+			err:       errors.ErrUnauthorizedRequest,
+			requestId: "7f3c2a9e",
+			want: response.JsonHttpResponse(http.StatusUnauthorized, map[string]string{
+				"error":     "unauthorized request",
+				"requestId": "7f3c2a9e",
+			}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(tt.requestId) > 0 {
+				ctx = context.WithValue(ctx, RequestIdCtxKey, tt.requestId)
+			}
 			resp, err := ErrJsonResponse()(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
-				return nil, errors.ErrUnauthorizedRequest
-			})(context.Background(), path.MatchingContext{})
+				return nil, tt.err
+			})(ctx, path.MatchingContext{})
 			if err != nil {
 				t.Fatalf("ErrJsonResponse() returned error: %v", err)
 			}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+)
+
+type flashCtxKey int
+
+// FlashCtxKey is used to pass the flash messages of the request to the context.Context
+const FlashCtxKey flashCtxKey = 1
+
+// flashes contains the messages received from the previous request and the ones added for the next request
+type flashes struct {
+	received []string
+	added    []string
+}
+
+// GetFlashesFromContext returns the flash messages added by the previous request (see AddFlash) or nil if there are no messages
+func GetFlashesFromContext(ctx context.Context) []string {
+	if f, ok := ctx.Value(FlashCtxKey).(*flashes); ok {
+		return f.received
+	}
+	return nil
+}
+
+// AddFlash adds a message that is shown by the next request, for example after a redirect.
+// It returns false if the request is not handled by the Flash middleware
+func AddFlash(ctx context.Context, message string) bool {
+	if f, ok := ctx.Value(FlashCtxKey).(*flashes); ok {
+		f.added = append(f.added, message)
+		return true
+	}
+	return false
+}
+
+// FlashConfig contains the settings of the Flash middleware.
+// If no custom values are provided for the struct fields then, the default one are used
+type FlashConfig struct {
+	// the name of the cookie that keeps the messages until the next request. Default: "gofre_flash"
+	CookieName string
+	// the path of the cookie. Default: "/"
+	CookiePath string
+	// if true, the cookie is sent only over HTTPS. Default: false
+	Secure bool
+}
+
+func (c *FlashConfig) setDefaults() {
+	if c.CookieName == "" {
+		c.CookieName = "gofre_flash"
+	}
+	if c.CookiePath == "" {
+		c.CookiePath = "/"
+	}
+}
+
+// Flash is a middleware that keeps the messages added with AddFlash in a cookie until the next request, where they
+// can be read with GetFlashesFromContext (or with the flashes template function). The messages are removed by the
+// next request handled by the middleware, so it should be used only for the page routes.
+// The cookie is not signed, so the messages should not be trusted, and they are escaped when rendered by html/template
+func Flash(config FlashConfig) Middleware {
+	config.setDefaults()
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			f := &flashes{}
+			cookie, cookieErr := mc.R.Cookie(config.CookieName)
+			if cookieErr == nil {
+				f.received = decodeFlashes(cookie.Value)
+			}
+			resp, err := handler(context.WithValue(ctx, FlashCtxKey, f), mc)
+			if resp == nil {
+				return resp, err
+			}
+			if len(f.added) > 0 {
+				resp.Cookies().Add(config.cookie(encodeFlashes(f.added), 0))
+			} else if cookieErr == nil {
+				resp.Cookies().Add(config.cookie("", -1))
+			}
+			return resp, err
+		}
+	}
+}
+
+func (c *FlashConfig) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.CookieName,
+		Value:    value,
+		Path:     c.CookiePath,
+		MaxAge:   maxAge,
+		Secure:   c.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// encodeFlashes encodes the messages as base64 JSON, a valid cookie value
+func encodeFlashes(messages []string) string {
+	data, _ := json.Marshal(messages)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeFlashes decodes the messages from a cookie value, ignoring the invalid values
+func decodeFlashes(value string) []string {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var messages []string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil
+	}
+	return messages
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlash(t *testing.T) {
+	tests := []struct {
+		name         string
+		cookie       string
+		add          []string
+		wantReceived []string
+		wantCookie   string
+	}{
+		{
+			name: "without messages",
+		},
+		{
+			name:       "added messages",
+			add:        []string{"Saved", "<b>Welcome</b>"},
+			wantCookie: "gofre_flash=" + encodeFlashes([]string{"Saved", "<b>Welcome</b>"}) + "; Path=/; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:         "received messages are removed",
+			cookie:       encodeFlashes([]string{"Saved"}),
+			wantReceived: []string{"Saved"},
+			wantCookie:   "gofre_flash=; Path=/; Max-Age=0; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:         "received messages are replaced",
+			cookie:       encodeFlashes([]string{"Saved"}),
+			add:          []string{"Deleted"},
+			wantReceived: []string{"Saved"},
+			wantCookie:   "gofre_flash=" + encodeFlashes([]string{"Deleted"}) + "; Path=/; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:       "invalid cookie",
+			cookie:     "invalid!",
+			wantCookie: "gofre_flash=; Path=/; Max-Age=0; HttpOnly; SameSite=Lax",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReceived []string
+			h := Flash(FlashConfig{})(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				gotReceived = GetFlashesFromContext(ctx)
+				for _, message := range tt.add {
+					if !AddFlash(ctx, message) {
+						t.Fatalf("AddFlash() got: false, want: true")
+					}
+				}
+				return response.PlainTextHttpResponseOK("ok"), nil
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(tt.cookie) > 0 {
+				req.AddCookie(&http.Cookie{Name: "gofre_flash", Value: tt.cookie})
+			}
+			mc := path.MatchingContext{R: req}
+			resp, err := h(context.Background(), mc)
+			if err != nil {
+				t.Fatalf("Flash() unexpected error: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, mc); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(gotReceived, tt.wantReceived) {
+				t.Errorf("GetFlashesFromContext() got: %v, want: %v", gotReceived, tt.wantReceived)
+			}
+			if got := strings.Join(recorder.Header().Values("Set-Cookie"), "\n"); got != tt.wantCookie {
+				t.Errorf("Flash() Set-Cookie got: %q, want: %q", got, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestAddFlash_WithoutMiddleware(t *testing.T) {
+	if AddFlash(context.Background(), "Saved") {
+		t.Errorf("AddFlash() got: true, want: false")
+	}
+	if got := GetFlashesFromContext(context.Background()); got != nil {
+		t.Errorf("GetFlashesFromContext() got: %v, want: nil", got)
+	}
+}
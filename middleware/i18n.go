@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+)
+
+// Localization resolves the request i18n.Locale and propagates it to the context.Context, so that the handlers and the
+// templates can use i18n.Localize. The Content-Language header is added to the response, together with the Vary
+// header fields used to resolve the locale (Accept-Language and Cookie).
+// The locale is visible only to the middlewares that follow it, so, to localize the error messages, it must be added
+// before the error middlewares (ErrJsonResponse, ErrProblemResponse or ErrHtmlResponse), for example:
+//
+//	gofreMux.CommonMiddlewares(middleware.Localization(catalog, config), middleware.ErrJsonResponse())
+func Localization(catalog *i18n.Catalog, config i18n.ResolverConfig) Middleware {
+	varyHeaders := config.VaryHeaders()
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			locale := catalog.ResolveLocale(mc.R, config)
+			resp, err := handler(context.WithValue(ctx, i18n.LocaleCtxKey, locale), mc)
+			if resp != nil {
+				if headers := resp.Headers(); headers != nil {
+					if _, found := headers["Content-Language"]; !found {
+						headers["Content-Language"] = locale.Tag()
+					}
+					vary := headers[response.HeaderVary]
+					for _, header := range varyHeaders {
+						vary = response.AppendVary(vary, header)
+					}
+					if len(vary) > 0 {
+						headers[response.HeaderVary] = vary
+					}
+				}
+			}
+			return resp, err
+		}
+	}
+}
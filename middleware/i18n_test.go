@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"github.com/ixtendio/gofre/errors"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestI18nCatalog(t *testing.T) *i18n.Catalog {
+	c := i18n.NewCatalog("en")
+	if err := c.AddJSON("en", []byte(`{"not found": "Not found", "invalid.field": "Invalid field: {field}"}`)); err != nil {
+		t.Fatalf("AddJSON() unexpected error: %v", err)
+	}
+	if err := c.AddJSON("fr", []byte(`{"not found": "Introuvable", "invalid.field": "Champ invalide : {field}"}`)); err != nil {
+		t.Fatalf("AddJSON() unexpected error: %v", err)
+	}
+	return c
+}
+
+func TestLocalization(t *testing.T) {
+	catalog := newTestI18nCatalog(t)
+	tests := []struct {
+		name                string
+		config              i18n.ResolverConfig
+		acceptLanguage      string
+		respHeaders         response.HttpHeaders
+		wantBody            string
+		wantContentLanguage string
+		wantVary            string
+	}{
+		{name: "default locale", wantBody: "Not found", wantContentLanguage: "en", wantVary: "Cookie, Accept-Language"},
+		{name: "negotiated locale", acceptLanguage: "fr-FR,en;q=0.5", wantBody: "Introuvable", wantContentLanguage: "fr", wantVary: "Cookie, Accept-Language"},
+		{name: "content language set by the handler", acceptLanguage: "fr", respHeaders: response.HttpHeaders{"Content-Language": "de"}, wantBody: "Introuvable", wantContentLanguage: "de", wantVary: "Cookie, Accept-Language"},
+		{name: "vary set by the handler", acceptLanguage: "fr", respHeaders: response.HttpHeaders{"Vary": "Accept-Encoding, cookie"}, wantBody: "Introuvable", wantContentLanguage: "fr", wantVary: "Accept-Encoding, cookie, Accept-Language"},
+		{name: "accept language source", config: i18n.ResolverConfig{Sources: []i18n.LocaleSource{i18n.AcceptLanguage}}, acceptLanguage: "fr", wantBody: "Introuvable", wantContentLanguage: "fr", wantVary: "Accept-Language"},
+		{name: "without request header sources", config: i18n.ResolverConfig{Sources: []i18n.LocaleSource{i18n.QueryParam}}, acceptLanguage: "fr", wantBody: "Not found", wantContentLanguage: "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			h := Localization(catalog, tt.config)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.PlainTextHttpResponseWithHeaders(http.StatusOK, i18n.Localize(ctx, "not found"), tt.respHeaders), nil
+			})
+			resp, err := h(context.Background(), path.MatchingContext{R: req})
+			if err != nil {
+				t.Fatalf("Localization() unexpected error: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, path.MatchingContext{R: req}); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if recorder.Body.String() != tt.wantBody || recorder.Header().Get("Content-Language") != tt.wantContentLanguage {
+				t.Errorf("Localization() got: %q %q, want: %q %q", recorder.Body.String(), recorder.Header().Get("Content-Language"), tt.wantBody, tt.wantContentLanguage)
+			}
+			if got := recorder.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf("Localization() Vary header got: %q, want: %q", got, tt.wantVary)
+			}
+		})
+	}
+}
+
+func TestErrJsonResponse_Localized(t *testing.T) {
+	catalog := newTestI18nCatalog(t)
+	tests := []struct {
+		name           string
+		locale         string
+		err            error
+		wantStatusCode int
+		wantBody       string
+	}{
+		{name: "without locale", err: errors.NewObjectNotFoundWithMessage("not found"), wantStatusCode: http.StatusNotFound, wantBody: `{"error":"not found"}`},
+		{name: "error message as key", locale: "fr", err: errors.NewObjectNotFoundWithMessage("not found"), wantStatusCode: http.StatusNotFound, wantBody: `{"error":"Introuvable"}`},
+		{name: "wrapped localizable error", locale: "fr", err: errors.NewBadRequest(i18n.NewError("invalid.field", "field", "email")), wantStatusCode: http.StatusBadRequest, wantBody: `{"error":"Champ invalide : email"}`},
+		{name: "localizable error", locale: "en", err: fmt.Errorf("validation: %w", errors.NewBadRequest(i18n.NewError("invalid.field", "field", "name"))), wantStatusCode: http.StatusBadRequest, wantBody: `{"error":"Invalid field: name"}`},
+		{name: "missing translation", locale: "fr", err: errors.NewObjectNotFoundWithMessage("custom error"), wantStatusCode: http.StatusNotFound, wantBody: `{"error":"custom error"}`},
+		{name: "internal error", locale: "fr", err: goerrors.New("custom error"), wantStatusCode: http.StatusInternalServerError, wantBody: `{"error":"Internal Server Error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(tt.locale) > 0 {
+				ctx = context.WithValue(ctx, i18n.LocaleCtxKey, catalog.Locale(tt.locale))
+			}
+			h := ErrJsonResponse()(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return nil, tt.err
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			resp, err := h(ctx, path.MatchingContext{R: req})
+			if err != nil {
+				t.Fatalf("ErrJsonResponse() unexpected error: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, path.MatchingContext{R: req}); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ErrJsonResponse() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+		})
+	}
+}
@@ -2,21 +2,45 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
+	"net/http"
 	"runtime/debug"
 )
 
-// PanicRecover is middleware that recovers from panic and convert it to an error
+// PanicError is the error returned by PanicRecover, that contains the recovered value and the stack trace of the panic.
+// The stack trace is not part of the error message, so it is never sent to the clients
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("recover from panic: %v", e.Value)
+}
+
+// PanicRecover is middleware that recovers from the handler panics and convert them to a *PanicError. The recovered value
+// and the stack trace are logged, as separate messages, with the request logger (see logging.GetLoggerFromContext).
+// The http.ErrAbortHandler panics are propagated, while the panics raised when the response is written are handled
+// by the router (see router.PanicConfig)
 func PanicRecover() Middleware {
 	return func(handler handler.Handler) handler.Handler {
 		return func(ctx context.Context, mc path.MatchingContext) (resp response.HttpResponse, err error) {
 			defer func() {
 				if r := recover(); r != nil {
-					err = fmt.Errorf("recover from panic, stack: [%s]", string(debug.Stack()))
+					if abortErr, ok := r.(error); ok && errors.Is(abortErr, http.ErrAbortHandler) {
+						panic(r)
+					}
+					stack := debug.Stack()
+					logger := logging.GetLoggerFromContext(ctx)
+					logger.Error("recover from panic", "panic", r)
+					logger.Error("panic stack", "stack", string(stack))
+					err = &PanicError{Value: r, Stack: stack}
 				}
 			}()
 			resp, err = handler(ctx, mc)
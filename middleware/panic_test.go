@@ -1,12 +1,16 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	goerrors "errors"
 	"github.com/ixtendio/gofre/errors"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
+	"log"
 	"reflect"
 	"strings"
 	"testing"
@@ -55,8 +59,18 @@ func TestPanicMiddleware(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx := context.WithValue(context.Background(), logging.LoggerCtxKey, logging.NewStdLogger(log.New(&buf, "", 0), logging.LevelInfo))
 			m := PanicRecover()
-			resp, err := m(tt.handler)(context.Background(), path.MatchingContext{})
+			resp, err := m(tt.handler)(ctx, path.MatchingContext{})
+			var panicErr *PanicError
+			if goerrors.As(err, &panicErr) {
+				if logs := buf.String(); !strings.Contains(logs, `msg="recover from panic" panic="a panic message"`) || !strings.Contains(logs, `msg="panic stack" stack=`) {
+					t.Errorf("PanicRecover() logged: %q, want the panic and its stack", logs)
+				}
+			} else if buf.Len() > 0 {
+				t.Errorf("PanicRecover() logged: %q, want nothing", buf.String())
+			}
 			if err != nil {
 				if strings.Index(err.Error(), tt.want.errMsgPrefix) != 0 {
 					t.Errorf("PanicRecover() = %v, want to start with: %v", err.Error(), tt.want.errMsgPrefix)
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	goerrors "errors"
+	"github.com/ixtendio/gofre/errors"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+)
+
+// A ProblemEnricher adds members to the problem details of an error, for example a "traceId" extension
+type ProblemEnricher func(ctx context.Context, mc path.MatchingContext, err error, problem *response.ProblemDetails)
+
+// ErrProblemResponse translates an error to an RFC 9457 application/problem+json response (see ProblemFromError).
+// The enrichers are called, in order, before the response is created
+func ErrProblemResponse(enrichers ...ProblemEnricher) Middleware {
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			resp, err := handler(ctx, mc)
+			if err != nil {
+				problem := ProblemFromError(ctx, mc, err)
+				for _, enricher := range enrichers {
+					enricher(ctx, mc, err, &problem)
+				}
+				return response.ProblemHttpResponse(problem), nil
+			}
+			return resp, err
+		}
+	}
+}
+
+// ProblemFromError returns the problem details of an error. The members of an errors.ErrProblem (that can be wrapped) are used
+// as they are, while any other error is an "about:blank" problem, with the status code resolved by Error2HttpStatusCode.
+// The detail of the server errors (5xx) is hidden, unless it is explicitly set by an errors.ErrProblem or the error is an errors.PublicError.
+// The code of an errors.CodedError is added as the "code" extension, the request id (see RequestId) as the "requestId" extension,
+// and the instance is the request path
+func ProblemFromError(ctx context.Context, mc path.MatchingContext, err error) response.ProblemDetails {
+	var problem response.ProblemDetails
+	var problemErr *errors.ErrProblem
+	if goerrors.As(err, &problemErr) {
+		problem = response.ProblemDetails{
+			Type:   problemErr.Type,
+			Title:  problemErr.Title,
+			Status: problemErr.Status,
+			Detail: problemErr.Detail,
+		}
+		cause := problemErr.Unwrap()
+		if problem.Status == 0 {
+			problem.Status = http.StatusInternalServerError
+			if cause != nil {
+				problem.Status = Error2HttpStatusCode(cause)
+			}
+		}
+		if problem.Detail == "" && cause != nil && problem.Status < http.StatusInternalServerError {
+			problem.Detail = LocalizeError(ctx, cause)
+		}
+		if len(problemErr.Extensions) > 0 {
+			problem.Extensions = make(map[string]any, len(problemErr.Extensions))
+			for name, value := range problemErr.Extensions {
+				problem.Extensions[name] = value
+			}
+		}
+	} else {
+		problem = response.NewProblemDetails(Error2HttpStatusCode(err), "")
+		if problem.Status < http.StatusInternalServerError || isPublicError(err) {
+			problem.Detail = LocalizeError(ctx, err)
+		}
+	}
+	if code := errorCode(err); len(code) > 0 {
+		if _, found := problem.Extensions["code"]; !found {
+			if problem.Extensions == nil {
+				problem.Extensions = map[string]any{}
+			}
+			problem.Extensions["code"] = code
+		}
+	}
+	if requestId := GetRequestIdFromContext(ctx); len(requestId) > 0 {
+		if _, found := problem.Extensions["requestId"]; !found {
+			if problem.Extensions == nil {
+				problem.Extensions = map[string]any{}
+			}
+			problem.Extensions["requestId"] = requestId
+		}
+	}
+	if problem.Type == "" {
+		problem.Type = response.DefaultProblemType
+	}
+	if problem.Title == "" && problem.Type == response.DefaultProblemType {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	problem.Title = i18n.Localize(ctx, problem.Title)
+	if mc.R != nil {
+		problem.Instance = mc.R.URL.Path
+	}
+	return problem
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"github.com/ixtendio/gofre/errors"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrProblemResponse(t *testing.T) {
+	catalog := i18n.NewCatalog("en")
+	if err := catalog.AddJSON("fr", []byte(`{"Not Found": "Introuvable", "user not found": "Utilisateur introuvable"}`)); err != nil {
+		t.Fatalf("AddJSON() unexpected error: %v", err)
+	}
+	traceId := func(ctx context.Context, mc path.MatchingContext, err error, problem *response.ProblemDetails) {
+		if problem.Extensions == nil {
+			problem.Extensions = map[string]any{}
+		}
+		problem.Extensions["traceId"] = "4bf92f3577b34da6"
+	}
+	tests := []struct {
+		name           string
+		err            error
+		locale         string
+		requestId      string
+		enrichers      []ProblemEnricher
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "client error",
+			err:            errors.NewObjectNotFoundWithMessage("user not found"),
+			wantStatusCode: http.StatusNotFound,
+			wantBody:       `{"detail":"user not found","instance":"/users/10","status":404,"title":"Not Found","type":"about:blank"}`,
+		},
+		{
+			name:           "localized client error",
+			err:            errors.NewObjectNotFoundWithMessage("user not found"),
+			locale:         "fr",
+			wantStatusCode: http.StatusNotFound,
+			wantBody:       `{"detail":"Utilisateur introuvable","instance":"/users/10","status":404,"title":"Introuvable","type":"about:blank"}`,
+		},
+		{
+			name:           "server error hides the detail",
+			err:            goerrors.New("db connection refused"),
+			wantStatusCode: http.StatusInternalServerError,
+			wantBody:       `{"instance":"/users/10","status":500,"title":"Internal Server Error","type":"about:blank"}`,
+		},
+		{
+			name: "wrapped problem error with extensions",
+			err: fmt.Errorf("checkout: %w", errors.NewProblem(http.StatusForbidden, "https://example.com/problems/out-of-credit", "You do not have enough credit", "Your current balance is 30, but that costs 50").
+				WithExtension("balance", 30)),
+			wantStatusCode: http.StatusForbidden,
+			wantBody:       `{"balance":30,"detail":"Your current balance is 30, but that costs 50","instance":"/users/10","status":403,"title":"You do not have enough credit","type":"https://example.com/problems/out-of-credit"}`,
+		},
+		{
+			name:           "problem error with the status from the cause",
+			err:            errors.NewProblemFromError("https://example.com/problems/validation", "Validation failed", errors.NewBadRequestWithMessage("invalid email")).WithExtension("errors", []string{"email"}),
+			wantStatusCode: http.StatusBadRequest,
+			wantBody:       `{"detail":"invalid email","errors":["email"],"instance":"/users/10","status":400,"title":"Validation failed","type":"https://example.com/problems/validation"}`,
+		},
+		{
+			name:           "public server error with code",
+			err:            errors.NewServiceUnavailable("maintenance in progress").WithCode("maintenance").Wrap(goerrors.New("db is read-only")),
+			wantStatusCode: http.StatusServiceUnavailable,
+			wantBody:       `{"code":"maintenance","detail":"maintenance in progress","instance":"/users/10","status":503,"title":"Service Unavailable","type":"about:blank"}`,
+		},
+		{
+			name:           "enrichers",
+			err:            errors.ErrAccessDenied,
+			enrichers:      []ProblemEnricher{traceId},
+			wantStatusCode: http.StatusForbidden,
+			wantBody:       `{"detail":"access denied","instance":"/users/10","status":403,"title":"Forbidden","traceId":"4bf92f3577b34da6","type":"about:blank"}`,
+		},
+		{
+			name:           "request id",
+			err:            goerrors.New("db connection refused"),
+			requestId:      "7f3c2a9e",
+			wantStatusCode: http.StatusInternalServerError,
+			wantBody:       `{"instance":"/users/10","requestId":"7f3c2a9e","status":500,"title":"Internal Server Error","type":"about:blank"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(tt.locale) > 0 {
+				ctx = context.WithValue(ctx, i18n.LocaleCtxKey, catalog.Locale(tt.locale))
+			}
+			if len(tt.requestId) > 0 {
+				ctx = context.WithValue(ctx, RequestIdCtxKey, tt.requestId)
+			}
+			mc := path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/users/10", nil)}
+			h := ErrProblemResponse(tt.enrichers...)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return nil, tt.err
+			})
+			resp, err := h(ctx, mc)
+			if err != nil {
+				t.Fatalf("ErrProblemResponse() unexpected error: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, mc); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ErrProblemResponse() got: %d %s, want: %d %s", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("ErrProblemResponse() Content-Type got: %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
+	"github.com/ixtendio/gofre/router"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+)
+
+type requestIdCtxKey int
+
+// RequestIdCtxKey is used to pass the request id to the context.Context
+const RequestIdCtxKey requestIdCtxKey = 1
+
+// the maximum length of an incoming request id
+const maxRequestIdLength = 128
+
+// GetRequestIdFromContext returns the request id from the context.Context or an empty string if missing (see RequestId)
+func GetRequestIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if requestId, ok := ctx.Value(RequestIdCtxKey).(string); ok {
+		return requestId
+	}
+	return ""
+}
+
+// RequestIdConfig contains the settings of the RequestId middleware.
+// If no custom values are provided for the struct fields then, the default one are used
+type RequestIdConfig struct {
+	// the request and the response header of the request id. Default: response.HeaderRequestId (X-Request-Id)
+	Header string
+	// if true, the incoming request ids are ignored and a new one is always generated, for example when the clients are not trusted. Default: false
+	IgnoreIncoming bool
+	// the request id generator. Default: 128 random bits, hex encoded
+	Generator func() string
+}
+
+func (c *RequestIdConfig) setDefaults() {
+	if c.Header == "" {
+		c.Header = response.HeaderRequestId
+	}
+	if c.Generator == nil {
+		c.Generator = newRequestId
+	}
+}
+
+// RequestId is a middleware that accepts the incoming request id (up to 128 visible ASCII chars) or generates a new one,
+// and echoes it on the response. The request id is passed to the context.Context, to extract it, you have to use the
+// method GetRequestIdFromContext(context.Context), and it is added to the request logger, to the error responses
+// (see ErrJsonResponse, ErrProblemResponse and ErrHtmlResponse) and to the RequestDumper output.
+// The request id is passed to the router too (see router.SetRequestId), so the errors logged by the router
+// (with the ErrLogFunc) and the AccessLog lines contain it, and the responses written by the router for the uncaught errors echo it. The request headers are not changed. It should be the first middleware
+func RequestId(config RequestIdConfig) Middleware {
+	config.setDefaults()
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			var requestId string
+			if !config.IgnoreIncoming {
+				requestId = mc.R.Header.Get(config.Header)
+			}
+			if !isValidRequestId(requestId) {
+				requestId = config.Generator()
+			}
+			ctx = context.WithValue(ctx, RequestIdCtxKey, requestId)
+			ctx = logging.WithFields(ctx, logging.FieldRequestId, requestId)
+			router.SetRequestId(ctx, config.Header, requestId)
+
+			resp, err := handler(ctx, mc)
+			if resp != nil {
+				if headers := resp.Headers(); headers != nil {
+					headers[http.CanonicalHeaderKey(config.Header)] = requestId
+				}
+			}
+			return resp, err
+		}
+	}
+}
+
+// isValidRequestId returns true if the request id is not empty and contains only visible ASCII chars, so it can be safely logged
+func isValidRequestId(requestId string) bool {
+	if len(requestId) == 0 || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if c := requestId[i]; c <= ' ' || c > '~' || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestId returns 128 random bits, hex encoded
+func newRequestId() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic("failed to generate a request id: " + err.Error())
+	}
+	return hex.EncodeToString(id[:])
+}
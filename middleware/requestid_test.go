@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	goerrors "errors"
+	"github.com/ixtendio/gofre/logging"
+	"github.com/ixtendio/gofre/router"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	generator := func() string { return "generated-id" }
+	tests := []struct {
+		name           string
+		config         RequestIdConfig
+		header         http.Header
+		handlerErr     error
+		wantRequestId  string
+		wantHeader     string
+		wantErrLogLine string
+	}{
+		{
+			name:          "incoming request id",
+			config:        RequestIdConfig{Generator: generator},
+			header:        http.Header{"X-Request-Id": {"4bf92f35-77b3"}},
+			wantRequestId: "4bf92f35-77b3",
+			wantHeader:    "X-Request-Id",
+		},
+		{
+			name:          "generated request id",
+			config:        RequestIdConfig{Generator: generator},
+			wantRequestId: "generated-id",
+			wantHeader:    "X-Request-Id",
+		},
+		{
+			name:          "invalid incoming request id",
+			config:        RequestIdConfig{Generator: generator},
+			header:        http.Header{"X-Request-Id": {"id\nlevel=ERROR"}},
+			wantRequestId: "generated-id",
+			wantHeader:    "X-Request-Id",
+		},
+		{
+			name:          "too long incoming request id",
+			config:        RequestIdConfig{Generator: generator},
+			header:        http.Header{"X-Request-Id": {strings.Repeat("a", 129)}},
+			wantRequestId: "generated-id",
+			wantHeader:    "X-Request-Id",
+		},
+		{
+			name:          "ignored incoming request id",
+			config:        RequestIdConfig{Generator: generator, IgnoreIncoming: true},
+			header:        http.Header{"X-Request-Id": {"4bf92f35-77b3"}},
+			wantRequestId: "generated-id",
+			wantHeader:    "X-Request-Id",
+		},
+		{
+			name:          "custom header",
+			config:        RequestIdConfig{Header: "X-Correlation-Id", Generator: generator},
+			header:        http.Header{"X-Correlation-Id": {"4bf92f35-77b3"}},
+			wantRequestId: "4bf92f35-77b3",
+			wantHeader:    "X-Correlation-Id",
+		},
+		{
+			name:           "uncaught error is logged only with the generated request id",
+			config:         RequestIdConfig{Generator: generator, IgnoreIncoming: true},
+			header:         http.Header{"X-Request-Id": {"4bf92f35-77b3"}},
+			handlerErr:     goerrors.New("db connection refused"),
+			wantRequestId:  "generated-id",
+			wantHeader:     "X-Request-Id",
+			wantErrLogLine: "uncaught error in GoFre framework method=GET route=/users/{id} requestId=generated-id err=\"db connection refused\"",
+		},
+		{
+			name:           "uncaught error is logged with the request id",
+			config:         RequestIdConfig{Generator: generator},
+			handlerErr:     goerrors.New("db connection refused"),
+			wantRequestId:  "generated-id",
+			wantHeader:     "X-Request-Id",
+			wantErrLogLine: "uncaught error in GoFre framework method=GET route=/users/{id} requestId=generated-id err=\"db connection refused\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRequestId, gotRequestHeader string
+			var gotLogger logging.Logger
+			var gotStats router.WriteStats
+			var errLogs []string
+			h := RequestId(tt.config)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				gotRequestId = GetRequestIdFromContext(ctx)
+				gotRequestHeader = mc.R.Header.Get(response.HeaderRequestId)
+				gotLogger = logging.GetLoggerFromContext(ctx)
+				if tt.handlerErr != nil {
+					return nil, tt.handlerErr
+				}
+				return response.PlainTextHttpResponseOK("ok"), nil
+			})
+			r := router.NewRouter(false, func(err error) {
+				errLogs = append(errLogs, err.Error())
+			}).Handle(http.MethodGet, "/users/{id}", h).ObserveWrites(func(req *http.Request, stats router.WriteStats) {
+				gotStats = stats
+			})
+			req := httptest.NewRequest(http.MethodGet, "/users/10", nil)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, req)
+
+			if gotRequestId != tt.wantRequestId {
+				t.Errorf("GetRequestIdFromContext() got: %q, want: %q", gotRequestId, tt.wantRequestId)
+			}
+			if want := tt.header.Get(response.HeaderRequestId); gotRequestHeader != want {
+				t.Errorf("RequestId() X-Request-Id request header got: %q, want: %q", gotRequestHeader, want)
+			}
+			if gotStats.RequestId != tt.wantRequestId {
+				t.Errorf("RequestId() WriteStats.RequestId got: %q, want: %q", gotStats.RequestId, tt.wantRequestId)
+			}
+			if gotLogger == nil {
+				t.Errorf("RequestId() the request logger is missing")
+			}
+			if len(tt.wantHeader) > 0 {
+				if got := recorder.Header().Get(tt.wantHeader); got != tt.wantRequestId {
+					t.Errorf("RequestId() %s response header got: %q, want: %q", tt.wantHeader, got, tt.wantRequestId)
+				}
+			}
+			if len(tt.wantErrLogLine) > 0 {
+				if len(errLogs) != 1 || errLogs[0] != tt.wantErrLogLine {
+					t.Errorf("RequestId() error logs got: %q, want: %q", errLogs, tt.wantErrLogLine)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRequestId(t *testing.T) {
+	ids := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id := newRequestId()
+		if len(id) != 32 || !isValidRequestId(id) {
+			t.Fatalf("newRequestId() got: %q, want 32 hex chars", id)
+		}
+		if ids[id] {
+			t.Fatalf("newRequestId() got a duplicate: %q", id)
+		}
+		ids[id] = true
+	}
+}
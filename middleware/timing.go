@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"github.com/ixtendio/gofre/auth"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/router/path"
+	"github.com/ixtendio/gofre/timing"
+
+	"github.com/ixtendio/gofre/response"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+const serverTimingHeaderName = "Server-Timing"
+
+// The metrics recorded by the ServerTiming middleware
+const (
+	// the time spent in the handler and in the next middlewares
+	ServerTimingHandler = "handler"
+	// the time spent in the HttpResponse.Write until the response headers are written, for example to render a buffered template
+	ServerTimingRender = "render"
+)
+
+// ServerTimingConfig contains the settings of the ServerTiming middleware.
+// If no restriction is configured, the Server-Timing header is sent to all the clients
+type ServerTimingConfig struct {
+	// the networks of the clients (by the request remote address) that receive the header, for example 10.0.0.0/8. Default: nil
+	AllowedNetworks []netip.Prefix
+	// if not nil, the security principals (see auth.GetSecurityPrincipalFromContext) that receive the header. The middleware
+	// should be registered after the middlewares that authenticate the request. Default: nil
+	AllowPrincipal func(principal auth.SecurityPrincipal) bool
+}
+
+// allowed returns true if the client can receive the Server-Timing header: from an allowed network or an allowed principal
+func (c *ServerTimingConfig) allowed(ctx context.Context, req *http.Request) bool {
+	if len(c.AllowedNetworks) == 0 && c.AllowPrincipal == nil {
+		return true
+	}
+	if len(c.AllowedNetworks) > 0 && isRemoteAddrAllowed(req, c.AllowedNetworks) {
+		return true
+	}
+	if c.AllowPrincipal != nil {
+		if principal := auth.GetSecurityPrincipalFromContext(ctx); principal != nil && c.AllowPrincipal(principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerTiming is a middleware that collects the named durations of the request and sends them in the Server-Timing header,
+// so they can be inspected in the browser devtools. The handlers record their own metrics with timing.Start(ctx, "db").Stop()
+// or timing.Add, while the middleware records the handler time (ServerTimingHandler) and the time spent in the HttpResponse.Write
+// until the headers are written (ServerTimingRender). The metrics recorded after the headers were written, for example while
+// a stream is written, are not sent. The errors returned by the handler are not timed, so the middleware should be registered
+// after the error middlewares
+func ServerTiming(config ServerTimingConfig) Middleware {
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			if !config.allowed(ctx, mc.R) {
+				return handler(ctx, mc)
+			}
+			timings := timing.NewTimings()
+			start := time.Now()
+			resp, err := handler(timing.ContextWithTimings(ctx, timings), mc)
+			if err != nil || resp == nil {
+				return resp, err
+			}
+			timings.Add(ServerTimingHandler, "", time.Since(start))
+			return &serverTimingResponse{HttpResponse: resp, timings: timings}, nil
+		}
+	}
+}
+
+// serverTimingResponse adds the Server-Timing header when the wrapped response writes its headers
+type serverTimingResponse struct {
+	response.HttpResponse
+	timings *timing.Timings
+}
+
+func (r *serverTimingResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
+	tw := &serverTimingResponseWriter{ResponseWriter: w, timings: r.timings, start: time.Now()}
+	err := r.HttpResponse.Write(tw.responseWriter(), mc)
+	// the headers are written by the http.Server if the response has no body
+	tw.addHeader()
+	return err
+}
+
+// serverTimingResponseWriter adds the Server-Timing header before the headers are written
+type serverTimingResponseWriter struct {
+	http.ResponseWriter
+	timings     *timing.Timings
+	start       time.Time
+	headerAdded bool
+}
+
+func (w *serverTimingResponseWriter) addHeader() {
+	if w.headerAdded {
+		return
+	}
+	w.headerAdded = true
+	w.timings.Add(ServerTimingRender, "", time.Since(w.start))
+	w.ResponseWriter.Header().Set(serverTimingHeaderName, w.timings.Header())
+}
+
+func (w *serverTimingResponseWriter) WriteHeader(statusCode int) {
+	w.addHeader()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *serverTimingResponseWriter) Write(bytes []byte) (int, error) {
+	w.addHeader()
+	return w.ResponseWriter.Write(bytes)
+}
+
+// ReadFrom is used by io.Copy, for example by the http.ServeContent, and delegates to the io.ReaderFrom of the wrapped
+// http.ResponseWriter, if it implements it, so that the files can still be sent with sendfile
+func (w *serverTimingResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.addHeader()
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(src)
+	}
+	// hides the ReadFrom method, otherwise io.Copy calls it again
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, src)
+}
+
+func (w *serverTimingResponseWriter) flush() {
+	w.addHeader()
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *serverTimingResponseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	// the hijacked connections have no response headers
+	w.headerAdded = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter, used by the http.ResponseController
+func (w *serverTimingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// responseWriter returns the http.ResponseWriter passed to the response, which implements http.Flusher and http.Hijacker
+// only if the wrapped http.ResponseWriter implements them
+func (w *serverTimingResponseWriter) responseWriter() http.ResponseWriter {
+	_, flusher := w.ResponseWriter.(http.Flusher)
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+	switch {
+	case flusher && hijacker:
+		return serverTimingFlusherHijacker{w}
+	case flusher:
+		return serverTimingFlusher{w}
+	case hijacker:
+		return serverTimingHijacker{w}
+	}
+	return w
+}
+
+type serverTimingFlusher struct {
+	*serverTimingResponseWriter
+}
+
+func (w serverTimingFlusher) Flush() {
+	w.flush()
+}
+
+type serverTimingHijacker struct {
+	*serverTimingResponseWriter
+}
+
+func (w serverTimingHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+type serverTimingFlusherHijacker struct {
+	*serverTimingResponseWriter
+}
+
+func (w serverTimingFlusherHijacker) Flush() {
+	w.flush()
+}
+
+func (w serverTimingFlusherHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
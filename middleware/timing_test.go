@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/auth"
+	"github.com/ixtendio/gofre/router/path"
+	"github.com/ixtendio/gofre/timing"
+
+	"github.com/ixtendio/gofre/response"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerTiming(t *testing.T) {
+	internal := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+	isAdmin := func(principal auth.SecurityPrincipal) bool {
+		return principal.Identity() == "admin"
+	}
+	tests := []struct {
+		name       string
+		config     ServerTimingConfig
+		remoteAddr string
+		principal  auth.SecurityPrincipal
+		resp       response.HttpResponse
+		want       string
+	}{
+		{
+			name:       "all the clients",
+			config:     ServerTimingConfig{},
+			remoteAddr: "203.0.113.10:51234",
+			resp:       response.PlainTextHttpResponseOK("ok"),
+			want:       `^db;desc="users";dur=[0-9.]+, cache;dur=2, handler;dur=[0-9.]+, render;dur=[0-9.]+$`,
+		},
+		{
+			name:       "internal ip",
+			config:     ServerTimingConfig{AllowedNetworks: internal},
+			remoteAddr: "10.1.2.3:51234",
+			resp:       response.PlainTextHttpResponseOK("ok"),
+			want:       `^db;desc="users";dur=[0-9.]+, cache;dur=2, handler;dur=[0-9.]+, render;dur=[0-9.]+$`,
+		},
+		{
+			name:       "internal ipv6",
+			config:     ServerTimingConfig{AllowedNetworks: internal},
+			remoteAddr: "[::1]:51234",
+			resp:       response.PlainTextHttpResponseOK("ok"),
+			want:       `^db;desc="users";dur=[0-9.]+, cache;dur=2, handler;dur=[0-9.]+, render;dur=[0-9.]+$`,
+		},
+		{
+			name:       "external ip",
+			config:     ServerTimingConfig{AllowedNetworks: internal},
+			remoteAddr: "203.0.113.10:51234",
+			resp:       response.PlainTextHttpResponseOK("ok"),
+			want:       `^$`,
+		},
+		{
+			name:       "authorized principal",
+			config:     ServerTimingConfig{AllowedNetworks: internal, AllowPrincipal: isAdmin},
+			remoteAddr: "203.0.113.10:51234",
+			principal:  auth.User{Id: "admin"},
+			resp:       response.PlainTextHttpResponseOK("ok"),
+			want:       `^db;desc="users";dur=[0-9.]+, cache;dur=2, handler;dur=[0-9.]+, render;dur=[0-9.]+$`,
+		},
+		{
+			name:       "unauthorized principal",
+			config:     ServerTimingConfig{AllowPrincipal: isAdmin},
+			remoteAddr: "10.1.2.3:51234",
+			principal:  auth.User{Id: "john"},
+			resp:       response.PlainTextHttpResponseOK("ok"),
+			want:       `^$`,
+		},
+		{
+			name:       "response without body",
+			config:     ServerTimingConfig{},
+			remoteAddr: "10.1.2.3:51234",
+			resp:       &response.HttpHeadersResponse{HttpStatusCode: http.StatusNoContent},
+			want:       `^db;desc="users";dur=[0-9.]+, cache;dur=2, handler;dur=[0-9.]+, render;dur=[0-9.]+$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.RemoteAddr = tt.remoteAddr
+			mc := path.MatchingContext{R: req}
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = context.WithValue(ctx, auth.SecurityPrincipalCtxKey, tt.principal)
+			}
+			h := ServerTiming(tt.config)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				timer := timing.Start(ctx, "db").Describe("users")
+				time.Sleep(time.Millisecond)
+				timer.Stop()
+				timing.Add(ctx, "cache", 2*time.Millisecond)
+				return tt.resp, nil
+			})
+			resp, err := h(ctx, mc)
+			if err != nil {
+				t.Fatalf("ServerTiming() unexpected error: %v", err)
+			}
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, mc); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if got := recorder.Header().Get("Server-Timing"); !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("ServerTiming() Server-Timing header got: %q, want to match: %q", got, tt.want)
+			}
+			if recorder.Code != tt.resp.StatusCode() {
+				t.Errorf("ServerTiming() status code got: %d, want: %d", recorder.Code, tt.resp.StatusCode())
+			}
+		})
+	}
+}
+
+// writerFuncResponse calls a function with the http.ResponseWriter
+type writerFuncResponse func(w http.ResponseWriter)
+
+func (r writerFuncResponse) StatusCode() int               { return http.StatusOK }
+func (r writerFuncResponse) Headers() response.HttpHeaders { return nil }
+func (r writerFuncResponse) Cookies() response.HttpCookies { return nil }
+func (r writerFuncResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
+	r(w)
+	return nil
+}
+
+// readerFromRecorder is a httptest.ResponseRecorder that implements io.ReaderFrom, like the http.Server response writer
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFromCalls int
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFromCalls++
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestServerTiming_ResponseWriterFeatures(t *testing.T) {
+	h := ServerTiming(ServerTimingConfig{})(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.ContentHttpResponse("file.txt", time.Time{}, strings.NewReader("content")), nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/file.txt", nil)
+	mc := path.MatchingContext{R: req}
+	resp, err := h(context.Background(), mc)
+	if err != nil {
+		t.Fatalf("ServerTiming() unexpected error: %v", err)
+	}
+
+	// the files are still copied with the io.ReaderFrom of the http.ResponseWriter
+	recorder := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	if err := resp.Write(recorder, mc); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if recorder.readFromCalls != 1 || recorder.Body.String() != "content" {
+		t.Errorf("Write() ReadFrom calls got: %d, body: %q, want: 1 call and body: content", recorder.readFromCalls, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Server-Timing"); !strings.HasPrefix(got, "handler;dur=") {
+		t.Errorf("Write() Server-Timing header got: %q", got)
+	}
+
+	// the http.Flusher is implemented only if the http.ResponseWriter implements it
+	for _, tt := range []struct {
+		w           http.ResponseWriter
+		wantFlusher bool
+	}{
+		{w: httptest.NewRecorder(), wantFlusher: true},
+		{w: struct{ http.ResponseWriter }{httptest.NewRecorder()}, wantFlusher: false},
+	} {
+		var gotFlusher bool
+		resp := &serverTimingResponse{
+			HttpResponse: writerFuncResponse(func(w http.ResponseWriter) {
+				_, gotFlusher = w.(http.Flusher)
+			}),
+			timings: timing.NewTimings(),
+		}
+		if err := resp.Write(tt.w, mc); err != nil {
+			t.Fatalf("Write() unexpected error: %v", err)
+		}
+		if gotFlusher != tt.wantFlusher {
+			t.Errorf("Write() http.Flusher got: %v, want: %v", gotFlusher, tt.wantFlusher)
+		}
+	}
+}
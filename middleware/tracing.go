@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
+	"github.com/ixtendio/gofre/router/path"
+	"github.com/ixtendio/gofre/tracing"
+
+	"github.com/ixtendio/gofre/response"
+	"net/http"
+	"strconv"
+)
+
+// The request logger fields with the trace identifiers
+const (
+	LogFieldTraceId = "traceId"
+	LogFieldSpanId  = "spanId"
+)
+
+// Tracing is a middleware that starts a server span for each request, child of the remote span from the W3C traceparent
+// and tracestate headers, if valid, or the root span of a new trace. The span is named by the request method and the route
+// pattern (for example "GET /users/{id}") and it ends when the handler returns.
+// The span is passed to the context.Context, to extract it, you have to use the method tracing.GetSpanFromContext(context.Context),
+// while the child spans can be started with tracing.StartSpan(context.Context, ...). The trace identifiers are added to the request logger
+func Tracing(tracer *tracing.Tracer) Middleware {
+	return func(handler handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			if sc, ok := tracing.Extract(mc.R.Header); ok {
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, sc)
+			}
+			route := mc.MatchedPattern()
+			name := mc.R.Method
+			if route != "" {
+				name += " " + route
+			}
+			ctx, span := tracer.Start(ctx, name, tracing.SpanKindServer)
+			defer span.End()
+			span.SetAttribute("http.request.method", mc.R.Method)
+			if route != "" {
+				span.SetAttribute("http.route", route)
+			}
+			if mc.R.URL != nil {
+				span.SetAttribute("url.path", mc.R.URL.Path)
+			}
+			sc := span.SpanContext()
+			ctx = logging.WithFields(ctx, LogFieldTraceId, sc.TraceID.String(), LogFieldSpanId, sc.SpanID.String())
+
+			resp, err := handler(ctx, mc)
+			statusCode := http.StatusOK
+			if err != nil {
+				statusCode = Error2HttpStatusCode(err)
+			} else if resp != nil && resp.StatusCode() > 0 {
+				statusCode = resp.StatusCode()
+			}
+			span.SetAttribute("http.response.status_code", statusCode)
+			// the client errors (4xx) are not errors of the server span
+			if statusCode >= http.StatusInternalServerError {
+				if err != nil {
+					span.RecordError(err)
+				} else {
+					span.SetStatus(tracing.StatusError, strconv.Itoa(statusCode))
+				}
+			} else if err != nil {
+				span.SetAttribute("exception.message", err.Error())
+			}
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"github.com/ixtendio/gofre/errors"
+	"github.com/ixtendio/gofre/logging"
+	"github.com/ixtendio/gofre/router"
+	"github.com/ixtendio/gofre/router/path"
+	"github.com/ixtendio/gofre/tracing"
+
+	"github.com/ixtendio/gofre/response"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name           string
+		traceparent    string
+		handlerErr     error
+		wantName       string
+		wantAttributes map[string]any
+		wantStatus     tracing.StatusCode
+	}{
+		{
+			name:     "new trace",
+			wantName: "GET /users/{id}",
+			wantAttributes: map[string]any{
+				"http.request.method":       http.MethodGet,
+				"http.route":                "/users/{id}",
+				"url.path":                  "/users/10",
+				"http.response.status_code": http.StatusOK,
+			},
+		},
+		{
+			name:        "remote parent",
+			traceparent: traceparent,
+			wantName:    "GET /users/{id}",
+			wantAttributes: map[string]any{
+				"http.request.method":       http.MethodGet,
+				"http.route":                "/users/{id}",
+				"url.path":                  "/users/10",
+				"http.response.status_code": http.StatusOK,
+			},
+		},
+		{
+			name:        "client error",
+			traceparent: "00-invalid",
+			handlerErr:  errors.NewObjectNotFoundWithMessage("user not found"),
+			wantName:    "GET /users/{id}",
+			wantAttributes: map[string]any{
+				"http.request.method":       http.MethodGet,
+				"http.route":                "/users/{id}",
+				"url.path":                  "/users/10",
+				"http.response.status_code": http.StatusNotFound,
+				"exception.message":         "user not found",
+			},
+		},
+		{
+			name:       "server error",
+			handlerErr: errors.NewServiceUnavailable("maintenance"),
+			wantName:   "GET /users/{id}",
+			wantAttributes: map[string]any{
+				"http.request.method":       http.MethodGet,
+				"http.route":                "/users/{id}",
+				"url.path":                  "/users/10",
+				"http.response.status_code": http.StatusServiceUnavailable,
+				"exception.message":         errors.NewServiceUnavailable("maintenance").Error(),
+			},
+			wantStatus: tracing.StatusError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var exported []*tracing.Span
+			tracer := tracing.NewTracer(tracing.TracerConfig{Exporter: tracing.ExporterFunc(func(ctx context.Context, serviceName string, spans []*tracing.Span) error {
+				exported = append(exported, spans...)
+				return nil
+			})})
+			var handlerSpan *tracing.Span
+			h := Tracing(tracer)(func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				handlerSpan = tracing.GetSpanFromContext(ctx)
+				logging.GetLoggerFromContext(ctx).Info("loading user")
+				_, child := tracing.StartSpan(ctx, "load user", tracing.SpanKindInternal)
+				child.End()
+				if tt.handlerErr != nil {
+					return nil, tt.handlerErr
+				}
+				return response.PlainTextHttpResponseOK("ok"), nil
+			})
+			var logs bytes.Buffer
+			r := router.NewRouter(false, nil).
+				WithLogger(logging.NewStdLogger(log.New(&logs, "", 0), logging.LevelInfo)).
+				Handle(http.MethodGet, "/users/{id}", h)
+			req := httptest.NewRequest(http.MethodGet, "/users/10", nil)
+			if tt.traceparent != "" {
+				req.Header.Set(tracing.HeaderTraceparent, tt.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			tracer.Shutdown(context.Background())
+
+			if len(exported) != 2 {
+				t.Fatalf("Tracing() exported spans got = %d, want 2", len(exported))
+			}
+			child, span := exported[0], exported[1]
+			if span != handlerSpan || span.Kind != tracing.SpanKindServer || span.Name != tt.wantName {
+				t.Fatalf("Tracing() span got = %v %v, want the server span %v", span.Kind, span.Name, tt.wantName)
+			}
+			if child.ParentSpanID != span.SpanContext().SpanID {
+				t.Errorf("Tracing() child span parent got = %v, want %v", child.ParentSpanID, span.SpanContext().SpanID)
+			}
+			if tt.traceparent == traceparent {
+				remote, _ := tracing.ParseTraceparent(traceparent)
+				if span.SpanContext().TraceID != remote.TraceID || span.ParentSpanID != remote.SpanID {
+					t.Errorf("Tracing() span got = %v, parent: %v, want child of %v", span.SpanContext(), span.ParentSpanID, remote)
+				}
+			} else if span.ParentSpanID.IsValid() {
+				t.Errorf("Tracing() span parent got = %v, want none", span.ParentSpanID)
+			}
+			if !reflect.DeepEqual(span.Attributes, tt.wantAttributes) {
+				t.Errorf("Tracing() span attributes got = %v, want %v", span.Attributes, tt.wantAttributes)
+			}
+			if span.StatusCode != tt.wantStatus {
+				t.Errorf("Tracing() span status got = %v, want %v", span.StatusCode, tt.wantStatus)
+			}
+			sc := span.SpanContext()
+			wantLog := "traceId=" + sc.TraceID.String() + " spanId=" + sc.SpanID.String()
+			if !strings.Contains(logs.String(), wantLog) {
+				t.Errorf("Tracing() logs got = %v, want to contain %v", logs.String(), wantLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ixtendio/gofre/router/path"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// the buffers that grew over this size are not put back in the pool, so that a few big responses will not keep the memory allocated
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// RenderError can be returned by an ExecutableTemplate when the error was rendered as a page, for example the templates
+// parse errors in development mode. When a buffered response fails with a RenderError, its Page is written instead of the default error body
+type RenderError struct {
+	Err         error
+	ContentType string
+	Page        []byte
+}
+
+func (e *RenderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// writeBuffered writes the headers, the Content-Length and the buffered body
+func (r *HttpHeadersResponse) writeBuffered(w http.ResponseWriter, mc path.MatchingContext, body []byte) error {
+	w.Header().Set(headerContentLength, strconv.Itoa(len(body)))
+	if err := r.Write(w, mc); err != nil {
+		w.Header().Del(headerContentLength)
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// writeRenderFailure releases the headers and the cookies of the failed response and writes a http.StatusInternalServerError
+// response with the default body or, if the error is a RenderError, with the rendered page
+func writeRenderFailure(w http.ResponseWriter, r *HttpHeadersResponse, renderErr error, contentType string, body string) {
+	r.release()
+	var re *RenderError
+	if errors.As(renderErr, &re) && len(re.Page) > 0 {
+		contentType, body = re.ContentType, string(re.Page)
+	}
+	header := w.Header()
+	header.Set(HeaderContentType, contentType)
+	header.Set(HeaderContentTypeOptions, "nosniff")
+	header.Set(headerContentLength, strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write([]byte(body))
+}
@@ -0,0 +1,118 @@
+package response
+
+import (
+	"errors"
+	"github.com/ixtendio/gofre/router/path"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type failingTemplate struct {
+	partialOutput string
+	err           error
+}
+
+func (t failingTemplate) Execute(wr io.Writer, data any) error {
+	return t.ExecuteTemplate(wr, "", data)
+}
+
+func (t failingTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	if _, err := io.WriteString(wr, t.partialOutput); err != nil {
+		return err
+	}
+	return t.err
+}
+
+func TestBufferedResponses_Failure(t *testing.T) {
+	renderErr := errors.New("render failure")
+	type want struct {
+		httpCode    int
+		httpHeaders http.Header
+		body        string
+	}
+	tests := []struct {
+		name string
+		resp HttpResponse
+		want want
+	}{
+		{
+			name: "template error",
+			resp: TemplateHttpResponseWithHeadersAndCookies(failingTemplate{partialOutput: "<html>partial", err: renderErr}, http.StatusOK, "index", nil,
+				HttpHeaders{"X-Custom": "val"}, NewHttpCookie(&http.Cookie{Name: "session", Value: "1"})),
+			want: want{
+				httpCode:    http.StatusInternalServerError,
+				httpHeaders: http.Header{"Content-Type": {plainTextContentType}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"25"}},
+				body:        "500 Internal Server Error",
+			},
+		},
+		{
+			name: "template error with a rendered page",
+			resp: TemplateHttpResponseOK(failingTemplate{partialOutput: "<html>partial", err: &RenderError{Err: renderErr, ContentType: htmlContentType, Page: []byte("<h1>error</h1>")}}, "index", nil),
+			want: want{
+				httpCode:    http.StatusInternalServerError,
+				httpHeaders: http.Header{"Content-Type": {htmlContentType}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"14"}},
+				body:        "<h1>error</h1>",
+			},
+		},
+		{
+			name: "JSON marshal error",
+			resp: JsonHttpResponseWithHeaders(http.StatusCreated, map[string]any{"ch": make(chan int)}, HttpHeaders{"X-Custom": "val"}),
+			want: want{
+				httpCode:    http.StatusInternalServerError,
+				httpHeaders: http.Header{"Content-Type": {jsonContentType}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"33"}},
+				body:        internalServerErrorJson,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			err := tt.resp.Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/", nil)})
+			if err == nil {
+				t.Fatalf("Write() want error but got nil")
+			}
+			got := want{
+				httpCode:    recorder.Code,
+				httpHeaders: recorder.Header(),
+				body:        recorder.Body.String(),
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Write() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnbufferedResponses(t *testing.T) {
+	tmplResp := TemplateHttpResponseOK(failingTemplate{partialOutput: "<html>partial", err: errors.New("render failure")}, "index", nil)
+	tmplResp.Unbuffered = true
+	recorder := httptest.NewRecorder()
+	if err := tmplResp.Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/", nil)}); err == nil {
+		t.Errorf("Write() want error but got nil")
+	}
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "<html>partial" || recorder.Header().Get("Content-Length") != "" {
+		t.Errorf("Write() got: %d %q %v, want the partial output", recorder.Code, recorder.Body.String(), recorder.Header())
+	}
+
+	jsonResp := JsonHttpResponseOK(map[string]string{"name": "john"})
+	jsonResp.Unbuffered = true
+	recorder = httptest.NewRecorder()
+	if err := jsonResp.Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/", nil)}); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"name":"john"}` || recorder.Header().Get("Content-Length") != "" {
+		t.Errorf("Write() got: %d %q %v", recorder.Code, recorder.Body.String(), recorder.Header())
+	}
+}
+
+func TestPutBuffer(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("content")
+	putBuffer(buf)
+	if got := getBuffer(); got.Len() != 0 {
+		t.Errorf("getBuffer() returned a buffer with content: %q", got.String())
+	}
+}
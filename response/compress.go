@@ -5,7 +5,7 @@ import (
 	"compress/gzip"
 	"errors"
 	"github.com/ixtendio/gofre/router/path"
-
+	"io"
 	"net/http"
 	"strings"
 )
@@ -46,31 +46,17 @@ func (r *HttpCompressResponse) Cookies() HttpCookies {
 func (r *HttpCompressResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
 	// detect what compression algorithm to use
 	compressAlg := getCompressionAlgorithmFromHeaderValue(mc.R.Header.Get(acceptEncodingHeaderName))
-
-	if compressAlg == "gzip" {
-		cw, err := gzip.NewWriterLevel(w, r.compressionLevel)
-		if err == nil {
-			delete(r.Headers(), acceptEncodingHeaderName)
-			w.Header().Set(contentEncodingHeaderName, compressAlg)
-			w = &compressResponseWriter{
-				origResponseWriter: w,
-				compressWriter:     cw,
-			}
-			defer cw.Close()
-		}
-	} else if compressAlg == "deflate" {
-		cw, err := flate.NewWriter(w, r.compressionLevel)
-		if err == nil {
-			delete(r.Headers(), acceptEncodingHeaderName)
-			w.Header().Set(contentEncodingHeaderName, compressAlg)
-			w = &compressResponseWriter{
-				origResponseWriter: w,
-				compressWriter:     cw,
-			}
-			defer cw.Close()
-		}
+	if compressAlg == "" {
+		return r.httpResponse.Write(w, mc)
+	}
+	delete(r.Headers(), acceptEncodingHeaderName)
+	cw := &compressResponseWriter{
+		origResponseWriter: w,
+		compressAlg:        compressAlg,
+		compressionLevel:   r.compressionLevel,
 	}
-	return r.httpResponse.Write(w, mc)
+	defer cw.close()
+	return r.httpResponse.Write(cw, mc)
 }
 
 func getCompressionAlgorithmFromHeaderValue(acceptEncodingHeaderNameVal string) string {
@@ -95,9 +81,16 @@ type compressWriter interface {
 	Flush() error
 }
 
+// compressResponseWriter decides if the response will be compressed when the status code is written.
+// The partial (206), not modified (304) and no content (204) responses, as well as the responses that
+// are already encoded, are written as they are
 type compressResponseWriter struct {
 	origResponseWriter http.ResponseWriter
+	compressAlg        string
+	compressionLevel   int
 	compressWriter     compressWriter
+	closer             io.Closer
+	headerWritten      bool
 }
 
 func (c *compressResponseWriter) Header() http.Header {
@@ -105,13 +98,63 @@ func (c *compressResponseWriter) Header() http.Header {
 }
 
 func (c *compressResponseWriter) Write(bytes []byte) (int, error) {
-	return c.compressWriter.Write(bytes)
+	if !c.headerWritten {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.compressWriter != nil {
+		return c.compressWriter.Write(bytes)
+	}
+	return c.origResponseWriter.Write(bytes)
 }
 
 func (c *compressResponseWriter) WriteHeader(statusCode int) {
+	if c.headerWritten || statusCode < http.StatusOK {
+		c.origResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	c.headerWritten = true
+	header := c.Header()
+	if statusCode != http.StatusNoContent &&
+		statusCode != http.StatusPartialContent &&
+		statusCode != http.StatusNotModified &&
+		len(header.Get(contentEncodingHeaderName)) == 0 &&
+		len(header.Get(headerContentRange)) == 0 {
+		var err error
+		if c.compressAlg == "gzip" {
+			var gw *gzip.Writer
+			gw, err = gzip.NewWriterLevel(c.origResponseWriter, c.compressionLevel)
+			c.compressWriter, c.closer = gw, gw
+		} else if c.compressAlg == "deflate" {
+			var fw *flate.Writer
+			fw, err = flate.NewWriter(c.origResponseWriter, c.compressionLevel)
+			c.compressWriter, c.closer = fw, fw
+		}
+		if err == nil && c.compressWriter != nil {
+			header.Del(headerContentLength)
+			header.Set(contentEncodingHeaderName, c.compressAlg)
+			addVaryHeader(header, acceptEncodingHeaderName)
+			// the compressed representation is not byte-for-byte identical with the original one
+			if etag := header.Get(headerETag); len(etag) > 0 && !strings.HasPrefix(etag, "W/") {
+				header.Set(headerETag, "W/"+etag)
+			}
+		} else {
+			c.compressWriter, c.closer = nil, nil
+		}
+	}
 	c.origResponseWriter.WriteHeader(statusCode)
 }
 
 func (c *compressResponseWriter) Flush() {
-	c.compressWriter.Flush()
+	if c.compressWriter != nil {
+		c.compressWriter.Flush()
+	}
+	if flusher, ok := c.origResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *compressResponseWriter) close() {
+	if c.closer != nil {
+		c.closer.Close()
+	}
 }
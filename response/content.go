@@ -0,0 +1,132 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ixtendio/gofre/router/path"
+	"io"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerETag          = "ETag"
+	headerContentRange  = "Content-Range"
+	headerContentLength = "Content-Length"
+)
+
+// HttpContentResponse implements response.HttpResponse and serves a seekable content, supporting the
+// conditional requests (If-Match, If-None-Match, If-Modified-Since, If-Unmodified-Since, If-Range) and the
+// range requests (single or multipart/byteranges), based on http.ServeContent.
+//
+// The status code is decided by the request (200, 206, 304, 412 or 416), so the HttpStatusCode field is ignored.
+// If the ContentType is empty, it is detected from the Name extension or, if it fails, from the content itself.
+// The Content is closed after it was written, if it implements io.Closer
+type HttpContentResponse struct {
+	HttpHeadersResponse
+	// the content to serve
+	Content io.ReadSeeker
+	// the name of the content, used to detect the content type
+	Name string
+	// the last modification time. If not zero, the Last-Modified header is written
+	ModTime time.Time
+	// the entity tag of the content. If not empty, the ETag header is written. The value is quoted if necessary
+	ETag string
+	// if not empty, the content will be downloaded as an attachment with this file name
+	AttachmentName string
+}
+
+func (r *HttpContentResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
+	if closer, ok := r.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if r.Content == nil {
+		r.release()
+		w.WriteHeader(http.StatusInternalServerError)
+		return errors.New("the content to serve is nil")
+	}
+
+	r.writeHeaders(w)
+
+	header := w.Header()
+	if len(r.ETag) > 0 && len(header.Get(headerETag)) == 0 {
+		header.Set(headerETag, quoteETag(r.ETag))
+	}
+	if len(r.AttachmentName) > 0 {
+		header.Set(HeaderContentDisposition, ContentDispositionAttachment(r.AttachmentName))
+	}
+
+	http.ServeContent(w, mc.R, r.Name, r.ModTime, r.Content)
+	return nil
+}
+
+// quoteETag adds the double quotes to an entity tag, if they are missing
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// FileETag returns an entity tag computed from the file modification time and size
+func FileETag(info fs.FileInfo) string {
+	return `"` + strconv.FormatInt(info.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(info.Size(), 16) + `"`
+}
+
+// ContentHttpResponse creates a response that serves a seekable content with support for range and conditional requests
+func ContentHttpResponse(name string, modTime time.Time, content io.ReadSeeker) *HttpContentResponse {
+	return ContentHttpResponseWithHeadersAndCookies(name, modTime, content, nil, nil)
+}
+
+// ContentHttpResponseWithHeadersAndCookies creates a response that serves a seekable content with custom headers and cookies
+// The headers and cookies, if present, once will be written to output will be added in the pool for re-use
+func ContentHttpResponseWithHeadersAndCookies(name string, modTime time.Time, content io.ReadSeeker, headers HttpHeaders, cookies HttpCookies) *HttpContentResponse {
+	contentType := ""
+	if headers != nil {
+		contentType = headers[HeaderContentType]
+	}
+	return &HttpContentResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: http.StatusOK,
+			ContentType:    contentType,
+			HttpHeaders:    headers,
+			HttpCookies:    cookies,
+		},
+		Content: content,
+		Name:    name,
+		ModTime: modTime,
+	}
+}
+
+// FileHttpResponse creates a response that serves a file (for example from os.Open or an fs.FS) with support for range and conditional requests.
+// The name, the modification time and the entity tag are taken from the file info. The file must implement io.Seeker
+func FileHttpResponse(file fs.File) (*HttpContentResponse, error) {
+	return FileAttachmentHttpResponse(file, "")
+}
+
+// FileAttachmentHttpResponse creates a response like FileHttpResponse, that will be downloaded by the browser with the attachment name
+func FileAttachmentHttpResponse(file fs.File, attachmentName string) (*HttpContentResponse, error) {
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		file.Close()
+		return nil, errors.New("the file does not implement io.Seeker")
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read the file info, err: %w", err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("the file: %s is a directory", info.Name())
+	}
+	resp := ContentHttpResponse(info.Name(), info.ModTime(), content)
+	if !info.ModTime().IsZero() {
+		resp.ETag = FileETag(info)
+	}
+	resp.AttachmentName = attachmentName
+	return resp, nil
+}
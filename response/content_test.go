@@ -0,0 +1,229 @@
+package response
+
+import (
+	"compress/gzip"
+	"github.com/ixtendio/gofre/router/path"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestHttpContentResponse_Write(t *testing.T) {
+	modTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	const content = "0123456789"
+	type args struct {
+		reqHeaders     http.Header
+		method         string
+		etag           string
+		attachmentName string
+		cookie         *http.Cookie
+	}
+	type want struct {
+		httpCode    int
+		httpHeaders map[string]string
+		body        string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "full content",
+			args: args{etag: "v1", cookie: &http.Cookie{Name: "c1", Value: "v1"}},
+			want: want{
+				httpCode: http.StatusOK,
+				httpHeaders: map[string]string{
+					"Content-Type":   "text/plain; charset=utf-8",
+					"Content-Length": "10",
+					"Accept-Ranges":  "bytes",
+					"Etag":           `"v1"`,
+					"Last-Modified":  "Sat, 01 Oct 2022 12:00:00 GMT",
+					"Set-Cookie":     "c1=v1",
+				},
+				body: content,
+			},
+		},
+		{
+			name: "head request",
+			args: args{method: http.MethodHead},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: map[string]string{"Content-Length": "10"},
+			},
+		},
+		{
+			name: "single range",
+			args: args{reqHeaders: http.Header{"Range": {"bytes=2-4"}}},
+			want: want{
+				httpCode:    http.StatusPartialContent,
+				httpHeaders: map[string]string{"Content-Range": "bytes 2-4/10", "Content-Length": "3"},
+				body:        "234",
+			},
+		},
+		{
+			name: "multipart byteranges",
+			args: args{reqHeaders: http.Header{"Range": {"bytes=0-1,8-9"}}},
+			want: want{
+				httpCode: http.StatusPartialContent,
+				body:     "Content-Range: bytes 8-9/10",
+			},
+		},
+		{
+			name: "range not satisfiable",
+			args: args{reqHeaders: http.Header{"Range": {"bytes=20-30"}}},
+			want: want{
+				httpCode:    http.StatusRequestedRangeNotSatisfiable,
+				httpHeaders: map[string]string{"Content-Range": "bytes */10"},
+			},
+		},
+		{
+			name: "if-none-match",
+			args: args{etag: `"v1"`, reqHeaders: http.Header{"If-None-Match": {`"v1"`}}},
+			want: want{
+				httpCode: http.StatusNotModified,
+			},
+		},
+		{
+			name: "if-modified-since",
+			args: args{reqHeaders: http.Header{"If-Modified-Since": {"Sat, 01 Oct 2022 12:00:00 GMT"}}},
+			want: want{
+				httpCode: http.StatusNotModified,
+			},
+		},
+		{
+			name: "if-range with a stale etag returns the full content",
+			args: args{etag: "v2", reqHeaders: http.Header{"Range": {"bytes=2-4"}, "If-Range": {`"v1"`}}},
+			want: want{
+				httpCode: http.StatusOK,
+				body:     content,
+			},
+		},
+		{
+			name: "if-range with the current etag returns the range",
+			args: args{etag: "v2", reqHeaders: http.Header{"Range": {"bytes=2-4"}, "If-Range": {`"v2"`}}},
+			want: want{
+				httpCode: http.StatusPartialContent,
+				body:     "234",
+			},
+		},
+		{
+			name: "attachment",
+			args: args{attachmentName: "digits.txt"},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: map[string]string{"Content-Disposition": `attachment; filename="digits.txt"`},
+				body:        content,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.args.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/digits.txt", nil)
+			for k, v := range tt.args.reqHeaders {
+				req.Header[k] = v
+			}
+			resp := ContentHttpResponseWithHeadersAndCookies("digits.txt", modTime, strings.NewReader(content), nil, NewHttpCookie(tt.args.cookie))
+			resp.ETag = tt.args.etag
+			resp.AttachmentName = tt.args.attachmentName
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, path.MatchingContext{R: req}); err != nil {
+				t.Fatalf("HttpContentResponse.Write() unexpected error: %v", err)
+			}
+			if recorder.Code != tt.want.httpCode {
+				t.Errorf("HttpContentResponse.Write() status code = %v, want %v", recorder.Code, tt.want.httpCode)
+			}
+			for k, v := range tt.want.httpHeaders {
+				if got := recorder.Header().Get(k); got != v {
+					t.Errorf("HttpContentResponse.Write() header %s = %q, want %q", k, got, v)
+				}
+			}
+			if tt.want.body != "" && !strings.Contains(recorder.Body.String(), tt.want.body) {
+				t.Errorf("HttpContentResponse.Write() body = %q, want to contain %q", recorder.Body.String(), tt.want.body)
+			}
+		})
+	}
+}
+
+func TestHttpContentResponse_WriteWithCompression(t *testing.T) {
+	modTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                string
+		reqHeaders          http.Header
+		wantCode            int
+		wantContentEncoding string
+		wantETag            string
+	}{
+		{
+			name:                "full content is compressed",
+			reqHeaders:          http.Header{"Accept-Encoding": {"gzip"}},
+			wantCode:            http.StatusOK,
+			wantContentEncoding: "gzip",
+			wantETag:            `W/"v1"`,
+		},
+		{
+			name:       "ranges are not compressed",
+			reqHeaders: http.Header{"Accept-Encoding": {"gzip"}, "Range": {"bytes=0-9"}},
+			wantCode:   http.StatusPartialContent,
+			wantETag:   `"v1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/lorem.txt", nil)
+			req.Header = tt.reqHeaders
+			content := ContentHttpResponse("lorem.txt", modTime, strings.NewReader(bigText))
+			content.ETag = "v1"
+			resp, _ := NewHttpCompressResponse(content, gzip.DefaultCompression)
+			recorder := httptest.NewRecorder()
+			if err := resp.Write(recorder, path.MatchingContext{R: req}); err != nil {
+				t.Fatalf("HttpCompressResponse.Write() unexpected error: %v", err)
+			}
+			if recorder.Code != tt.wantCode {
+				t.Errorf("HttpCompressResponse.Write() status code = %v, want %v", recorder.Code, tt.wantCode)
+			}
+			if got := recorder.Header().Get("Content-Encoding"); got != tt.wantContentEncoding {
+				t.Errorf("HttpCompressResponse.Write() Content-Encoding = %q, want %q", got, tt.wantContentEncoding)
+			}
+			if got := recorder.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("HttpCompressResponse.Write() ETag = %q, want %q", got, tt.wantETag)
+			}
+			if tt.wantContentEncoding != "" {
+				if got := recorder.Header().Get("Content-Length"); got != "" {
+					t.Errorf("HttpCompressResponse.Write() Content-Length = %q, want empty", got)
+				}
+				decompressed, err := decompress(tt.wantContentEncoding, recorder.Body)
+				if err != nil || decompressed != bigText {
+					t.Errorf("HttpCompressResponse.Write() decompress error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileHttpResponse(t *testing.T) {
+	modTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"docs/report.pdf": &fstest.MapFile{Data: []byte("%PDF"), ModTime: modTime},
+	}
+	f, _ := fsys.Open("docs/report.pdf")
+	resp, err := FileAttachmentHttpResponse(f, "raport anual.pdf")
+	if err != nil {
+		t.Fatalf("FileAttachmentHttpResponse() unexpected error: %v", err)
+	}
+	if resp.Name != "report.pdf" || !resp.ModTime.Equal(modTime) || resp.ETag != `"1719f029e4058000-4"` || resp.AttachmentName != "raport anual.pdf" {
+		t.Errorf("FileAttachmentHttpResponse() = %+v", resp)
+	}
+
+	dir, _ := fsys.Open("docs")
+	if _, err := FileHttpResponse(dir); err == nil {
+		t.Errorf("FileHttpResponse() want error for directory")
+	}
+}
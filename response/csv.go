@@ -0,0 +1,362 @@
+package response
+
+import (
+	"context"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"github.com/ixtendio/gofre/router/path"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	csvContentType = "text/csv; charset=utf-8"
+	tsvContentType = "text/tab-separated-values; charset=utf-8"
+	csvTagName     = "csv"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// CsvOptions contains the settings used to write a tabular (CSV, TSV) response
+type CsvOptions struct {
+	// the field delimiter. Default: ','
+	Delimiter rune
+	// if true, the UTF-8 byte order mark is written first (required by some spreadsheet applications to detect the encoding)
+	WriteBOM bool
+	// if true, all the fields are quoted, otherwise only the fields that contain the delimiter, quotes or line breaks
+	QuoteAll bool
+	// if true, the lines are terminated with \r\n instead of \n
+	UseCRLF bool
+	// the download file name. If not empty, a Content-Disposition attachment header is added to the response
+	Filename string
+	// the number of rows after which the response is flushed. Default: 100
+	FlushEvery int
+	// the maximum time between two flushes. Default: 1 second
+	FlushInterval time.Duration
+}
+
+// HttpCsvResponse implements response.HttpResponse and streams tabular data as CSV or TSV.
+//
+// The Iterator elements can be:
+//   - []string - written as they are
+//   - []any - each value is converted to string
+//   - a struct or a pointer to a struct - the exported fields are written in the declaration order. The `csv` tag can be used
+//     to rename a column (`csv:"name"`) or to skip a field (`csv:"-"`)
+//
+// If the Header is nil and the elements are structs, the header row is built from the struct fields.
+// As with HttpJsonStreamResponse, the first row is fetched before writing the headers, so that an early failure will result in a http.StatusInternalServerError.
+type HttpCsvResponse struct {
+	HttpHeadersResponse
+	Header   []string
+	Iterator Iterator
+	Options  CsvOptions
+}
+
+func (r *HttpCsvResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
+	reqCtx := mc.R.Context()
+	item, ok, err := r.next(reqCtx)
+	if err != nil {
+		if reqCtx.Err() != nil {
+			r.release()
+			return nil
+		}
+		writeRenderFailure(w, &r.HttpHeadersResponse, err, plainTextContentType, "500 Internal Server Error")
+		return fmt.Errorf("failed to fetch the first CSV row, err: %w", err)
+	}
+
+	header := r.Header
+	if header == nil && ok {
+		header = csvHeaderFromItem(item)
+	}
+
+	if len(r.Options.Filename) > 0 {
+		w.Header().Set(HeaderContentDisposition, ContentDispositionAttachment(r.Options.Filename))
+	}
+	// write the headers
+	if err := r.HttpHeadersResponse.Write(w, mc); err != nil {
+		return err
+	}
+
+	if r.Options.WriteBOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return fmt.Errorf("failed to write the CSV response, err: %w", err)
+		}
+	}
+
+	flushEvery := r.Options.FlushEvery
+	if flushEvery <= 0 {
+		flushEvery = defaultStreamFlushEvery
+	}
+	flushInterval := r.Options.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultStreamFlushInterval
+	}
+	rw := newCsvRowWriter(w, r.Options)
+	flusher := newPeriodicFlusher(w, flushEvery, flushInterval)
+	flusher.beforeFlush = rw.flush
+	defer flusher.stop()
+	defer flusher.flush()
+
+	if len(header) > 0 {
+		if err := rw.write(header); err != nil {
+			return fmt.Errorf("failed to write the CSV header, err: %w", err)
+		}
+	}
+	for count := 0; ok; count++ {
+		select {
+		case <-reqCtx.Done():
+			return nil
+		default:
+		}
+		record, err := csvRecordFromItem(item)
+		if err != nil {
+			return fmt.Errorf("failed to convert the CSV row: %d, err: %w", count, err)
+		}
+		if err := rw.write(record); err != nil {
+			return fmt.Errorf("failed to write the CSV row: %d, err: %w", count, err)
+		}
+		flusher.itemWritten()
+
+		item, ok, err = flusher.next(reqCtx, r.next)
+		if err != nil {
+			if reqCtx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch the CSV row: %d, err: %w", count+1, err)
+		}
+	}
+	return rw.err()
+}
+
+func (r *HttpCsvResponse) next(ctx context.Context) (any, bool, error) {
+	if r.Iterator == nil {
+		return nil, false, nil
+	}
+	return r.Iterator(ctx)
+}
+
+// csvRowWriter writes the records using encoding/csv or, when all the fields should be quoted, its own encoder
+type csvRowWriter struct {
+	w         io.Writer
+	cw        *csv.Writer
+	delimiter string
+	lineEnd   string
+	writeErr  error
+}
+
+func newCsvRowWriter(w io.Writer, options CsvOptions) *csvRowWriter {
+	delimiter := options.Delimiter
+	if delimiter == 0 {
+		delimiter = ','
+	}
+	rw := &csvRowWriter{w: w}
+	if options.QuoteAll {
+		rw.delimiter = string(delimiter)
+		rw.lineEnd = "\n"
+		if options.UseCRLF {
+			rw.lineEnd = "\r\n"
+		}
+	} else {
+		rw.cw = csv.NewWriter(w)
+		rw.cw.Comma = delimiter
+		rw.cw.UseCRLF = options.UseCRLF
+	}
+	return rw
+}
+
+func (c *csvRowWriter) write(record []string) error {
+	if c.cw != nil {
+		return c.cw.Write(record)
+	}
+	var sb strings.Builder
+	for i, field := range record {
+		if i > 0 {
+			sb.WriteString(c.delimiter)
+		}
+		sb.WriteByte('"')
+		sb.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		sb.WriteByte('"')
+	}
+	sb.WriteString(c.lineEnd)
+	_, err := io.WriteString(c.w, sb.String())
+	return err
+}
+
+func (c *csvRowWriter) flush() {
+	if c.cw != nil {
+		c.cw.Flush()
+		if err := c.cw.Error(); err != nil && c.writeErr == nil {
+			c.writeErr = err
+		}
+	}
+}
+
+func (c *csvRowWriter) err() error {
+	c.flush()
+	if c.writeErr != nil {
+		return fmt.Errorf("failed to write the CSV response, err: %w", c.writeErr)
+	}
+	return nil
+}
+
+type csvStructField struct {
+	name  string
+	index []int
+}
+
+// csvStructFieldsCache caches the csv fields for each struct type
+var csvStructFieldsCache sync.Map
+
+func csvStructFields(t reflect.Type) []csvStructField {
+	if fields, ok := csvStructFieldsCache.Load(t); ok {
+		return fields.([]csvStructField)
+	}
+	var fields []csvStructField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup(csvTagName); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if len(tagName) > 0 {
+				name = tagName
+			}
+		}
+		fields = append(fields, csvStructField{name: name, index: f.Index})
+	}
+	csvStructFieldsCache.Store(t, fields)
+	return fields
+}
+
+func structValue(item any) (reflect.Value, bool) {
+	v := reflect.ValueOf(item)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Struct
+}
+
+func csvHeaderFromItem(item any) []string {
+	v, ok := structValue(item)
+	if !ok {
+		return nil
+	}
+	fields := csvStructFields(v.Type())
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	return header
+}
+
+func csvRecordFromItem(item any) ([]string, error) {
+	switch row := item.(type) {
+	case []string:
+		return row, nil
+	case []any:
+		record := make([]string, len(row))
+		for i, val := range row {
+			record[i] = formatCsvValue(reflect.ValueOf(val))
+		}
+		return record, nil
+	}
+	v, ok := structValue(item)
+	if !ok {
+		return nil, fmt.Errorf("unsupported row type: %T", item)
+	}
+	fields := csvStructFields(v.Type())
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			// nil embedded struct pointer
+			continue
+		}
+		record[i] = formatCsvValue(fv)
+	}
+	return record, nil
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func formatCsvValue(v reflect.Value) string {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return ""
+		}
+		if v.Type().Implements(textMarshalerType) {
+			break
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	if v.Type().Implements(textMarshalerType) && v.CanInterface() {
+		if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	if v.CanInterface() {
+		return fmt.Sprint(v.Interface())
+	}
+	return ""
+}
+
+// CsvHttpResponse creates a 200 success CSV response. If the filename is not empty, the response will be downloaded as an attachment
+func CsvHttpResponse(filename string, header []string, iterator Iterator) *HttpCsvResponse {
+	return CsvHttpResponseWithOptions(http.StatusOK, header, iterator, CsvOptions{Filename: filename}, nil, nil)
+}
+
+// TsvHttpResponse creates a 200 success TSV (tab separated values) response. If the filename is not empty, the response will be downloaded as an attachment
+func TsvHttpResponse(filename string, header []string, iterator Iterator) *HttpCsvResponse {
+	return CsvHttpResponseWithOptions(http.StatusOK, header, iterator, CsvOptions{Filename: filename, Delimiter: '\t'}, nil, nil)
+}
+
+// CsvHttpResponseWithOptions creates a tabular response with a specific status code, options, custom headers and cookies
+// The content type is text/tab-separated-values if the delimiter is a tab character, and text/csv otherwise
+// The headers and cookies, if present, once will be written to output will be added in the pool for re-use
+func CsvHttpResponseWithOptions(statusCode int, header []string, iterator Iterator, options CsvOptions, headers HttpHeaders, cookies HttpCookies) *HttpCsvResponse {
+	contentType := csvContentType
+	if options.Delimiter == '\t' {
+		contentType = tsvContentType
+	}
+	return &HttpCsvResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: statusCode,
+			ContentType:    contentType,
+			HttpHeaders:    headers,
+			HttpCookies:    cookies,
+		},
+		Header:   header,
+		Iterator: iterator,
+		Options:  options,
+	}
+}
@@ -0,0 +1,191 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"github.com/ixtendio/gofre/router/path"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type csvEmbedded struct {
+	Country string `csv:"country"`
+}
+
+type csvUser struct {
+	csvEmbedded
+	Id       int       `csv:"id"`
+	Name     string    `csv:"name"`
+	Password string    `csv:"-"`
+	Score    *float64  `csv:"score"`
+	Created  time.Time `csv:"created"`
+	Active   bool
+	internal string
+}
+
+func TestHttpCsvResponse_Write(t *testing.T) {
+	score := 9.5
+	created := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	type args struct {
+		header   []string
+		iterator Iterator
+		options  CsvOptions
+	}
+	type want struct {
+		httpCode    int
+		httpHeaders http.Header
+		body        string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "string rows with header",
+			args: args{
+				header:   []string{"id", "name"},
+				iterator: SliceIterator([][]string{{"1", "John"}, {"2", "Doe, Jane"}}),
+			},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"text/csv; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        "id,name\n1,John\n2,\"Doe, Jane\"\n",
+			},
+		},
+		{
+			name: "struct rows with header derived from tags",
+			args: args{
+				iterator: SliceIterator([]*csvUser{
+					{csvEmbedded: csvEmbedded{Country: "RO"}, Id: 1, Name: "John", Password: "secret", Score: &score, Created: created, Active: true},
+					{Id: 2, Name: "Jane"},
+				}),
+			},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"text/csv; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        "country,id,name,score,created,Active\nRO,1,John,9.5,2022-10-01T12:00:00Z,true\n,2,Jane,,0001-01-01T00:00:00Z,false\n",
+			},
+		},
+		{
+			name: "tsv with BOM, quote all, CRLF and download filename",
+			args: args{
+				header:   []string{"id", "name"},
+				iterator: SliceIterator([][]any{{1, `say "hi"`}}),
+				options:  CsvOptions{Delimiter: '\t', WriteBOM: true, QuoteAll: true, UseCRLF: true, Filename: "export.tsv"},
+			},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"text/tab-separated-values; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}, "Content-Disposition": {`attachment; filename="export.tsv"`}},
+				body:        "\xEF\xBB\xBF\"id\"\t\"name\"\r\n\"1\"\t\"say \"\"hi\"\"\"\r\n",
+			},
+		},
+		{
+			name: "iterator fails before the first row",
+			args: args{
+				iterator: func(ctx context.Context) (any, bool, error) {
+					return nil, false, errors.New("db down")
+				},
+			},
+			want: want{
+				httpCode:    http.StatusInternalServerError,
+				httpHeaders: http.Header{"Content-Type": {"text/plain; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"25"}},
+				body:        "500 Internal Server Error",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported row type",
+			args: args{
+				header:   []string{"id"},
+				iterator: SliceIterator([]int{1}),
+			},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"text/csv; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        "id\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "nil row",
+			args: args{
+				header:   []string{"id"},
+				iterator: SliceIterator([]any{nil}),
+			},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"text/csv; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        "id\n",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := CsvHttpResponseWithOptions(http.StatusOK, tt.args.header, tt.args.iterator, tt.args.options, nil, nil)
+			recorder := httptest.NewRecorder()
+			err := resp.Write(recorder, path.MatchingContext{R: &http.Request{}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HttpCsvResponse.Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := want{
+				httpCode:    recorder.Code,
+				httpHeaders: recorder.Header(),
+				body:        recorder.Body.String(),
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HttpCsvResponse.Write() got:  %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpCsvResponse_WriteFirstRowFailureReleases(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "iterator failure", ctx: context.Background()},
+		{name: "cancelled context", ctx: cancelledCtx},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := NewHttpHeaders()
+			headers["X-Custom"] = "value"
+			cookies := NewHttpCookie(&http.Cookie{Name: "session", Value: "1"})
+			it := func(ctx context.Context) (any, bool, error) {
+				if err := ctx.Err(); err != nil {
+					return nil, false, err
+				}
+				return nil, false, errors.New("db down")
+			}
+			resp := CsvHttpResponseWithOptions(http.StatusOK, nil, it, CsvOptions{}, headers, cookies)
+			req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(tt.ctx)
+			_ = resp.Write(httptest.NewRecorder(), path.MatchingContext{R: req})
+			if len(headers) > 0 || len(cookies) > 0 {
+				t.Errorf("HttpCsvResponse.Write() the headers: %v and the cookies: %v were not released", headers, cookies)
+			}
+		})
+	}
+}
+
+func TestTsvHttpResponse(t *testing.T) {
+	want := &HttpCsvResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: http.StatusOK,
+			ContentType:    tsvContentType,
+		},
+		Header:  []string{"id"},
+		Options: CsvOptions{Filename: "a.tsv", Delimiter: '\t'},
+	}
+	if got := TsvHttpResponse("a.tsv", []string{"id"}, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("TsvHttpResponse() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package response
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const HeaderContentDisposition = "Content-Disposition"
+
+const upperHex = "0123456789ABCDEF"
+
+// ContentDispositionAttachment returns a Content-Disposition header value that asks the browser to download the content using the filename.
+// The value contains an ASCII fallback (RFC 6266) and, when the filename contains characters outside of it, an UTF-8 encoded filename* parameter (RFC 5987)
+func ContentDispositionAttachment(filename string) string {
+	return contentDisposition("attachment", filename)
+}
+
+// ContentDispositionInline returns a Content-Disposition header value that asks the browser to display the content inline, keeping the filename for a later save
+func ContentDispositionInline(filename string) string {
+	return contentDisposition("inline", filename)
+}
+
+func contentDisposition(dispositionType string, filename string) string {
+	if len(filename) == 0 {
+		return dispositionType
+	}
+	var fallback strings.Builder
+	needsExtValue := false
+	for _, c := range filename {
+		switch {
+		case c == '/' || c == '\\':
+			fallback.WriteByte('_')
+			needsExtValue = true
+		case c == '"':
+			fallback.WriteString(`\"`)
+		case c < 0x20 || c == 0x7f || c >= utf8.RuneSelf:
+			fallback.WriteByte('_')
+			needsExtValue = true
+		default:
+			fallback.WriteRune(c)
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(dispositionType)
+	sb.WriteString(`; filename="`)
+	sb.WriteString(fallback.String())
+	sb.WriteByte('"')
+	if needsExtValue {
+		sb.WriteString("; filename*=UTF-8''")
+		for _, b := range []byte(filename) {
+			if b == '/' || b == '\\' {
+				sb.WriteByte('_')
+			} else if isRFC5987AttrChar(b) {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('%')
+				sb.WriteByte(upperHex[b>>4])
+				sb.WriteByte(upperHex[b&0x0f])
+			}
+		}
+	}
+	return sb.String()
+}
+
+func isRFC5987AttrChar(b byte) bool {
+	if (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') {
+		return true
+	}
+	switch b {
+	case '!', '#', '$', '&', '+', '-', '.', '^', '_', '`', '|', '~':
+		return true
+	}
+	return false
+}
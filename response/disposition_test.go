@@ -0,0 +1,50 @@
+package response
+
+import "testing"
+
+func TestContentDispositionAttachment(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+	}{
+		{
+			name:     "empty filename",
+			filename: "",
+			want:     "attachment",
+		},
+		{
+			name:     "ascii filename",
+			filename: "report 2022.csv",
+			want:     `attachment; filename="report 2022.csv"`,
+		},
+		{
+			name:     "filename with quotes",
+			filename: `my "report".csv`,
+			want:     `attachment; filename="my \"report\".csv"`,
+		},
+		{
+			name:     "non ascii filename",
+			filename: "rapport-été.csv",
+			want:     `attachment; filename="rapport-_t_.csv"; filename*=UTF-8''rapport-%C3%A9t%C3%A9.csv`,
+		},
+		{
+			name:     "filename with path separators",
+			filename: "../etc/passwd",
+			want:     `attachment; filename=".._etc_passwd"; filename*=UTF-8''.._etc_passwd`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentDispositionAttachment(tt.filename); got != tt.want {
+				t.Errorf("ContentDispositionAttachment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentDispositionInline(t *testing.T) {
+	if got, want := ContentDispositionInline("image.png"), `inline; filename="image.png"`; got != want {
+		t.Errorf("ContentDispositionInline() = %v, want %v", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/ixtendio/gofre/router/path"
 	"net/http"
+	"sort"
 )
 
 // HttpHeadersResponse implements response.HttpResponse and provides HTTP headers write
@@ -36,25 +37,37 @@ func (r *HttpHeadersResponse) Cookies() HttpCookies {
 }
 
 func (r *HttpHeadersResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
-	defer func() {
-		if r.HttpHeaders != nil {
-			r.HttpHeaders.Release()
-		}
-		if r.HttpCookies != nil {
-			r.HttpCookies.Release()
-		}
-	}()
-
 	statusCode := r.StatusCode()
 	if statusCode < 100 || statusCode > 999 {
+		r.release()
 		return errors.New("http status code should be between 100 and 999")
 	}
 
-	// Write the cookies
-	if r.HttpCookies != nil {
+	r.writeHeaders(w)
+
+	// Write the status code to the response.
+	w.WriteHeader(statusCode)
+	return nil
+}
+
+// writeHeaders writes the cookies and the headers (without the status code) and releases them to the pool
+func (r *HttpHeadersResponse) writeHeaders(w http.ResponseWriter) {
+	defer r.release()
+
+	// Write the cookies (sorted, so that the output is deterministic)
+	if len(r.HttpCookies) == 1 {
 		for _, cookie := range r.HttpCookies {
 			http.SetCookie(w, cookie)
 		}
+	} else if len(r.HttpCookies) > 1 {
+		ids := make([]string, 0, len(r.HttpCookies))
+		for id := range r.HttpCookies {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			http.SetCookie(w, r.HttpCookies[id])
+		}
 	}
 
 	header := w.Header()
@@ -72,10 +85,15 @@ func (r *HttpHeadersResponse) Write(w http.ResponseWriter, mc path.MatchingConte
 	if len(header.Get(HeaderContentTypeOptions)) == 0 {
 		header.Set(HeaderContentTypeOptions, "nosniff")
 	}
+}
 
-	// Write the status code to the response.
-	w.WriteHeader(statusCode)
-	return nil
+func (r *HttpHeadersResponse) release() {
+	if r.HttpHeaders != nil {
+		r.HttpHeaders.Release()
+	}
+	if r.HttpCookies != nil {
+		r.HttpCookies.Release()
+	}
 }
 
 // InternalServerErrorHttpResponse writes the http.StatusInternalServerError HTTP status code to the client
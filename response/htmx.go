@@ -0,0 +1,113 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderHXRequest  = "HX-Request"
+	HeaderHXBoosted  = "HX-Boosted"
+	HeaderHXTrigger  = "HX-Trigger"
+	HeaderHXRedirect = "HX-Redirect"
+	HeaderHXPushUrl  = "HX-Push-Url"
+	HeaderTurboFrame = "Turbo-Frame"
+)
+
+// FragmentTemplate can be implemented by an ExecutableTemplate whose blocks are scoped by page, like the LayoutTemplate, where
+// each page has its own template set. The other templates render a fragment as a regular template, by its name
+type FragmentTemplate interface {
+	ExecuteFragment(wr io.Writer, pageName string, fragmentName string, data any) error
+}
+
+// executeFragment renders a named block (fragment) of a page
+func executeFragment(template ExecutableTemplate, wr io.Writer, pageName string, fragmentName string, data any) error {
+	if ft, ok := template.(FragmentTemplate); ok {
+		return ft.ExecuteFragment(wr, pageName, fragmentName, data)
+	}
+	return template.ExecuteTemplate(wr, fragmentName, data)
+}
+
+// IsFragmentRequest returns true if the request was sent by htmx (and it is not a boosted request, which expects the full page)
+// or by a Turbo Frame, so that only the page fragments should be rendered
+func IsFragmentRequest(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+	if req.Header.Get(HeaderHXRequest) == "true" {
+		return req.Header.Get(HeaderHXBoosted) != "true"
+	}
+	return len(req.Header.Get(HeaderTurboFrame)) > 0
+}
+
+// HXTrigger sets the HX-Trigger header, so that the client side events will be triggered when the response is received
+func (r *HttpTemplateResponse) HXTrigger(events ...string) *HttpTemplateResponse {
+	r.Headers().Set(HeaderHXTrigger, strings.Join(events, ", "))
+	return r
+}
+
+// HXTriggerWithDetails sets the HX-Trigger header with the events details, encoded as a JSON object
+func (r *HttpTemplateResponse) HXTriggerWithDetails(events map[string]any) error {
+	details, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the HX-Trigger events, err: %w", err)
+	}
+	r.Headers().Set(HeaderHXTrigger, string(details))
+	return nil
+}
+
+// HXPushUrl sets the HX-Push-Url header, so that the URL will be pushed in the browser history
+func (r *HttpTemplateResponse) HXPushUrl(url string) *HttpTemplateResponse {
+	r.Headers().Set(HeaderHXPushUrl, url)
+	return r
+}
+
+// HXRedirect sets the HX-Redirect header, so that the client will do a full page redirect to the URL
+func (r *HttpTemplateResponse) HXRedirect(url string) *HttpTemplateResponse {
+	r.Headers().Set(HeaderHXRedirect, url)
+	return r
+}
+
+// HtmxRedirectHttpResponse creates a 200 response without body that instructs htmx to do a full page redirect (the regular
+// redirects are followed by the browser before htmx receives the response)
+func HtmxRedirectHttpResponse(url string) *HttpHeadersResponse {
+	headers := NewHttpHeaders()
+	headers.Set(HeaderHXRedirect, url)
+	return &HttpHeadersResponse{
+		HttpStatusCode: http.StatusOK,
+		HttpHeaders:    headers,
+	}
+}
+
+// HtmxTemplateHttpResponseOK creates a 200 success HTML response that renders the full page or, for the fragment requests (see IsFragmentRequest),
+// only the page fragments. The first fragment is the swapped content, and the others should be out-of-band swaps (having the hx-swap-oob attribute)
+func HtmxTemplateHttpResponseOK(req *http.Request, template ExecutableTemplate, pageName string, templateData any, fragments ...string) *HttpTemplateResponse {
+	return HtmxTemplateHttpResponseWithHeadersAndCookies(req, template, http.StatusOK, pageName, fragments, templateData, nil, nil)
+}
+
+// HtmxTemplateHttpResponseWithHeadersAndCookies creates an HTML response that renders the full page or the page fragments, with custom headers and cookies.
+// Because the response depends on the request headers, they are added to the Vary header too
+// The headers and cookies, if present, once will be written to output will be added in the pool for re-use
+func HtmxTemplateHttpResponseWithHeadersAndCookies(req *http.Request, template ExecutableTemplate, statusCode int, pageName string, fragments []string, templateData any, headers HttpHeaders, cookies HttpCookies) *HttpTemplateResponse {
+	resp := TemplateHttpResponseWithHeadersAndCookies(template, statusCode, pageName, templateData, headers, cookies)
+	if IsFragmentRequest(req) {
+		resp.Fragments = fragments
+	}
+	respHeaders := resp.Headers()
+	vary := respHeaders[HeaderVary]
+	for _, header := range []string{HeaderHXRequest, HeaderHXBoosted, HeaderTurboFrame} {
+		vary = AppendVary(vary, header)
+	}
+	respHeaders[HeaderVary] = vary
+	return resp
+}
+
+// FragmentTemplateHttpResponseOK creates a 200 success HTML response that always renders only the page fragments, for the endpoints used only by htmx
+func FragmentTemplateHttpResponseOK(template ExecutableTemplate, pageName string, templateData any, fragments ...string) *HttpTemplateResponse {
+	resp := TemplateHttpResponseOK(template, pageName, templateData)
+	resp.Fragments = fragments
+	return resp
+}
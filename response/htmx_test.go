@@ -0,0 +1,201 @@
+package response
+
+import (
+	"github.com/ixtendio/gofre/router/path"
+	html "html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsFragmentRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		want    bool
+	}{
+		{name: "regular request", want: false},
+		{name: "htmx request", headers: http.Header{"Hx-Request": {"true"}}, want: true},
+		{name: "htmx boosted request", headers: http.Header{"Hx-Request": {"true"}, "Hx-Boosted": {"true"}}, want: false},
+		{name: "turbo frame request", headers: http.Header{"Turbo-Frame": {"messages"}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header[k] = v
+			}
+			if got := IsFragmentRequest(req); got != tt.want {
+				t.Errorf("IsFragmentRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if IsFragmentRequest(nil) {
+		t.Errorf("IsFragmentRequest(nil) = true, want false")
+	}
+}
+
+func TestHtmxTemplateHttpResponseOK(t *testing.T) {
+	layoutTemplate := newLayoutTestTemplate(t)
+	htmlTemplate := html.Must(html.New("page").Parse(`<body>{{block "content" .}}content {{.}}{{end}}{{block "counter" .}}<span id="counter" hx-swap-oob="true">1</span>{{end}}</body>`))
+	tests := []struct {
+		name           string
+		template       ExecutableTemplate
+		pageName       string
+		fragments      []string
+		headers        http.Header
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "full page",
+			template:       layoutTemplate,
+			pageName:       "about.html",
+			fragments:      []string{"content"},
+			wantStatusCode: http.StatusOK,
+			wantBody:       `<title>About</title><nav>GO</nav><main>about <button>go</button></main>`,
+		},
+		{
+			name:           "page fragment",
+			template:       layoutTemplate,
+			pageName:       "about.html",
+			fragments:      []string{"content"},
+			headers:        http.Header{"Hx-Request": {"true"}},
+			wantStatusCode: http.StatusOK,
+			wantBody:       `about <button>go</button>`,
+		},
+		{
+			name:           "fragment overridden by a nested layout",
+			template:       layoutTemplate,
+			pageName:       "admin/users.html",
+			fragments:      []string{"content", "title"},
+			headers:        http.Header{"Turbo-Frame": {"main"}},
+			wantStatusCode: http.StatusOK,
+			wantBody:       `<aside></aside>users goAdmin`,
+		},
+		{
+			name:           "out of band fragments from a html template",
+			template:       htmlTemplate,
+			pageName:       "page",
+			fragments:      []string{"content", "counter"},
+			headers:        http.Header{"Hx-Request": {"true"}},
+			wantStatusCode: http.StatusOK,
+			wantBody:       `content go<span id="counter" hx-swap-oob="true">1</span>`,
+		},
+		{
+			name:           "missing fragment",
+			template:       layoutTemplate,
+			pageName:       "about.html",
+			fragments:      []string{"missing"},
+			headers:        http.Header{"Hx-Request": {"true"}},
+			wantStatusCode: http.StatusInternalServerError,
+			wantBody:       "500 Internal Server Error",
+		},
+		{
+			name:           "missing page",
+			template:       layoutTemplate,
+			pageName:       "missing.html",
+			fragments:      []string{"content"},
+			headers:        http.Header{"Hx-Request": {"true"}},
+			wantStatusCode: http.StatusInternalServerError,
+			wantBody:       "500 Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header[k] = v
+			}
+			resp := HtmxTemplateHttpResponseOK(req, tt.template, tt.pageName, "go", tt.fragments...)
+			recorder := httptest.NewRecorder()
+			err := resp.Write(recorder, path.MatchingContext{R: req})
+			if (err != nil) != (tt.wantStatusCode != http.StatusOK) {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("Write() got: %d %q, want: %d %q", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+			if tt.wantStatusCode == http.StatusOK && recorder.Header().Get("Vary") != "HX-Request, HX-Boosted, Turbo-Frame" {
+				t.Errorf("Write() Vary header got: %q", recorder.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestHtmxTemplateHttpResponseWithHeadersAndCookies_Vary(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  HttpHeaders
+		wantVary string
+	}{
+		{
+			name:     "without headers",
+			wantVary: "HX-Request, HX-Boosted, Turbo-Frame",
+		},
+		{
+			name:     "existing Vary header",
+			headers:  HttpHeaders{"Vary": "Accept-Encoding"},
+			wantVary: "Accept-Encoding, HX-Request, HX-Boosted, Turbo-Frame",
+		},
+		{
+			name:     "existing Vary header with HX-Request",
+			headers:  HttpHeaders{"Vary": "hx-request"},
+			wantVary: "hx-request, HX-Boosted, Turbo-Frame",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			resp := HtmxTemplateHttpResponseWithHeadersAndCookies(req, NilTemplate{}, http.StatusOK, "page", nil, nil, tt.headers, nil)
+			if got := resp.HttpHeaders[HeaderVary]; got != tt.wantVary {
+				t.Errorf("HtmxTemplateHttpResponseWithHeadersAndCookies() Vary header got: %q, want: %q", got, tt.wantVary)
+			}
+		})
+	}
+}
+
+func TestHttpTemplateResponse_HtmxHeaders(t *testing.T) {
+	resp := FragmentTemplateHttpResponseOK(newLayoutTestTemplate(t), "index.html", "go", "content").
+		HXTrigger("saved", "refresh").
+		HXPushUrl("/items/1").
+		HXRedirect("/login")
+	recorder := httptest.NewRecorder()
+	if err := resp.Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/", nil)}); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"HX-Trigger":  "saved, refresh",
+		"HX-Push-Url": "/items/1",
+		"HX-Redirect": "/login",
+	}
+	for k, v := range want {
+		if got := recorder.Header().Get(k); got != v {
+			t.Errorf("Write() header %s got: %q, want: %q", k, got, v)
+		}
+	}
+	if recorder.Body.String() != "home go" {
+		t.Errorf("Write() body got: %q, want: %q", recorder.Body.String(), "home go")
+	}
+
+	resp = TemplateHttpResponseOK(newLayoutTestTemplate(t), "index.html", nil)
+	if err := resp.HXTriggerWithDetails(map[string]any{"showMessage": map[string]string{"level": "info"}}); err != nil {
+		t.Fatalf("HXTriggerWithDetails() unexpected error: %v", err)
+	}
+	if got := resp.Headers()["HX-Trigger"]; got != `{"showMessage":{"level":"info"}}` {
+		t.Errorf("HXTriggerWithDetails() header got: %q", got)
+	}
+	if err := resp.HXTriggerWithDetails(map[string]any{"invalid": make(chan int)}); err == nil {
+		t.Errorf("HXTriggerWithDetails() want error but got nil")
+	}
+}
+
+func TestHtmxRedirectHttpResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	if err := HtmxRedirectHttpResponse("/login").Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodPost, "/", nil)}); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if recorder.Code != http.StatusOK || recorder.Header().Get("HX-Redirect") != "/login" || recorder.Body.Len() != 0 {
+		t.Errorf("Write() got: %d %v %q", recorder.Code, recorder.Header(), recorder.Body.String())
+	}
+}
@@ -0,0 +1,49 @@
+package response
+
+import (
+	"context"
+)
+
+// An Iterator is a pull iterator used by the streaming responses to fetch the elements one by one.
+// It returns the next element and true, or false when there are no more elements.
+// A non-nil error stops the iteration.
+type Iterator func(ctx context.Context) (item any, ok bool, err error)
+
+// ChannelIterator adapts a channel to an Iterator. The iteration stops when the channel is closed or the context.Context is cancelled
+func ChannelIterator[T any](ch <-chan T) Iterator {
+	return func(ctx context.Context) (any, bool, error) {
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case item, ok := <-ch:
+			if !ok {
+				return nil, false, nil
+			}
+			return item, true, nil
+		}
+	}
+}
+
+// SliceIterator adapts a slice to an Iterator
+func SliceIterator[T any](items []T) Iterator {
+	idx := 0
+	return func(ctx context.Context) (any, bool, error) {
+		if idx >= len(items) {
+			return nil, false, nil
+		}
+		item := items[idx]
+		idx++
+		return item, true, nil
+	}
+}
+
+// FuncIterator adapts a typed pull function to an Iterator
+func FuncIterator[T any](next func(ctx context.Context) (T, bool, error)) Iterator {
+	return func(ctx context.Context) (any, bool, error) {
+		item, ok, err := next(ctx)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		return item, true, nil
+	}
+}
@@ -0,0 +1,71 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func collectIterator(ctx context.Context, it Iterator) ([]any, error) {
+	var items []any
+	for {
+		item, ok, err := it(ctx)
+		if err != nil {
+			return items, err
+		}
+		if !ok {
+			return items, nil
+		}
+		items = append(items, item)
+	}
+}
+
+func TestChannelIterator(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	close(ch)
+	got, err := collectIterator(context.Background(), ChannelIterator(ch))
+	if err != nil {
+		t.Fatalf("ChannelIterator() unexpected error: %v", err)
+	}
+	if want := []any{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChannelIterator() = %v, want %v", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := collectIterator(ctx, ChannelIterator(make(chan int))); !errors.Is(err, context.Canceled) {
+		t.Errorf("ChannelIterator() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSliceIterator(t *testing.T) {
+	got, err := collectIterator(context.Background(), SliceIterator([]string{"a", "b", "c"}))
+	if err != nil {
+		t.Fatalf("SliceIterator() unexpected error: %v", err)
+	}
+	if want := []any{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SliceIterator() = %v, want %v", got, want)
+	}
+}
+
+func TestFuncIterator(t *testing.T) {
+	errFailed := errors.New("failed")
+	idx := 0
+	it := FuncIterator(func(ctx context.Context) (int, bool, error) {
+		idx++
+		if idx > 2 {
+			return 0, false, errFailed
+		}
+		return idx, true, nil
+	})
+	got, err := collectIterator(context.Background(), it)
+	if !errors.Is(err, errFailed) {
+		t.Errorf("FuncIterator() error = %v, want %v", err, errFailed)
+	}
+	if want := []any{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FuncIterator() = %v, want %v", got, want)
+	}
+}
@@ -1,10 +1,12 @@
 package response
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/ixtendio/gofre/router/path"
 
+	"io"
 	"net/http"
 	"strings"
 )
@@ -12,37 +14,69 @@ import (
 const jsonContentType = "application/json"
 
 var emptyJson = []byte("{}")
+var newLine = []byte("\n")
 
-// HttpJsonResponse implements response.HttpResponse and provides automatic conversion of an object to JSON
+const internalServerErrorJson = `{"error":"Internal server error"}`
+
+// HttpJsonResponse implements response.HttpResponse and provides automatic conversion of an object to JSON.
+// By default, the payload is encoded in a pooled buffer before writing the headers, so that an encoding failure
+// results in a clean http.StatusInternalServerError response, and the Content-Length header is set
 type HttpJsonResponse struct {
 	HttpHeadersResponse
 	Payload any
+	// if true, the payload is encoded directly to the client, after the headers were written. Useful for the large payloads
+	Unbuffered bool
 }
 
 func (r *HttpJsonResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
-	// write the headers
-	if err := r.HttpHeadersResponse.Write(w, mc); err != nil {
-		return err
+	if r.Unbuffered {
+		// write the headers
+		if err := r.HttpHeadersResponse.Write(w, mc); err != nil {
+			return err
+		}
+		if r.Payload == nil {
+			_, err := w.Write(emptyJson)
+			return err
+		}
+		if err := json.NewEncoder(trimNewLineWriter{w}).Encode(r.Payload); err != nil {
+			return fmt.Errorf("failed to write the JSON response, err: %w", err)
+		}
+		return nil
 	}
 
-	var err error
-	var payload []byte
+	payload := emptyJson
 	if r.Payload != nil {
-		payload, err = json.Marshal(r.Payload)
-		if err != nil {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := json.NewEncoder(buf).Encode(r.Payload); err != nil {
+			writeRenderFailure(w, &r.HttpHeadersResponse, err, jsonContentType, internalServerErrorJson)
 			return fmt.Errorf("failed to marshal JSON response, err: %w", err)
 		}
-	} else {
-		payload = emptyJson
+		// remove the new line added by the encoder
+		payload = bytes.TrimSuffix(buf.Bytes(), newLine)
 	}
 
 	// write the JSON response
-	if _, err := w.Write(payload); err != nil {
+	if err := r.writeBuffered(w, mc, payload); err != nil {
 		return fmt.Errorf("failed to write the JSON response, err: %w", err)
 	}
 	return nil
 }
 
+// trimNewLineWriter removes the new line added by the json.Encoder, that writes the encoded value with a single Write call,
+// so that the unbuffered and the buffered JSON responses have the same body
+type trimNewLineWriter struct {
+	io.Writer
+}
+
+func (w trimNewLineWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(bytes.TrimSuffix(p, newLine))
+	if err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
+
 // JsonHttpResponseOK creates a 200 success JSON response
 func JsonHttpResponseOK(payload any) *HttpJsonResponse {
 	return JsonHttpResponseWithHeadersAndCookies(http.StatusOK, payload, nil, nil)
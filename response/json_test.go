@@ -36,7 +36,7 @@ func TestHttpJsonResponse_Write(t *testing.T) {
 			},
 			want: want{
 				httpCode:    201,
-				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}},
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Length": {"2"}},
 				body:        emptyJson,
 			},
 			wantErr: false,
@@ -49,7 +49,7 @@ func TestHttpJsonResponse_Write(t *testing.T) {
 			},
 			want: want{
 				httpCode:    201,
-				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}},
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Length": {"15"}},
 				body:        []byte(`{"status":"ok"}`),
 			},
 			wantErr: false,
@@ -62,7 +62,7 @@ func TestHttpJsonResponse_Write(t *testing.T) {
 			},
 			want: want{
 				httpCode:    201,
-				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}},
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Length": {"7"}},
 				body:        []byte(`"hello"`),
 			},
 			wantErr: false,
@@ -75,7 +75,7 @@ func TestHttpJsonResponse_Write(t *testing.T) {
 			},
 			want: want{
 				httpCode:    202,
-				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Type": {"application/json"}},
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Length": {"2"}, "Content-Type": {"application/json"}},
 				body:        emptyJson,
 			},
 			wantErr: false,
@@ -94,7 +94,7 @@ func TestHttpJsonResponse_Write(t *testing.T) {
 			},
 			want: want{
 				httpCode:    202,
-				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Set-Cookie": {"cookie1=val1", "cookie2=val2"}},
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Length": {"2"}, "Set-Cookie": {"cookie1=val1", "cookie2=val2"}},
 				body:        emptyJson,
 			},
 			wantErr: false,
@@ -112,7 +112,7 @@ func TestHttpJsonResponse_Write(t *testing.T) {
 			},
 			want: want{
 				httpCode:    202,
-				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Set-Cookie": {"cookie3=val3"}, "Header1": {"val1"}},
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Length": {"16"}, "Set-Cookie": {"cookie3=val3"}, "Header1": {"val1"}},
 				body:        []byte(`{"userId":"123"}`),
 			},
 			wantErr: false,
@@ -0,0 +1,252 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ixtendio/gofre/router/path"
+	"net/http"
+	"time"
+)
+
+const ndJsonContentType = "application/x-ndjson"
+
+const (
+	defaultStreamFlushEvery    = 100
+	defaultStreamFlushInterval = 1 * time.Second
+)
+
+var (
+	jsonArrayStart     = []byte("[")
+	jsonArrayEnd       = []byte("]")
+	jsonArraySeparator = []byte(",")
+	ndJsonSeparator    = []byte("\n")
+)
+
+type JsonStreamFormat int
+
+const (
+	// JsonStreamFormatNDJson writes each element as a JSON document on its own line (https://github.com/ndjson/ndjson-spec)
+	JsonStreamFormatNDJson JsonStreamFormat = iota
+	// JsonStreamFormatArray writes the elements as a top level JSON array
+	JsonStreamFormatArray
+)
+
+// HttpJsonStreamResponse implements response.HttpResponse and streams the elements returned by an Iterator as JSON, encoding one element at a time.
+// The response is flushed after every FlushEvery elements or when FlushInterval elapsed since the last flush, even if the
+// Iterator is waiting for the next element (in this case the Iterator is called in its own goroutine).
+// The streaming stops when the request context.Context is cancelled.
+//
+// The first element is fetched before writing the headers, so that an iterator failure at this stage will result in a http.StatusInternalServerError.
+// If the iterator fails later, the headers were already sent and the stream is just interrupted (for JsonStreamFormatArray the closing bracket is not written,
+// so the client will receive an invalid JSON document)
+type HttpJsonStreamResponse struct {
+	HttpHeadersResponse
+	Format   JsonStreamFormat
+	Iterator Iterator
+	// the number of elements after which the response is flushed. Default: 100
+	FlushEvery int
+	// the maximum time between two flushes. Default: 1 second
+	FlushInterval time.Duration
+}
+
+func (r *HttpJsonStreamResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
+	reqCtx := mc.R.Context()
+	item, ok, err := r.next(reqCtx)
+	if err != nil {
+		if reqCtx.Err() != nil {
+			r.release()
+			return nil
+		}
+		writeRenderFailure(w, &r.HttpHeadersResponse, err, jsonContentType, internalServerErrorJson)
+		return fmt.Errorf("failed to fetch the first JSON stream element, err: %w", err)
+	}
+
+	// write the headers
+	if err := r.HttpHeadersResponse.Write(w, mc); err != nil {
+		return err
+	}
+
+	flushEvery := r.FlushEvery
+	if flushEvery <= 0 {
+		flushEvery = defaultStreamFlushEvery
+	}
+	flushInterval := r.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultStreamFlushInterval
+	}
+	flusher := newPeriodicFlusher(w, flushEvery, flushInterval)
+	defer flusher.stop()
+	defer flusher.flush()
+
+	isArray := r.Format == JsonStreamFormatArray
+	if isArray {
+		if _, err := w.Write(jsonArrayStart); err != nil {
+			return fmt.Errorf("failed to write the JSON stream, err: %w", err)
+		}
+	}
+	for count := 0; ok; count++ {
+		select {
+		case <-reqCtx.Done():
+			return nil
+		default:
+		}
+		if isArray && count > 0 {
+			if _, err := w.Write(jsonArraySeparator); err != nil {
+				return fmt.Errorf("failed to write the JSON stream, err: %w", err)
+			}
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the JSON stream element: %d, err: %w", count, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write the JSON stream, err: %w", err)
+		}
+		if !isArray {
+			if _, err := w.Write(ndJsonSeparator); err != nil {
+				return fmt.Errorf("failed to write the JSON stream, err: %w", err)
+			}
+		}
+		flusher.itemWritten()
+
+		item, ok, err = flusher.next(reqCtx, r.next)
+		if err != nil {
+			if reqCtx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch the JSON stream element: %d, err: %w", count+1, err)
+		}
+	}
+	if isArray {
+		if _, err := w.Write(jsonArrayEnd); err != nil {
+			return fmt.Errorf("failed to write the JSON stream, err: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *HttpJsonStreamResponse) next(ctx context.Context) (any, bool, error) {
+	if r.Iterator == nil {
+		return nil, false, nil
+	}
+	return r.Iterator(ctx)
+}
+
+// periodicFlusher flushes the http.ResponseWriter after a number of items or when an interval elapsed since the last flush
+type periodicFlusher struct {
+	flusher       http.Flusher
+	beforeFlush   func()
+	flushEvery    int
+	flushInterval time.Duration
+	pendingItems  int
+	lastFlush     time.Time
+	// the iterator calls and their results, when the iterator is called in its own goroutine (see next)
+	requests chan struct{}
+	results  chan iteratorResult
+}
+
+type iteratorResult struct {
+	item any
+	ok   bool
+	err  error
+}
+
+func newPeriodicFlusher(w http.ResponseWriter, flushEvery int, flushInterval time.Duration) *periodicFlusher {
+	flusher, _ := w.(http.Flusher)
+	return &periodicFlusher{
+		flusher:       flusher,
+		flushEvery:    flushEvery,
+		flushInterval: flushInterval,
+		lastFlush:     time.Now(),
+	}
+}
+
+func (f *periodicFlusher) itemWritten() {
+	f.pendingItems++
+	if f.pendingItems >= f.flushEvery || time.Since(f.lastFlush) >= f.flushInterval {
+		f.flush()
+	}
+}
+
+// next returns the next element of the iterator. If there are pending items, the iterator is called in its own goroutine and,
+// if it doesn't return before the flush interval elapsed since the last flush, the pending items are flushed while waiting
+func (f *periodicFlusher) next(ctx context.Context, iterator Iterator) (any, bool, error) {
+	if f.pendingItems == 0 {
+		return iterator(ctx)
+	}
+	if f.requests == nil {
+		f.requests = make(chan struct{})
+		// buffered, so that the goroutine doesn't block if the result is not read anymore
+		f.results = make(chan iteratorResult, 1)
+		go func(requests <-chan struct{}, results chan<- iteratorResult) {
+			for range requests {
+				item, ok, err := iterator(ctx)
+				results <- iteratorResult{item: item, ok: ok, err: err}
+			}
+		}(f.requests, f.results)
+	}
+	f.requests <- struct{}{}
+	timer := time.NewTimer(f.flushInterval - time.Since(f.lastFlush))
+	defer timer.Stop()
+	timerC := timer.C
+	for {
+		select {
+		case res := <-f.results:
+			return res.item, res.ok, res.err
+		case <-timerC:
+			f.flush()
+			timerC = nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// stop ends the goroutine that calls the iterator, if started
+func (f *periodicFlusher) stop() {
+	if f.requests != nil {
+		close(f.requests)
+		f.requests = nil
+	}
+}
+
+func (f *periodicFlusher) flush() {
+	if f.beforeFlush != nil {
+		f.beforeFlush()
+	}
+	if f.flusher != nil {
+		f.flusher.Flush()
+	}
+	f.pendingItems = 0
+	f.lastFlush = time.Now()
+}
+
+// NDJsonHttpResponse creates a 200 success NDJSON (application/x-ndjson) streaming response
+func NDJsonHttpResponse(iterator Iterator) *HttpJsonStreamResponse {
+	return JsonStreamHttpResponseWithHeadersAndCookies(http.StatusOK, JsonStreamFormatNDJson, iterator, nil, nil)
+}
+
+// JsonArrayStreamHttpResponse creates a 200 success streaming response where the elements are written as a JSON array
+func JsonArrayStreamHttpResponse(iterator Iterator) *HttpJsonStreamResponse {
+	return JsonStreamHttpResponseWithHeadersAndCookies(http.StatusOK, JsonStreamFormatArray, iterator, nil, nil)
+}
+
+// JsonStreamHttpResponseWithHeadersAndCookies creates a JSON streaming response with a specific status code, format, custom headers and cookies
+// The headers and cookies, if present, once will be written to output will be added in the pool for re-use
+func JsonStreamHttpResponseWithHeadersAndCookies(statusCode int, format JsonStreamFormat, iterator Iterator, headers HttpHeaders, cookies HttpCookies) *HttpJsonStreamResponse {
+	contentType := ndJsonContentType
+	if format == JsonStreamFormatArray {
+		contentType = jsonContentType
+	}
+	return &HttpJsonStreamResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: statusCode,
+			ContentType:    contentType,
+			HttpHeaders:    headers,
+			HttpCookies:    cookies,
+		},
+		Format:   format,
+		Iterator: iterator,
+	}
+}
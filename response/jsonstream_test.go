@@ -0,0 +1,252 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"github.com/ixtendio/gofre/router/path"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type flushCounterRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (r *flushCounterRecorder) Flush() {
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+func TestHttpJsonStreamResponse_Write(t *testing.T) {
+	errFailed := errors.New("failed")
+	failingAfter := func(n int) Iterator {
+		idx := 0
+		return func(ctx context.Context) (any, bool, error) {
+			if idx >= n {
+				return nil, false, errFailed
+			}
+			idx++
+			return idx, true, nil
+		}
+	}
+	type args struct {
+		format     JsonStreamFormat
+		iterator   Iterator
+		flushEvery int
+	}
+	type want struct {
+		httpCode    int
+		httpHeaders http.Header
+		body        string
+		flushes     int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "ndjson",
+			args: args{format: JsonStreamFormatNDJson, iterator: SliceIterator([]map[string]int{{"id": 1}, {"id": 2}, {"id": 3}}), flushEvery: 2},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"application/x-ndjson"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n",
+				flushes:     2,
+			},
+		},
+		{
+			name: "json array",
+			args: args{format: JsonStreamFormatArray, iterator: SliceIterator([]string{"a", "b"})},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"application/json"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        `["a","b"]`,
+				flushes:     1,
+			},
+		},
+		{
+			name: "empty json array",
+			args: args{format: JsonStreamFormatArray},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"application/json"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        `[]`,
+				flushes:     1,
+			},
+		},
+		{
+			name: "iterator fails before the first element",
+			args: args{format: JsonStreamFormatArray, iterator: failingAfter(0)},
+			want: want{
+				httpCode:    http.StatusInternalServerError,
+				httpHeaders: http.Header{"Content-Type": {"application/json"}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"33"}},
+				body:        `{"error":"Internal server error"}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "iterator fails after the first element",
+			args: args{format: JsonStreamFormatArray, iterator: failingAfter(1)},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"application/json"}, "X-Content-Type-Options": {"nosniff"}},
+				body:        `[1`,
+				flushes:     1,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := JsonStreamHttpResponseWithHeadersAndCookies(http.StatusOK, tt.args.format, tt.args.iterator, nil, nil)
+			resp.FlushEvery = tt.args.flushEvery
+			recorder := &flushCounterRecorder{ResponseRecorder: httptest.NewRecorder()}
+			err := resp.Write(recorder, path.MatchingContext{R: &http.Request{}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HttpJsonStreamResponse.Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := want{
+				httpCode:    recorder.Code,
+				httpHeaders: recorder.Header(),
+				body:        recorder.Body.String(),
+				flushes:     recorder.flushes,
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HttpJsonStreamResponse.Write() got:  %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpJsonStreamResponse_WriteCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	idx := 0
+	it := FuncIterator(func(ctx context.Context) (int, bool, error) {
+		idx++
+		if idx > 1 {
+			cancel()
+			return 0, false, ctx.Err()
+		}
+		return idx, true, nil
+	})
+	req := (&http.Request{}).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	if err := NDJsonHttpResponse(it).Write(recorder, path.MatchingContext{R: req}); err != nil {
+		t.Fatalf("HttpJsonStreamResponse.Write() unexpected error: %v", err)
+	}
+	if got := recorder.Body.String(); got != "1\n" {
+		t.Errorf("HttpJsonStreamResponse.Write() body = %q, want %q", got, "1\n")
+	}
+}
+
+func TestHttpJsonStreamResponse_WriteFirstElementFailureReleases(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "iterator failure", ctx: context.Background()},
+		{name: "cancelled context", ctx: cancelledCtx},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := NewHttpHeaders()
+			headers["X-Custom"] = "value"
+			cookies := NewHttpCookie(&http.Cookie{Name: "session", Value: "1"})
+			it := func(ctx context.Context) (any, bool, error) {
+				if err := ctx.Err(); err != nil {
+					return nil, false, err
+				}
+				return nil, false, errors.New("failed")
+			}
+			resp := JsonStreamHttpResponseWithHeadersAndCookies(http.StatusOK, JsonStreamFormatNDJson, it, headers, cookies)
+			req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(tt.ctx)
+			_ = resp.Write(httptest.NewRecorder(), path.MatchingContext{R: req})
+			if len(headers) > 0 || len(cookies) > 0 {
+				t.Errorf("HttpJsonStreamResponse.Write() the headers: %v and the cookies: %v were not released", headers, cookies)
+			}
+		})
+	}
+}
+
+// signalFlushRecorder signals each flush, with the body written until then
+type signalFlushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed chan string
+}
+
+func (r *signalFlushRecorder) Flush() {
+	r.flushed <- r.Body.String()
+}
+
+func TestHttpJsonStreamResponse_WriteBlockedIterator(t *testing.T) {
+	unblock := make(chan struct{})
+	idx := 0
+	it := FuncIterator(func(ctx context.Context) (int, bool, error) {
+		idx++
+		switch idx {
+		case 1:
+			return idx, true, nil
+		case 2:
+			// the next element is not available until the written one was flushed
+			<-unblock
+			return idx, true, nil
+		}
+		return 0, false, nil
+	})
+	resp := NDJsonHttpResponse(it)
+	resp.FlushInterval = 10 * time.Millisecond
+	recorder := &signalFlushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan string, 10)}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- resp.Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/", nil)})
+	}()
+	select {
+	case got := <-recorder.flushed:
+		if got != "1\n" {
+			t.Errorf("HttpJsonStreamResponse.Write() flushed body = %q, want %q", got, "1\n")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("HttpJsonStreamResponse.Write() the written element was not flushed while the iterator is blocked")
+	}
+	close(unblock)
+	if err := <-errCh; err != nil {
+		t.Fatalf("HttpJsonStreamResponse.Write() unexpected error: %v", err)
+	}
+	if got := recorder.Body.String(); got != "1\n2\n" {
+		t.Errorf("HttpJsonStreamResponse.Write() body = %q, want %q", got, "1\n2\n")
+	}
+}
+
+func TestNDJsonHttpResponse(t *testing.T) {
+	want := &HttpJsonStreamResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: http.StatusOK,
+			ContentType:    ndJsonContentType,
+		},
+		Format: JsonStreamFormatNDJson,
+	}
+	if got := NDJsonHttpResponse(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("NDJsonHttpResponse() = %v, want %v", got, want)
+	}
+}
+
+func TestJsonArrayStreamHttpResponse(t *testing.T) {
+	want := &HttpJsonStreamResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: http.StatusOK,
+			ContentType:    jsonContentType,
+		},
+		Format: JsonStreamFormatArray,
+	}
+	if got := JsonArrayStreamHttpResponse(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("JsonArrayStreamHttpResponse() = %v, want %v", got, want)
+	}
+}
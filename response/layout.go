@@ -0,0 +1,244 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	html "html/template"
+	"io"
+	"io/fs"
+	pathpkg "path"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultLayoutPagesDir  = "pages"
+	defaultLayoutFileName  = "_layout.html"
+	defaultLayoutExtension = ".html"
+)
+
+// LayoutTemplateOptions contains the settings used to build a LayoutTemplate.
+// If no custom values are provided for the struct fields then, the default one are used
+type LayoutTemplateOptions struct {
+	// the directory (relative to the fs.FS root) that contains the pages. Default: "pages"
+	PagesDir string
+	// the directories (relative to the fs.FS root) that contain the templates shared by all the pages (partials, components). Default: nil
+	PartialsDirs []string
+	// the name of the layout files. Default: "_layout.html"
+	LayoutFileName string
+	// the extension of the pages, layouts and partials files. Default: ".html"
+	Extension string
+	// the functions added to all the templates
+	Funcs html.FuncMap
+}
+
+func (o *LayoutTemplateOptions) setDefaults() {
+	if o.PagesDir == "" {
+		o.PagesDir = defaultLayoutPagesDir
+	}
+	if o.LayoutFileName == "" {
+		o.LayoutFileName = defaultLayoutFileName
+	}
+	if o.Extension == "" {
+		o.Extension = defaultLayoutExtension
+	}
+}
+
+// layoutPage is the template set of a page
+type layoutPage struct {
+	set *html.Template
+	// the name of the template that is executed when the page is rendered (the outermost layout or the page itself)
+	entry string
+}
+
+// LayoutTemplate implements ExecutableTemplate and renders the pages using layouts, where each page has its own template set,
+// so that the same template name (for example "content") can be defined by all the pages.
+//
+// The layouts are inherited from the directory structure. A page is parsed after the layout files from its directory and
+// from all its parent directories (up to PagesDir), from the outermost to the innermost one, and after all the partials. Because the
+// templates parsed later replace the ones with the same name, an inner layout or a page can override any block of an outer layout:
+//
+//	pages/_layout.html        -> <html><body>{{block "content" .}}{{end}}</body></html>
+//	pages/index.html          -> {{define "content"}}home{{end}}
+//	pages/admin/_layout.html  -> {{define "content"}}<nav></nav>{{block "admin" .}}{{end}}{{end}}
+//	pages/admin/users.html    -> {{define "admin"}}users{{end}}
+//
+// The pages are rendered by their path relative to PagesDir (for example "admin/users.html"), using the outermost layout
+// as the entry point or, if the page has no layout, the page itself. Any other name is looked up in the partials.
+type LayoutTemplate struct {
+	pages    map[string]layoutPage
+	partials *html.Template
+}
+
+// NewLayoutTemplate parses the pages, the layouts and the partials from the fs.FS
+func NewLayoutTemplate(fsys fs.FS, options LayoutTemplateOptions) (*LayoutTemplate, error) {
+	options.setDefaults()
+	partials := html.New("").Funcs(options.Funcs)
+	for _, dir := range options.PartialsDirs {
+		dir = cleanFSDir(dir)
+		err := walkTemplateFiles(fsys, dir, options.Extension, func(name string, rel string) error {
+			return parseTemplateFile(fsys, partials, name, rel)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing the partials from: %s, err: %w", dir, err)
+		}
+	}
+
+	pagesDir := cleanFSDir(options.PagesDir)
+	layouts := map[string]string{}
+	var pageNames []string
+	err := walkTemplateFiles(fsys, pagesDir, options.Extension, func(name string, rel string) error {
+		if pathpkg.Base(rel) == options.LayoutFileName {
+			layouts[pathpkg.Dir(rel)] = name
+		} else {
+			pageNames = append(pageNames, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the pages from: %s, err: %w", pagesDir, err)
+	}
+
+	t := &LayoutTemplate{
+		pages:    make(map[string]layoutPage, len(pageNames)),
+		partials: partials,
+	}
+	for _, pageName := range pageNames {
+		set, err := partials.Clone()
+		if err != nil {
+			return nil, err
+		}
+		entry := pageName
+		for _, dir := range parentDirs(pathpkg.Dir(pageName)) {
+			layoutName, ok := layouts[dir]
+			if !ok {
+				continue
+			}
+			layoutRel := pathpkg.Join(dir, options.LayoutFileName)
+			if err := parseTemplateFile(fsys, set, layoutName, layoutRel); err != nil {
+				return nil, fmt.Errorf("failed parsing the layout: %s for the page: %s, err: %w", layoutRel, pageName, err)
+			}
+			if entry == pageName {
+				entry = layoutRel
+			}
+		}
+		if err := parseTemplateFile(fsys, set, pathpkg.Join(pagesDir, pageName), pageName); err != nil {
+			return nil, fmt.Errorf("failed parsing the page: %s, err: %w", pageName, err)
+		}
+		t.pages[pageName] = layoutPage{set: set, entry: entry}
+	}
+	return t, nil
+}
+
+// Clone returns a copy of the LayoutTemplate, whose template sets can be changed, for example with Funcs, without affecting
+// the original. Like for an html/template, the LayoutTemplate can not be cloned after one of its templates was executed
+func (t *LayoutTemplate) Clone() (*LayoutTemplate, error) {
+	partials, err := t.partials.Clone()
+	if err != nil {
+		return nil, err
+	}
+	clone := &LayoutTemplate{
+		pages:    make(map[string]layoutPage, len(t.pages)),
+		partials: partials,
+	}
+	for name, page := range t.pages {
+		set, err := page.set.Clone()
+		if err != nil {
+			return nil, err
+		}
+		clone.pages[name] = layoutPage{set: set, entry: page.entry}
+	}
+	return clone, nil
+}
+
+// Funcs adds the functions to the partials and to the template sets of all the pages, replacing the ones with the same name
+func (t *LayoutTemplate) Funcs(funcMap html.FuncMap) *LayoutTemplate {
+	t.partials.Funcs(funcMap)
+	for _, page := range t.pages {
+		page.set.Funcs(funcMap)
+	}
+	return t
+}
+
+// Execute is not supported, because a LayoutTemplate has no default page. Use ExecuteTemplate instead
+func (t *LayoutTemplate) Execute(wr io.Writer, data any) error {
+	return errors.New("a LayoutTemplate can only render a page by name")
+}
+
+// ExecuteTemplate renders a page, by its path relative to PagesDir, or a partial template
+func (t *LayoutTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	if page, ok := t.pages[name]; ok {
+		return page.set.ExecuteTemplate(wr, page.entry, data)
+	}
+	return t.partials.ExecuteTemplate(wr, name, data)
+}
+
+// ExecuteFragment renders a named block (for example "content") as defined for a page, including the overrides from its layouts
+func (t *LayoutTemplate) ExecuteFragment(wr io.Writer, pageName string, fragmentName string, data any) error {
+	page, ok := t.pages[pageName]
+	if !ok {
+		return fmt.Errorf("the page: %s does not exist", pageName)
+	}
+	return page.set.ExecuteTemplate(wr, fragmentName, data)
+}
+
+// ContentType returns the HTML content type
+func (t *LayoutTemplate) ContentType() string {
+	return htmlContentType
+}
+
+// Pages returns the sorted names of all the pages
+func (t *LayoutTemplate) Pages() []string {
+	names := make([]string, 0, len(t.pages))
+	for name := range t.pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cleanFSDir(dir string) string {
+	return strings.TrimPrefix(pathpkg.Clean("/"+strings.TrimPrefix(dir, "./")), "/")
+}
+
+// walkTemplateFiles calls fn for each file with the extension, passing the file name and its path relative to the dir
+func walkTemplateFiles(fsys fs.FS, dir string, extension string, fn func(name string, rel string) error) error {
+	if dir == "" {
+		dir = "."
+	}
+	return fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || pathpkg.Ext(name) != extension {
+			return nil
+		}
+		rel := name
+		if dir != "." {
+			rel = strings.TrimPrefix(name, dir+"/")
+		}
+		return fn(name, rel)
+	})
+}
+
+func parseTemplateFile(fsys fs.FS, set *html.Template, name string, templateName string) error {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	_, err = set.New(templateName).Parse(string(content))
+	return err
+}
+
+// parentDirs returns the dir and all its parents, from the outermost (".") to the dir itself
+func parentDirs(dir string) []string {
+	dirs := []string{dir}
+	for dir != "." {
+		dir = pathpkg.Dir(dir)
+		dirs = append(dirs, dir)
+	}
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return dirs
+}
@@ -0,0 +1,173 @@
+package response
+
+import (
+	"github.com/ixtendio/gofre/router/path"
+	html "html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var layoutTestFS = fstest.MapFS{
+	"web/partials/nav.html":            &fstest.MapFile{Data: []byte(`<nav>{{upper .}}</nav>`)},
+	"web/partials/components/btn.html": &fstest.MapFile{Data: []byte(`{{define "button"}}<button>{{.}}</button>{{end}}`)},
+	"web/pages/_layout.html":           &fstest.MapFile{Data: []byte(`<title>{{block "title" .}}Site{{end}}</title>{{template "nav.html" .}}<main>{{block "content" .}}{{end}}</main>`)},
+	"web/pages/index.html":             &fstest.MapFile{Data: []byte(`{{define "content"}}home {{.}}{{end}}`)},
+	"web/pages/about.html":             &fstest.MapFile{Data: []byte(`{{define "title"}}About{{end}}{{define "content"}}about {{template "button" .}}{{end}}`)},
+	"web/pages/admin/_layout.html":     &fstest.MapFile{Data: []byte(`{{define "title"}}Admin{{end}}{{define "content"}}<aside></aside>{{block "admin" .}}{{end}}{{end}}`)},
+	"web/pages/admin/users.html":       &fstest.MapFile{Data: []byte(`{{define "admin"}}users {{.}}{{end}}`)},
+	"web/pages/admin/reports/q1.html":  &fstest.MapFile{Data: []byte(`{{define "title"}}Q1{{end}}{{define "admin"}}q1{{end}}`)},
+	"web/pages/notes.txt":              &fstest.MapFile{Data: []byte(`ignored`)},
+}
+
+func newLayoutTestTemplate(t *testing.T) *LayoutTemplate {
+	tmpl, err := NewLayoutTemplate(layoutTestFS, LayoutTemplateOptions{
+		PagesDir:     "./web/pages",
+		PartialsDirs: []string{"web/partials"},
+		Funcs:        html.FuncMap{"upper": strings.ToUpper},
+	})
+	if err != nil {
+		t.Fatalf("NewLayoutTemplate() unexpected error: %v", err)
+	}
+	return tmpl
+}
+
+func TestLayoutTemplate_ExecuteTemplate(t *testing.T) {
+	tmpl := newLayoutTestTemplate(t)
+	tests := []struct {
+		name     string
+		pageName string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "page with the base layout",
+			pageName: "index.html",
+			want:     `<title>Site</title><nav>GO</nav><main>home go</main>`,
+		},
+		{
+			name:     "page that overrides a layout block",
+			pageName: "about.html",
+			want:     `<title>About</title><nav>GO</nav><main>about <button>go</button></main>`,
+		},
+		{
+			name:     "page with nested layouts",
+			pageName: "admin/users.html",
+			want:     `<title>Admin</title><nav>GO</nav><main><aside></aside>users go</main>`,
+		},
+		{
+			name:     "page from a sub directory without its own layout",
+			pageName: "admin/reports/q1.html",
+			want:     `<title>Q1</title><nav>GO</nav><main><aside></aside>q1</main>`,
+		},
+		{
+			name:     "partial",
+			pageName: "button",
+			want:     `<button>go</button>`,
+		},
+		{
+			name:     "unknown template",
+			pageName: "missing.html",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			err := tmpl.ExecuteTemplate(&sb, tt.pageName, "go")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExecuteTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && sb.String() != tt.want {
+				t.Errorf("ExecuteTemplate() got: %q, want: %q", sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutTemplate_Clone(t *testing.T) {
+	tmpl := newLayoutTestTemplate(t)
+	clone, err := tmpl.Clone()
+	if err != nil {
+		t.Fatalf("Clone() unexpected error: %v", err)
+	}
+	clone.Funcs(html.FuncMap{"upper": strings.ToLower})
+	for _, tc := range []struct {
+		tmpl *LayoutTemplate
+		want string
+	}{
+		{tmpl: clone, want: `<title>Site</title><nav>go</nav><main>home Go</main>`},
+		{tmpl: tmpl, want: `<title>Site</title><nav>GO</nav><main>home Go</main>`},
+	} {
+		var sb strings.Builder
+		if err := tc.tmpl.ExecuteTemplate(&sb, "index.html", "Go"); err != nil || sb.String() != tc.want {
+			t.Errorf("ExecuteTemplate() got: %q, err: %v, want: %q", sb.String(), err, tc.want)
+		}
+	}
+	if _, err := tmpl.Clone(); err == nil {
+		t.Errorf("Clone() after execution expected an error")
+	}
+}
+
+func TestLayoutTemplate_Pages(t *testing.T) {
+	tmpl := newLayoutTestTemplate(t)
+	want := []string{"about.html", "admin/reports/q1.html", "admin/users.html", "index.html"}
+	if got := tmpl.Pages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pages() got: %v, want: %v", got, want)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, nil); err == nil {
+		t.Errorf("Execute() expected an error")
+	}
+}
+
+func TestNewLayoutTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		options LayoutTemplateOptions
+	}{
+		{
+			name:    "missing pages dir",
+			fsys:    fstest.MapFS{},
+			options: LayoutTemplateOptions{},
+		},
+		{
+			name:    "invalid page",
+			fsys:    fstest.MapFS{"pages/index.html": &fstest.MapFile{Data: []byte(`{{define "content"}}`)}},
+			options: LayoutTemplateOptions{},
+		},
+		{
+			name: "invalid partial",
+			fsys: fstest.MapFS{
+				"pages/index.html":  &fstest.MapFile{Data: []byte(`index`)},
+				"partials/nav.html": &fstest.MapFile{Data: []byte(`{{end}}`)},
+			},
+			options: LayoutTemplateOptions{PartialsDirs: []string{"partials"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLayoutTemplate(tt.fsys, tt.options); err == nil {
+				t.Errorf("NewLayoutTemplate() expected an error")
+			}
+		})
+	}
+}
+
+func TestTemplateHttpResponseOK_LayoutTemplate(t *testing.T) {
+	tmpl := newLayoutTestTemplate(t)
+	resp := TemplateHttpResponseOK(tmpl, "admin/users.html", "go")
+	recorder := httptest.NewRecorder()
+	if err := resp.Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/", nil)}); err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+	if got := recorder.Header().Get(HeaderContentType); got != htmlContentType {
+		t.Errorf("Write() Content-Type got: %q, want: %q", got, htmlContentType)
+	}
+	if want := `<title>Admin</title><nav>GO</nav><main><aside></aside>users go</main>`; recorder.Body.String() != want {
+		t.Errorf("Write() body got: %q, want: %q", recorder.Body.String(), want)
+	}
+}
@@ -0,0 +1,349 @@
+package response
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/ixtendio/gofre/router/path"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	HeaderAccept = "Accept"
+	HeaderVary   = "Vary"
+)
+
+const xmlContentType = "application/xml; charset=utf-8"
+
+// A Codec encodes a payload in a specific media type
+type Codec interface {
+	// ContentType returns the value of the Content-Type header (the media type can be followed by parameters, e.g. charset)
+	ContentType() string
+	// Encode writes the encoded payload to the writer
+	Encode(w io.Writer, payload any) error
+}
+
+// JsonCodec implements Codec and encodes the payload to JSON using encoding/json
+type JsonCodec struct {
+}
+
+func (c JsonCodec) ContentType() string {
+	return jsonContentType
+}
+
+func (c JsonCodec) Encode(w io.Writer, payload any) error {
+	if payload == nil {
+		_, err := w.Write(emptyJson)
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// XmlCodec implements Codec and encodes the payload to XML using encoding/xml
+type XmlCodec struct {
+}
+
+func (c XmlCodec) ContentType() string {
+	return xmlContentType
+}
+
+func (c XmlCodec) Encode(w io.Writer, payload any) error {
+	if payload == nil {
+		return nil
+	}
+	data, err := xml.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// CodecRegistry holds the codecs that can be used to encode a negotiated response
+// The registration order defines the server preference when the client accepts more media types with the same quality
+type CodecRegistry struct {
+	mu         sync.RWMutex
+	codecs     []Codec
+	mediaTypes []string
+}
+
+// NewCodecRegistry creates a new CodecRegistry with the provided codecs
+func NewCodecRegistry(codecs ...Codec) *CodecRegistry {
+	r := &CodecRegistry{}
+	for _, c := range codecs {
+		r.Register(c)
+	}
+	return r
+}
+
+// DefaultCodecRegistry is the CodecRegistry used by the negotiated responses when no registry is provided.
+// It contains a JSON and an XML codec
+var DefaultCodecRegistry = NewCodecRegistry(JsonCodec{}, XmlCodec{})
+
+// Register adds a codec to the registry. A codec with the same media type as an existing one will replace it
+func (r *CodecRegistry) Register(codec Codec) {
+	if codec == nil {
+		return
+	}
+	mediaType := mediaTypeWithoutParams(codec.ContentType())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, mt := range r.mediaTypes {
+		if mt == mediaType {
+			r.codecs[i] = codec
+			return
+		}
+	}
+	r.codecs = append(r.codecs, codec)
+	r.mediaTypes = append(r.mediaTypes, mediaType)
+}
+
+// MediaTypes returns the media types (without parameters) of the registered codecs in the registration order
+func (r *CodecRegistry) MediaTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.mediaTypes...)
+}
+
+// Negotiate returns the codec that best matches the Accept header value or nil if no codec is acceptable
+// If the Accept header is empty, the first registered codec is returned
+func (r *CodecRegistry) Negotiate(acceptHeaderValue string) Codec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	idx := negotiateIndex(acceptHeaderValue, r.mediaTypes)
+	if idx < 0 {
+		return nil
+	}
+	return r.codecs[idx]
+}
+
+// NegotiateContentType returns the offer that best matches the Accept header value, taking into account the quality values and the wildcards.
+// When more offers have the same quality, the first one wins. If the Accept header is empty, the first offer is returned.
+// An empty string is returned if no offer is acceptable
+func NegotiateContentType(acceptHeaderValue string, offers ...string) string {
+	idx := negotiateIndex(acceptHeaderValue, offers)
+	if idx < 0 {
+		return ""
+	}
+	return offers[idx]
+}
+
+type acceptedMediaRange struct {
+	mainType string
+	subType  string
+	quality  float64
+}
+
+// specificity returns how specific the media range is for the offer or -1 if the media range doesn't match the offer
+func (m acceptedMediaRange) specificity(mainType string, subType string) int {
+	if m.mainType == "*" {
+		return 0
+	}
+	if m.mainType != mainType {
+		return -1
+	}
+	if m.subType == "*" {
+		return 1
+	}
+	if m.subType != subType {
+		return -1
+	}
+	return 2
+}
+
+func negotiateIndex(acceptHeaderValue string, offers []string) int {
+	if len(offers) == 0 {
+		return -1
+	}
+	if len(strings.TrimSpace(acceptHeaderValue)) == 0 {
+		return 0
+	}
+	mediaRanges := parseAcceptHeader(acceptHeaderValue)
+	bestIdx := -1
+	bestQuality := 0.0
+	for i, offer := range offers {
+		mainType, subType := splitMediaType(mediaTypeWithoutParams(offer))
+		quality := 0.0
+		specificity := -1
+		for _, mr := range mediaRanges {
+			if s := mr.specificity(mainType, subType); s > specificity {
+				specificity = s
+				quality = mr.quality
+			}
+		}
+		if quality > bestQuality {
+			bestQuality = quality
+			bestIdx = i
+		}
+	}
+	return bestIdx
+}
+
+func parseAcceptHeader(acceptHeaderValue string) []acceptedMediaRange {
+	var mediaRanges []acceptedMediaRange
+	for _, part := range strings.Split(acceptHeaderValue, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if len(mediaType) == 0 {
+			continue
+		}
+		if mediaType == "*" {
+			mediaType = "*/*"
+		}
+		mainType, subType := splitMediaType(mediaType)
+		if len(mainType) == 0 || len(subType) == 0 {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.EqualFold(strings.TrimSpace(name), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q >= 0 && q <= 1 {
+					quality = q
+				}
+				break
+			}
+		}
+		mediaRanges = append(mediaRanges, acceptedMediaRange{
+			mainType: mainType,
+			subType:  subType,
+			quality:  quality,
+		})
+	}
+	return mediaRanges
+}
+
+func mediaTypeWithoutParams(contentType string) string {
+	if idx := strings.IndexByte(contentType, ';'); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+func splitMediaType(mediaType string) (string, string) {
+	mainType, subType, _ := strings.Cut(mediaType, "/")
+	return mainType, subType
+}
+
+// addVaryHeader adds the value to the Vary header if it is not already present
+func addVaryHeader(header http.Header, value string) {
+	for _, v := range header.Values(HeaderVary) {
+		if varyContains(v, value) {
+			return
+		}
+	}
+	header.Add(HeaderVary, value)
+}
+
+// AppendVary appends the value to a Vary header value if it is not already present (case-insensitive)
+func AppendVary(vary string, value string) string {
+	if varyContains(vary, value) {
+		return vary
+	}
+	if len(vary) == 0 {
+		return value
+	}
+	return vary + ", " + value
+}
+
+// varyContains returns true if the Vary header value contains the field or "*"
+func varyContains(vary string, value string) bool {
+	for _, field := range strings.Split(vary, ",") {
+		field = strings.TrimSpace(field)
+		if field == "*" || strings.EqualFold(field, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// HttpNegotiatedResponse implements response.HttpResponse and encodes the payload using the codec that best matches the request Accept header
+// If no codec is acceptable, the http.StatusNotAcceptable status code is written
+type HttpNegotiatedResponse struct {
+	HttpHeadersResponse
+	Payload any
+	// the registry used to choose the codec. Default: DefaultCodecRegistry
+	Codecs *CodecRegistry
+}
+
+func (r *HttpNegotiatedResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
+	codecs := r.Codecs
+	if codecs == nil {
+		codecs = DefaultCodecRegistry
+	}
+	if vary, ok := r.HttpHeaders[HeaderVary]; ok {
+		delete(r.HttpHeaders, HeaderVary)
+		w.Header().Set(HeaderVary, vary)
+	}
+	addVaryHeader(w.Header(), HeaderAccept)
+
+	var acceptHeaderValue string
+	if mc.R != nil {
+		acceptHeaderValue = mc.R.Header.Get(HeaderAccept)
+	}
+	codec := codecs.Negotiate(acceptHeaderValue)
+	if codec == nil {
+		r.HttpStatusCode = http.StatusNotAcceptable
+		r.ContentType = plainTextContentType
+		if err := r.HttpHeadersResponse.Write(w, mc); err != nil {
+			return err
+		}
+		return writeTextResponse(w, "Not Acceptable, supported media types: "+strings.Join(codecs.MediaTypes(), ", "))
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := codec.Encode(buf, r.Payload); err != nil {
+		writeRenderFailure(w, &r.HttpHeadersResponse, err, plainTextContentType, "500 Internal Server Error")
+		return fmt.Errorf("failed to encode the response as %s, err: %w", codec.ContentType(), err)
+	}
+
+	r.ContentType = codec.ContentType()
+	delete(r.HttpHeaders, HeaderContentType)
+	if err := r.writeBuffered(w, mc, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write the %s response, err: %w", codec.ContentType(), err)
+	}
+	return nil
+}
+
+// NegotiatedHttpResponseOK creates a 200 success response encoded with a codec from the DefaultCodecRegistry
+func NegotiatedHttpResponseOK(payload any) *HttpNegotiatedResponse {
+	return NegotiatedHttpResponseWithHeadersAndCookies(http.StatusOK, payload, nil, nil, nil)
+}
+
+// NegotiatedHttpResponse creates a response with a specific status code encoded with a codec from the DefaultCodecRegistry
+func NegotiatedHttpResponse(statusCode int, payload any) *HttpNegotiatedResponse {
+	return NegotiatedHttpResponseWithHeadersAndCookies(statusCode, payload, nil, nil, nil)
+}
+
+// NegotiatedHttpResponseWithCodecs creates a response with a specific status code encoded with a codec from a custom CodecRegistry
+func NegotiatedHttpResponseWithCodecs(statusCode int, payload any, codecs *CodecRegistry) *HttpNegotiatedResponse {
+	return NegotiatedHttpResponseWithHeadersAndCookies(statusCode, payload, codecs, nil, nil)
+}
+
+// NegotiatedHttpResponseWithHeadersAndCookies creates a negotiated response with a specific status code, codecs, custom headers and cookies
+// If codecs is nil, the DefaultCodecRegistry is used
+// The headers and cookies, if present, once will be written to output will be added in the pool for re-use
+func NegotiatedHttpResponseWithHeadersAndCookies(statusCode int, payload any, codecs *CodecRegistry, headers HttpHeaders, cookies HttpCookies) *HttpNegotiatedResponse {
+	return &HttpNegotiatedResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: statusCode,
+			HttpHeaders:    headers,
+			HttpCookies:    cookies,
+		},
+		Payload: payload,
+		Codecs:  codecs,
+	}
+}
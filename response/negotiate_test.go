@@ -0,0 +1,243 @@
+package response
+
+import (
+	"github.com/ixtendio/gofre/router/path"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type csvCodec struct {
+}
+
+func (c csvCodec) ContentType() string {
+	return "text/csv"
+}
+
+func (c csvCodec) Encode(w io.Writer, payload any) error {
+	_, err := io.WriteString(w, "csv")
+	return err
+}
+
+type xmlPayload struct {
+	Name string `xml:"name"`
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	type args struct {
+		accept string
+		offers []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "empty accept header returns the first offer",
+			args: args{accept: "", offers: []string{"application/json", "application/xml"}},
+			want: "application/json",
+		},
+		{
+			name: "no offers",
+			args: args{accept: "application/json"},
+			want: "",
+		},
+		{
+			name: "exact match",
+			args: args{accept: "application/xml", offers: []string{"application/json", "application/xml"}},
+			want: "application/xml",
+		},
+		{
+			name: "exact match with content type params",
+			args: args{accept: "application/xml", offers: []string{"application/json", "application/xml; charset=utf-8"}},
+			want: "application/xml; charset=utf-8",
+		},
+		{
+			name: "quality values",
+			args: args{accept: "application/json;q=0.5, application/xml;q=0.9", offers: []string{"application/json", "application/xml"}},
+			want: "application/xml",
+		},
+		{
+			name: "same quality, server preference wins",
+			args: args{accept: "application/xml, application/json", offers: []string{"application/json", "application/xml"}},
+			want: "application/json",
+		},
+		{
+			name: "full wildcard",
+			args: args{accept: "*/*", offers: []string{"application/xml", "application/json"}},
+			want: "application/xml",
+		},
+		{
+			name: "subtype wildcard",
+			args: args{accept: "text/*", offers: []string{"application/json", "text/csv"}},
+			want: "text/csv",
+		},
+		{
+			name: "the most specific range decides the quality",
+			args: args{accept: "application/*;q=0.8, application/json;q=0, */*;q=0.1", offers: []string{"application/json", "application/xml"}},
+			want: "application/xml",
+		},
+		{
+			name: "zero quality excludes the offer",
+			args: args{accept: "application/json;q=0", offers: []string{"application/json"}},
+			want: "",
+		},
+		{
+			name: "nothing matches",
+			args: args{accept: "image/png", offers: []string{"application/json", "application/xml"}},
+			want: "",
+		},
+		{
+			name: "case insensitive and malformed entries are ignored",
+			args: args{accept: "bad, ,Application/XML;Q=0.7", offers: []string{"application/json", "application/xml"}},
+			want: "application/xml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NegotiateContentType(tt.args.accept, tt.args.offers...); got != tt.want {
+				t.Errorf("NegotiateContentType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodecRegistry_Register(t *testing.T) {
+	registry := NewCodecRegistry(JsonCodec{}, nil, XmlCodec{})
+	registry.Register(csvCodec{})
+	registry.Register(JsonCodec{})
+	want := []string{"application/json", "application/xml", "text/csv"}
+	if got := registry.MediaTypes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CodecRegistry.MediaTypes() = %v, want %v", got, want)
+	}
+	if got := registry.Negotiate("text/csv"); got != (csvCodec{}) {
+		t.Errorf("CodecRegistry.Negotiate() = %v, want csvCodec", got)
+	}
+}
+
+func TestHttpNegotiatedResponse_Write(t *testing.T) {
+	type args struct {
+		accept      string
+		codecs      *CodecRegistry
+		httpHeaders HttpHeaders
+		payload     any
+	}
+	type want struct {
+		httpCode    int
+		httpHeaders http.Header
+		body        string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "without accept header the JSON codec is used",
+			args: args{payload: map[string]string{"name": "john"}},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"application/json"}, "X-Content-Type-Options": {"nosniff"}, "Vary": {"Accept"}, "Content-Length": {"15"}},
+				body:        `{"name":"john"}`,
+			},
+		},
+		{
+			name: "XML codec",
+			args: args{accept: "application/xml", payload: xmlPayload{Name: "john"}},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"application/xml; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}, "Vary": {"Accept"}, "Content-Length": {"81"}},
+				body:        "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<xmlPayload><name>john</name></xmlPayload>",
+			},
+		},
+		{
+			name: "custom codec and existing Vary header",
+			args: args{accept: "text/*", codecs: NewCodecRegistry(JsonCodec{}, csvCodec{}), httpHeaders: HttpHeaders{"Vary": "Origin"}},
+			want: want{
+				httpCode:    http.StatusOK,
+				httpHeaders: http.Header{"Content-Type": {"text/csv"}, "X-Content-Type-Options": {"nosniff"}, "Vary": {"Origin", "Accept"}, "Content-Length": {"3"}},
+				body:        "csv",
+			},
+		},
+		{
+			name: "not acceptable",
+			args: args{accept: "image/png", payload: "hello"},
+			want: want{
+				httpCode:    http.StatusNotAcceptable,
+				httpHeaders: http.Header{"Content-Type": {"text/plain; charset=utf-8"}, "X-Content-Type-Options": {"nosniff"}, "Vary": {"Accept"}},
+				body:        "Not Acceptable, supported media types: application/json, application/xml",
+			},
+		},
+		{
+			name:    "encoding error",
+			args:    args{accept: "application/xml", payload: map[string]string{"name": "john"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.args.accept != "" {
+				req.Header.Set("Accept", tt.args.accept)
+			}
+			resp := NegotiatedHttpResponseWithHeadersAndCookies(http.StatusOK, tt.args.payload, tt.args.codecs, tt.args.httpHeaders, nil)
+			responseRecorder := httptest.NewRecorder()
+			err := resp.Write(responseRecorder, path.MatchingContext{R: req})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("HttpNegotiatedResponse.Write() want error but got nil")
+				}
+				if responseRecorder.Code != http.StatusInternalServerError {
+					t.Errorf("HttpNegotiatedResponse.Write() status code = %v, want 500", responseRecorder.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HttpNegotiatedResponse.Write() unexpected error: %v", err)
+			}
+			got := want{
+				httpCode:    responseRecorder.Code,
+				httpHeaders: responseRecorder.Header(),
+				body:        responseRecorder.Body.String(),
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HttpNegotiatedResponse.Write() got:  %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpNegotiatedResponse_WriteEncodingErrorReleases(t *testing.T) {
+	headers := NewHttpHeaders()
+	headers["X-Custom"] = "value"
+	cookies := NewHttpCookie(&http.Cookie{Name: "session", Value: "1"})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp := NegotiatedHttpResponseWithHeadersAndCookies(http.StatusOK, map[string]string{"name": "john"}, nil, headers, cookies)
+	responseRecorder := httptest.NewRecorder()
+	if err := resp.Write(responseRecorder, path.MatchingContext{R: req}); err == nil {
+		t.Fatalf("HttpNegotiatedResponse.Write() want error but got nil")
+	}
+	if len(headers) > 0 || len(cookies) > 0 {
+		t.Errorf("HttpNegotiatedResponse.Write() the headers: %v and the cookies: %v were not released", headers, cookies)
+	}
+	if got := responseRecorder.Header().Get("X-Custom"); got != "" {
+		t.Errorf("HttpNegotiatedResponse.Write() the X-Custom header of the failed response was written: %q", got)
+	}
+}
+
+func TestNegotiatedHttpResponseOK(t *testing.T) {
+	want := &HttpNegotiatedResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: http.StatusOK,
+		},
+		Payload: "hello",
+	}
+	if got := NegotiatedHttpResponseOK("hello"); !reflect.DeepEqual(got, want) {
+		t.Errorf("NegotiatedHttpResponseOK() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const problemJsonContentType = "application/problem+json"
+
+// DefaultProblemType is the problem type used when the problem has no other semantics than the HTTP status code
+const DefaultProblemType = "about:blank"
+
+// ProblemDetails is an RFC 9457 problem details object. The Extensions members are serialized next to the standard members,
+// but they can not replace them
+type ProblemDetails struct {
+	// a URI reference that identifies the problem type. Default: "about:blank"
+	Type string
+	// a short, human-readable summary of the problem type. Default: the status text, if the Type is "about:blank"
+	Title string
+	// the HTTP status code
+	Status int
+	// a human-readable explanation specific to this occurrence of the problem
+	Detail string
+	// a URI reference that identifies the specific occurrence of the problem, for example the request path
+	Instance string
+	// the extension members, for example "errors", "traceId" or "retryAfter"
+	Extensions map[string]any
+}
+
+// NewProblemDetails creates an "about:blank" problem, having the status text as title
+func NewProblemDetails(statusCode int, detail string) ProblemDetails {
+	return ProblemDetails{
+		Type:   DefaultProblemType,
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	}
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+	problemType := p.Type
+	if problemType == "" {
+		problemType = DefaultProblemType
+	}
+	members["type"] = problemType
+	title := p.Title
+	if title == "" && problemType == DefaultProblemType {
+		title = http.StatusText(p.Status)
+	}
+	setOrDelete(members, "title", title)
+	if p.Status > 0 {
+		members["status"] = p.Status
+	} else {
+		delete(members, "status")
+	}
+	setOrDelete(members, "detail", p.Detail)
+	setOrDelete(members, "instance", p.Instance)
+	return json.Marshal(members)
+}
+
+func setOrDelete(members map[string]any, name string, value string) {
+	if len(value) > 0 {
+		members[name] = value
+	} else {
+		delete(members, name)
+	}
+}
+
+// ProblemHttpResponse creates an application/problem+json response. The status code is the problem Status or, if missing, http.StatusInternalServerError.
+// If the problem has a numeric "retryAfter" extension (the number of seconds), the Retry-After header is set too
+func ProblemHttpResponse(problem ProblemDetails) *HttpJsonResponse {
+	return ProblemHttpResponseWithHeadersAndCookies(problem, nil, nil)
+}
+
+// ProblemHttpResponseWithHeaders creates an application/problem+json response with custom headers
+// The headers, if present, once will be written to output will be added in the pool for re-use
+func ProblemHttpResponseWithHeaders(problem ProblemDetails, headers HttpHeaders) *HttpJsonResponse {
+	return ProblemHttpResponseWithHeadersAndCookies(problem, headers, nil)
+}
+
+// ProblemHttpResponseWithHeadersAndCookies creates an application/problem+json response with custom headers and cookies
+// The headers and cookies, if present, once will be written to output will be added in the pool for re-use
+func ProblemHttpResponseWithHeadersAndCookies(problem ProblemDetails, headers HttpHeaders, cookies HttpCookies) *HttpJsonResponse {
+	statusCode := problem.Status
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+	resp := &HttpJsonResponse{
+		HttpHeadersResponse: HttpHeadersResponse{
+			HttpStatusCode: statusCode,
+			ContentType:    problemJsonContentType,
+			HttpHeaders:    headers,
+			HttpCookies:    cookies,
+		},
+		Payload: problem,
+	}
+	if retryAfter, ok := retryAfterSeconds(problem.Extensions["retryAfter"]); ok {
+		resp.Headers().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	return resp
+}
+
+func retryAfterSeconds(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, v >= 0
+	case int64:
+		return int(v), v >= 0
+	case float64:
+		return int(v), v >= 0
+	}
+	return 0, false
+}
@@ -0,0 +1,85 @@
+package response
+
+import (
+	"github.com/ixtendio/gofre/router/path"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestProblemHttpResponse(t *testing.T) {
+	type want struct {
+		httpCode    int
+		httpHeaders http.Header
+		body        string
+	}
+	tests := []struct {
+		name    string
+		problem ProblemDetails
+		want    want
+	}{
+		{
+			name:    "about:blank problem",
+			problem: NewProblemDetails(http.StatusNotFound, "the user 10 does not exist"),
+			want: want{
+				httpCode:    http.StatusNotFound,
+				httpHeaders: http.Header{"Content-Type": {problemJsonContentType}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"93"}},
+				body:        `{"detail":"the user 10 does not exist","status":404,"title":"Not Found","type":"about:blank"}`,
+			},
+		},
+		{
+			name: "problem with extensions",
+			problem: ProblemDetails{
+				Type:     "https://example.com/problems/validation",
+				Title:    "Validation failed",
+				Status:   http.StatusUnprocessableEntity,
+				Instance: "/users",
+				Extensions: map[string]any{
+					"errors": []map[string]string{{"pointer": "#/email", "detail": "invalid"}},
+					"status": 200,
+					"title":  "ignored",
+				},
+			},
+			want: want{
+				httpCode:    http.StatusUnprocessableEntity,
+				httpHeaders: http.Header{"Content-Type": {problemJsonContentType}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"163"}},
+				body:        `{"errors":[{"detail":"invalid","pointer":"#/email"}],"instance":"/users","status":422,"title":"Validation failed","type":"https://example.com/problems/validation"}`,
+			},
+		},
+		{
+			name:    "retry after",
+			problem: ProblemDetails{Status: http.StatusTooManyRequests, Extensions: map[string]any{"retryAfter": 30}},
+			want: want{
+				httpCode:    http.StatusTooManyRequests,
+				httpHeaders: http.Header{"Content-Type": {problemJsonContentType}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"79"}, "Retry-After": {"30"}},
+				body:        `{"retryAfter":30,"status":429,"title":"Too Many Requests","type":"about:blank"}`,
+			},
+		},
+		{
+			name:    "without status",
+			problem: ProblemDetails{Type: "https://example.com/problems/unknown"},
+			want: want{
+				httpCode:    http.StatusInternalServerError,
+				httpHeaders: http.Header{"Content-Type": {problemJsonContentType}, "X-Content-Type-Options": {"nosniff"}, "Content-Length": {"47"}},
+				body:        `{"type":"https://example.com/problems/unknown"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			if err := ProblemHttpResponse(tt.problem).Write(recorder, path.MatchingContext{R: httptest.NewRequest(http.MethodGet, "/", nil)}); err != nil {
+				t.Fatalf("Write() unexpected error: %v", err)
+			}
+			got := want{
+				httpCode:    recorder.Code,
+				httpHeaders: recorder.Header(),
+				body:        recorder.Body.String(),
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Write() got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
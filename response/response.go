@@ -13,6 +13,7 @@ import (
 const (
 	HeaderContentType        = "Content-Type"
 	HeaderContentTypeOptions = "X-Content-Type-Options"
+	HeaderRequestId          = "X-Request-Id"
 )
 
 var httpHeadersPool = sync.Pool{
@@ -65,10 +65,13 @@ type EventGenerator func(ctx context.Context, lastEventId string) <-chan ServerS
 type HttpSSEResponse struct {
 	HttpHeadersResponse
 	EventGenerator EventGenerator
+	// if true, the HTTP/1.x requests are accepted too. The browsers limit the number of HTTP/1.x connections per domain to 6,
+	// so this should be enabled only when a single stream per page is opened, for example in development mode
+	AllowHTTP1 bool
 }
 
 func (r *HttpSSEResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
-	if mc.R.ProtoMajor != 2 {
+	if mc.R.ProtoMajor != 2 && !r.AllowHTTP1 {
 		w.WriteHeader(http.StatusInternalServerError)
 		return ErrNotHttp2Request
 	}
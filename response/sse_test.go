@@ -230,6 +230,7 @@ func TestHttpSSEResponse_Write(t *testing.T) {
 		httpCookies                            HttpCookies
 		eventGeneratorStartIndex               int
 		eventGeneratorCallRequestContextCancel bool
+		allowHTTP1                             bool
 	}
 	type want struct {
 		httpCode    int
@@ -247,6 +248,19 @@ func TestHttpSSEResponse_Write(t *testing.T) {
 			args:    args{request: &http.Request{ProtoMajor: 1}},
 			wantErr: true,
 		},
+		{
+			name: "http1 allowed",
+			args: args{
+				request:        &http.Request{ProtoMajor: 1},
+				httpStatusCode: 200,
+				allowHTTP1:     true,
+			},
+			want: want{
+				httpCode:    200,
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Cache-Control": {"no-cache"}, "Connection": {"keep-alive"}, "Content-Type": {eventStreamContentType}},
+				body:        "event: message\ndata: msg1\nid: 1\n\nevent: message\ndata: msg2\nid: 2\n\nevent: message\ndata: msg3\nid: 3\n\n",
+			},
+		},
 		{
 			name: "request cancels",
 			args: args{
@@ -306,6 +320,7 @@ func TestHttpSSEResponse_Write(t *testing.T) {
 					HttpCookies:    tt.args.httpCookies,
 				},
 				EventGenerator: eventGenerator,
+				AllowHTTP1:     tt.args.allowHTTP1,
 			}
 			responseRecorder := httptest.NewRecorder()
 			err := resp.Write(responseRecorder, path.MatchingContext{R: tt.args.request.WithContext(ctx)})
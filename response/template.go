@@ -1,6 +1,7 @@
 package response
 
 import (
+	"context"
 	"fmt"
 	"github.com/ixtendio/gofre/router/path"
 
@@ -17,6 +18,18 @@ type ExecutableTemplate interface {
 	ExecuteTemplate(wr io.Writer, name string, data any) error
 }
 
+// ContentTypeTemplate can be implemented by an ExecutableTemplate to specify the content type of the rendered output
+type ContentTypeTemplate interface {
+	ContentType() string
+}
+
+// ContextTemplate can be implemented by an ExecutableTemplate that binds the request scoped template functions (for example
+// the CSRF token or the current user), returning the template that should render the response and a function that must be
+// called when the rendering is done, after which the returned template can be reused for another request
+type ContextTemplate interface {
+	WithContext(ctx context.Context, mc path.MatchingContext) (template ExecutableTemplate, release func(), err error)
+}
+
 // NilTemplate implements ExecutableTemplate and can be used when you use static resources without templating
 type NilTemplate struct {
 }
@@ -28,23 +41,76 @@ func (t NilTemplate) ExecuteTemplate(wr io.Writer, name string, data any) error
 	return nil
 }
 
-// HttpTemplateResponse implements response.HttpResponse and renders a template as a response
+// HttpTemplateResponse implements response.HttpResponse and renders a template as a response.
+// By default, the template is rendered in a pooled buffer before writing the headers, so that a rendering failure
+// results in a clean http.StatusInternalServerError response, and the Content-Length header is set
 type HttpTemplateResponse struct {
 	HttpHeadersResponse
 	Template ExecutableTemplate
 	Name     string
 	Data     any
+	// if true, the template is rendered directly to the client, after the headers were written. Useful for the large pages
+	Unbuffered bool
+	// if not empty, only these named blocks (fragments) of the Name page are rendered, one after another, instead of the full page
+	Fragments []string
+	// the request context.Context passed to a ContextTemplate. If nil, context.Background() is used
+	Context context.Context
+}
+
+// WithContext sets the request context.Context, used by a ContextTemplate to bind the request scoped template functions
+func (r *HttpTemplateResponse) WithContext(ctx context.Context) *HttpTemplateResponse {
+	r.Context = ctx
+	return r
+}
+
+// render executes the page or its fragments
+func (r *HttpTemplateResponse) render(wr io.Writer, mc path.MatchingContext) error {
+	template := r.Template
+	if ct, ok := template.(ContextTemplate); ok {
+		ctx := r.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		bound, release, err := ct.WithContext(ctx, mc)
+		if err != nil {
+			return err
+		}
+		defer release()
+		template = bound
+	}
+	if len(r.Fragments) == 0 {
+		return template.ExecuteTemplate(wr, r.Name, r.Data)
+	}
+	for _, fragment := range r.Fragments {
+		if err := executeFragment(template, wr, r.Name, fragment, r.Data); err != nil {
+			return fmt.Errorf("fragment: %s, err: %w", fragment, err)
+		}
+	}
+	return nil
 }
 
 func (r *HttpTemplateResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
-	// write the headers
-	if err := r.HttpHeadersResponse.Write(w, mc); err != nil {
-		return err
+	if r.Unbuffered {
+		// write the headers
+		if err := r.HttpHeadersResponse.Write(w, mc); err != nil {
+			return err
+		}
+
+		if err := r.render(w, mc); err != nil {
+			return fmt.Errorf("failed rendering the template: %s, err: %w", r.Name, err)
+		}
+		return nil
 	}
 
-	if err := r.Template.ExecuteTemplate(w, r.Name, r.Data); err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := r.render(buf, mc); err != nil {
+		writeRenderFailure(w, &r.HttpHeadersResponse, err, plainTextContentType, "500 Internal Server Error")
 		return fmt.Errorf("failed rendering the template: %s, err: %w", r.Name, err)
 	}
+	if err := r.writeBuffered(w, mc, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed writing the template: %s, err: %w", r.Name, err)
+	}
 	return nil
 }
 
@@ -77,9 +143,12 @@ func TemplateHttpResponseWithHeadersAndCookies(template ExecutableTemplate, stat
 	if headers != nil && len(headers[HeaderContentType]) > 0 {
 		contentType = headers[HeaderContentType]
 	} else {
-		if _, ok := template.(*html.Template); ok {
+		switch t := template.(type) {
+		case *html.Template:
 			contentType = htmlContentType
-		} else {
+		case ContentTypeTemplate:
+			contentType = t.ContentType()
+		default:
 			contentType = plainTextContentType
 		}
 	}
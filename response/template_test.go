@@ -62,7 +62,7 @@ func TestHttpTemplateResponse_Write(t *testing.T) {
 			},
 			want: want{
 				httpCode:    210,
-				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Set-Cookie": {"cookie3=val3"}, "Header1": {"val1"}},
+				httpHeaders: http.Header{"X-Content-Type-Options": {"nosniff"}, "Content-Length": {"51"}, "Set-Cookie": {"cookie3=val3"}, "Header1": {"val1"}},
 				body:        []byte(fmt.Sprintf("template: %s, data: %v", "not_found", map[string]string{"key1": "val1", "key2": "val2"})),
 			},
 			wantErr: false,
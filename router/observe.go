@@ -0,0 +1,237 @@
+package router
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var timeNow = time.Now
+
+// WriteStats contains the values recorded while a request is served, including the response write phase
+type WriteStats struct {
+	// the status code written to the client, http.StatusSwitchingProtocols for the hijacked connections or 0 if
+	// the connection was aborted before the status code was written
+	StatusCode int
+	// the Content-Type of the response, when the status code was written
+	ContentType string
+	// the number of the response body bytes written to the client
+	BytesWritten int64
+	// the time when the request was received
+	Start time.Time
+	// the time to the last byte, including the response write phase
+	Duration time.Duration
+	// the matched path pattern or an empty string if no pattern matched the request
+	Route string
+	// true if the connection was hijacked, for example by a WebSocket response
+	Hijacked bool
+	// true if a panic was recovered while the request was served
+	Panicked bool
+	// the request id set by the handler middlewares (see SetRequestId) or an empty string
+	RequestId string
+}
+
+// A RequestObserver is notified when a request is received, when its response status code is written (or the connection
+// is hijacked) and when the request was served. It is notified for all the requests, including the not found ones and the ones that panicked
+type RequestObserver interface {
+	// RequestReceived is called before the request is matched
+	RequestReceived(req *http.Request)
+	// ResponseStarted is called when the status code is written, before the response body
+	ResponseStarted(req *http.Request, statusCode int, header http.Header)
+	// RequestServed is called when the response is completely written to the client
+	RequestServed(req *http.Request, stats WriteStats)
+}
+
+// A WriteObserver is called after a request was served, when the response is completely written to the client.
+// It is called for all the requests, including the not found ones and the ones that panicked
+type WriteObserver func(req *http.Request, stats WriteStats)
+
+func (o WriteObserver) RequestReceived(req *http.Request) {}
+
+func (o WriteObserver) ResponseStarted(req *http.Request, statusCode int, header http.Header) {}
+
+func (o WriteObserver) RequestServed(req *http.Request, stats WriteStats) {
+	o(req, stats)
+}
+
+// ObserveWrites adds the observers that are called after each request is served (see WriteObserver)
+func (r *Router) ObserveWrites(observers ...WriteObserver) *Router {
+	for _, observer := range observers {
+		r.observers = append(r.observers, observer)
+	}
+	return r
+}
+
+// ObserveRequests adds the observers that are notified about the lifecycle of each request (see RequestObserver)
+func (r *Router) ObserveRequests(observers ...RequestObserver) *Router {
+	r.observers = append(r.observers, observers...)
+	return r
+}
+
+// observe notifies the observers that the request was served
+func (r *Router) observe(w *recordingResponseWriter, req *http.Request) {
+	if len(r.observers) == 0 {
+		return
+	}
+	stats := WriteStats{
+		StatusCode:   w.statusCode,
+		ContentType:  w.contentType,
+		BytesWritten: w.bytesWritten,
+		Start:        w.start,
+		Duration:     timeNow().Sub(w.start),
+		Route:        w.route,
+		Hijacked:     w.hijacked,
+		Panicked:     w.panicked,
+		RequestId:    w.requestId,
+	}
+	if stats.StatusCode == 0 && !stats.Panicked {
+		// the http.Server writes the http.StatusOK status code when the handler returns without writing it
+		stats.StatusCode = http.StatusOK
+	}
+	for _, observer := range r.observers {
+		observer.RequestServed(req, stats)
+	}
+}
+
+var recordingResponseWriterPool = sync.Pool{
+	New: func() interface{} {
+		return &recordingResponseWriter{}
+	},
+}
+
+// recordingResponseWriter records the status code, the number of bytes written and if the connection was hijacked.
+// It is also used to know if a response can still be sent after a panic
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	req             *http.Request
+	observers       []RequestObserver
+	start           time.Time
+	route           string
+	statusCode      int
+	contentType     string
+	bytesWritten    int64
+	hijacked        bool
+	panicked        bool
+	requestId       string
+	requestIdHeader string
+}
+
+func (w *recordingResponseWriter) reset() {
+	*w = recordingResponseWriter{}
+}
+
+// headerWritten returns true if a part of the response was sent to the client
+func (w *recordingResponseWriter) headerWritten() bool {
+	return w.statusCode != 0
+}
+
+// started records the status code of the response and notifies the observers
+func (w *recordingResponseWriter) started(statusCode int) {
+	w.statusCode = statusCode
+	header := w.ResponseWriter.Header()
+	w.contentType = header.Get("Content-Type")
+	for _, observer := range w.observers {
+		observer.ResponseStarted(w.req, statusCode, header)
+	}
+}
+
+func (w *recordingResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 && (statusCode >= http.StatusOK || statusCode == http.StatusSwitchingProtocols) {
+		w.started(statusCode)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingResponseWriter) Write(bytes []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.started(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(bytes)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+// ReadFrom is used by io.Copy, for example by the http.ServeContent, and delegates to the io.ReaderFrom of the wrapped
+// http.ResponseWriter, if it implements it, so that the files can still be sent with sendfile
+func (w *recordingResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if w.statusCode == 0 {
+		w.started(http.StatusOK)
+	}
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		// hides the ReadFrom method, otherwise io.Copy calls it again
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, src)
+	}
+	w.bytesWritten += n
+	return n, err
+}
+
+func (w *recordingResponseWriter) flush() {
+	if w.statusCode == 0 {
+		w.started(http.StatusOK)
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *recordingResponseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	if w.statusCode == 0 {
+		w.started(http.StatusSwitchingProtocols)
+	}
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter, used by the http.ResponseController
+func (w *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// responseWriter returns the http.ResponseWriter passed to the handlers, which implements http.Flusher and http.Hijacker
+// only if the wrapped http.ResponseWriter implements them, so that the responses can detect these features with type assertions
+func (w *recordingResponseWriter) responseWriter() http.ResponseWriter {
+	_, flusher := w.ResponseWriter.(http.Flusher)
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+	switch {
+	case flusher && hijacker:
+		return recordingFlusherHijacker{w}
+	case flusher:
+		return recordingFlusher{w}
+	case hijacker:
+		return recordingHijacker{w}
+	}
+	return w
+}
+
+type recordingFlusher struct {
+	*recordingResponseWriter
+}
+
+func (w recordingFlusher) Flush() {
+	w.flush()
+}
+
+type recordingHijacker struct {
+	*recordingResponseWriter
+}
+
+func (w recordingHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+type recordingFlusherHijacker struct {
+	*recordingResponseWriter
+}
+
+func (w recordingFlusherHijacker) Flush() {
+	w.flush()
+}
+
+func (w recordingFlusherHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
@@ -0,0 +1,220 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouter_ObserveWrites(t *testing.T) {
+	start := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	calls := 0
+	timeNow = func() time.Time {
+		calls++
+		return start.Add(time.Duration(calls-1) * 15 * time.Millisecond)
+	}
+	defer func() {
+		timeNow = time.Now
+	}()
+	tests := []struct {
+		name      string
+		path      string
+		wantPanic bool
+		want      WriteStats
+	}{
+		{
+			name: "buffered response",
+			path: "/users/10",
+			want: WriteStats{StatusCode: http.StatusCreated, ContentType: "text/plain; charset=utf-8", BytesWritten: 7, Route: "/users/{id}"},
+		},
+		{
+			name: "streamed response",
+			path: "/stream",
+			want: WriteStats{StatusCode: http.StatusOK, ContentType: "text/plain", BytesWritten: 10, Route: "/stream"},
+		},
+		{
+			name: "copied response",
+			path: "/copy",
+			want: WriteStats{StatusCode: http.StatusOK, ContentType: "text/plain; charset=utf-8", BytesWritten: 6, Route: "/copy"},
+		},
+		{
+			name: "not found",
+			path: "/missing",
+			want: WriteStats{StatusCode: http.StatusNotFound},
+		},
+		{
+			name: "handler panic",
+			path: "/panic",
+			want: WriteStats{StatusCode: http.StatusInternalServerError, ContentType: "text/plain; charset=utf-8", BytesWritten: 21, Route: "/panic", Panicked: true},
+		},
+		{
+			name:      "panic after the status code is written",
+			path:      "/stream-panic",
+			wantPanic: true,
+			want:      WriteStats{StatusCode: http.StatusOK, ContentType: "text/plain", BytesWritten: 7, Route: "/stream-panic", Panicked: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			var got []WriteStats
+			r := NewRouter(false, func(err error) {}).ObserveWrites(func(req *http.Request, stats WriteStats) {
+				got = append(got, stats)
+			})
+			r.Handle(http.MethodGet, "/users/{id}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.PlainTextHttpResponse(http.StatusCreated, "created"), nil
+			})
+			r.Handle(http.MethodGet, "/stream", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.RawWriterHttpResponse("text/plain", func(w io.Writer) error {
+					w.Write([]byte("chunk1"))
+					_, err := w.Write([]byte("ch2\n"))
+					return err
+				}), nil
+			})
+			r.Handle(http.MethodGet, "/copy", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.ContentHttpResponse("copied.txt", time.Time{}, strings.NewReader("copied")), nil
+			})
+			r.Handle(http.MethodGet, "/panic", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				panic("boom")
+			})
+			r.Handle(http.MethodGet, "/stream-panic", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.RawWriterHttpResponse("text/plain", func(w io.Writer) error {
+					w.Write([]byte("partial"))
+					panic("boom")
+				}), nil
+			})
+			func() {
+				defer func() {
+					if p := recover(); (p != nil) != tt.wantPanic {
+						t.Errorf("ServeHTTP() panic = %v, want panic: %v", p, tt.wantPanic)
+					}
+				}()
+				r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			}()
+			if len(got) != 1 {
+				t.Fatalf("WriteObserver() calls = %d, want 1", len(got))
+			}
+			tt.want.Start = start
+			tt.want.Duration = 15 * time.Millisecond
+			if got[0] != tt.want {
+				t.Errorf("WriteObserver() got: %+v, want: %+v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+// readerFromRecorder is a httptest.ResponseRecorder that implements io.ReaderFrom, like the http.Server response writer
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFromCalls int
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFromCalls++
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestRouter_ServeHTTP_ReadFrom(t *testing.T) {
+	var got WriteStats
+	r := NewRouter(false, func(err error) {}).ObserveWrites(func(req *http.Request, stats WriteStats) {
+		got = stats
+	})
+	r.Handle(http.MethodGet, "/file", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.ContentHttpResponse("file.txt", time.Time{}, strings.NewReader("content")), nil
+	})
+	recorder := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/file", nil))
+	if recorder.readFromCalls != 1 {
+		t.Errorf("ReadFrom() calls got: %d, want: 1", recorder.readFromCalls)
+	}
+	if recorder.Body.String() != "content" {
+		t.Errorf("ServeHTTP() body got: %q, want: content", recorder.Body.String())
+	}
+	if got.StatusCode != http.StatusOK || got.BytesWritten != 7 {
+		t.Errorf("WriteObserver() got: %+v, want status code: %d and bytes written: 7", got, http.StatusOK)
+	}
+}
+
+// hijackerRecorder is a httptest.ResponseRecorder that implements http.Hijacker
+type hijackerRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r hijackerRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+// writerFuncResponse calls a function with the http.ResponseWriter
+type writerFuncResponse func(w http.ResponseWriter)
+
+func (r writerFuncResponse) StatusCode() int               { return http.StatusOK }
+func (r writerFuncResponse) Headers() response.HttpHeaders { return nil }
+func (r writerFuncResponse) Cookies() response.HttpCookies { return nil }
+func (r writerFuncResponse) Write(w http.ResponseWriter, mc path.MatchingContext) error {
+	r(w)
+	return nil
+}
+
+func TestRouter_ServeHTTP_ResponseWriterFeatures(t *testing.T) {
+	tests := []struct {
+		name         string
+		w            http.ResponseWriter
+		wantFlusher  bool
+		wantHijacker bool
+	}{
+		{
+			name: "no optional features",
+			w:    struct{ http.ResponseWriter }{httptest.NewRecorder()},
+		},
+		{
+			name:        "flusher",
+			w:           httptest.NewRecorder(),
+			wantFlusher: true,
+		},
+		{
+			name: "hijacker",
+			w: struct {
+				http.ResponseWriter
+				http.Hijacker
+			}{httptest.NewRecorder(), hijackerRecorder{}},
+			wantHijacker: true,
+		},
+		{
+			name:         "flusher and hijacker",
+			w:            hijackerRecorder{httptest.NewRecorder()},
+			wantFlusher:  true,
+			wantHijacker: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotFlusher, gotHijacker bool
+			r := NewRouter(false, func(err error) {})
+			r.Handle(http.MethodGet, "/", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return writerFuncResponse(func(w http.ResponseWriter) {
+					_, gotFlusher = w.(http.Flusher)
+					_, gotHijacker = w.(http.Hijacker)
+					if _, ok := w.(io.ReaderFrom); !ok {
+						t.Errorf("ServeHTTP() the response writer doesn't implement io.ReaderFrom")
+					}
+				}), nil
+			})
+			r.ServeHTTP(tt.w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if gotFlusher != tt.wantFlusher {
+				t.Errorf("ServeHTTP() http.Flusher got: %v, want: %v", gotFlusher, tt.wantFlusher)
+			}
+			if gotHijacker != tt.wantHijacker {
+				t.Errorf("ServeHTTP() http.Hijacker got: %v, want: %v", gotHijacker, tt.wantHijacker)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"errors"
+	"github.com/ixtendio/gofre/logging"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicConfig contains the settings used by the Router to handle the panics recovered while a request is handled or while its response is written.
+// If no custom values are provided for the struct fields then, the default one are used
+type PanicConfig struct {
+	// called with the recovered value and the stack trace of the panic, for example to log or report it.
+	// Default: nil (the recovered value and the stack trace are logged, as separate messages, with the router logger)
+	OnPanic func(req *http.Request, recovered any, stack []byte)
+	// the status code of the response. Default: http.StatusInternalServerError
+	StatusCode int
+	// the Content-Type of the response body. Default: "text/plain; charset=utf-8"
+	ContentType string
+	// the response body. The recovered value and the stack trace are never sent to the client. Default: the status text
+	Body []byte
+}
+
+func (c *PanicConfig) setDefaults() {
+	if c.StatusCode == 0 {
+		c.StatusCode = http.StatusInternalServerError
+	}
+	if c.ContentType == "" {
+		c.ContentType = "text/plain; charset=utf-8"
+	}
+	if c.Body == nil {
+		c.Body = []byte(http.StatusText(c.StatusCode))
+	}
+}
+
+// recoverPanic handles a panic recovered while serving the request. If the response status code was not written yet,
+// the configured response is written, otherwise the connection is aborted, because the client already received a part of the response.
+// The http.ErrAbortHandler panics are propagated as they are
+func (c *PanicConfig) recoverPanic(w *recordingResponseWriter, req *http.Request, recovered any, logger logging.Logger) {
+	if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+		panic(recovered)
+	}
+	stack := debug.Stack()
+	if c.OnPanic != nil {
+		c.OnPanic(req, recovered, stack)
+	} else {
+		if len(w.requestId) > 0 {
+			logger = logger.With(logging.FieldRequestId, w.requestId)
+		}
+		logger.Error("recover from panic", logging.FieldMethod, req.Method, "path", req.URL.Path, "panic", recovered)
+		logger.Error("panic stack", "stack", string(stack))
+	}
+	w.panicked = true
+	if w.headerWritten() {
+		panic(http.ErrAbortHandler)
+	}
+	header := w.Header()
+	for name := range header {
+		header.Del(name)
+	}
+	header.Set("Content-Type", c.ContentType)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if len(w.requestIdHeader) > 0 && len(w.requestId) > 0 {
+		header.Set(w.requestIdHeader, w.requestId)
+	}
+	w.WriteHeader(c.StatusCode)
+	w.Write(c.Body)
+}
@@ -0,0 +1,145 @@
+package router
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/router/path"
+
+	"github.com/ixtendio/gofre/response"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type panicMarshaler struct{}
+
+func (p panicMarshaler) MarshalJSON() ([]byte, error) {
+	panic("marshal panic")
+}
+
+func TestRouter_ServeHTTP_Panic(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          *PanicConfig
+		handler         func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error)
+		wantRecovered   any
+		wantStatusCode  int
+		wantBody        string
+		wantContentType string
+		wantPanic       any
+	}{
+		{
+			name: "handler panic",
+			handler: func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				panic("handler panic")
+			},
+			wantRecovered:   "handler panic",
+			wantStatusCode:  http.StatusInternalServerError,
+			wantBody:        "Internal Server Error",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name: "panic while the response is written, with custom response",
+			config: &PanicConfig{
+				StatusCode:  http.StatusServiceUnavailable,
+				ContentType: "application/json",
+				Body:        []byte(`{"error":"unavailable"}`),
+			},
+			handler: func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.JsonHttpResponseWithHeaders(http.StatusOK, panicMarshaler{}, response.HttpHeaders{"X-Custom": "value"}), nil
+			},
+			wantRecovered:   "marshal panic",
+			wantStatusCode:  http.StatusServiceUnavailable,
+			wantBody:        `{"error":"unavailable"}`,
+			wantContentType: "application/json",
+		},
+		{
+			name: "panic after the status code is written aborts the connection",
+			handler: func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				return response.RawWriterHttpResponse("text/plain", func(w io.Writer) error {
+					w.Write([]byte("partial"))
+					panic("stream panic")
+				}), nil
+			},
+			wantRecovered: "stream panic",
+			wantPanic:     http.ErrAbortHandler,
+		},
+		{
+			name: "http.ErrAbortHandler is propagated",
+			handler: func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				panic(http.ErrAbortHandler)
+			},
+			wantPanic: http.ErrAbortHandler,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRecovered any
+			var gotStack []byte
+			config := PanicConfig{}
+			if tt.config != nil {
+				config = *tt.config
+			}
+			config.OnPanic = func(req *http.Request, recovered any, stack []byte) {
+				gotRecovered = recovered
+				gotStack = stack
+			}
+			r := NewRouterWithDefaultConfig().HandlePanic(config)
+			r.Handle(http.MethodGet, "/panic", tt.handler)
+			recorder := httptest.NewRecorder()
+			gotPanic := func() (p any) {
+				defer func() {
+					p = recover()
+				}()
+				r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+				return nil
+			}()
+			if gotPanic != tt.wantPanic {
+				t.Fatalf("ServeHTTP() panic = %v, want %v", gotPanic, tt.wantPanic)
+			}
+			if gotRecovered != tt.wantRecovered {
+				t.Errorf("OnPanic() recovered = %v, want %v", gotRecovered, tt.wantRecovered)
+			}
+			if tt.wantRecovered != nil && !strings.Contains(string(gotStack), "goroutine") {
+				t.Errorf("OnPanic() stack = %s, want a stack trace", gotStack)
+			}
+			if tt.wantPanic != nil {
+				return
+			}
+			if recorder.Code != tt.wantStatusCode || recorder.Body.String() != tt.wantBody {
+				t.Errorf("ServeHTTP() got: %d %s, want: %d %s", recorder.Code, recorder.Body.String(), tt.wantStatusCode, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("ServeHTTP() Content-Type = %s, want %s", got, tt.wantContentType)
+			}
+			if got := recorder.Header().Get("X-Custom"); got != "" {
+				t.Errorf("ServeHTTP() the response headers were not reset, X-Custom = %s", got)
+			}
+		})
+	}
+}
+
+func TestPanicConfig_defaultOnPanic(t *testing.T) {
+	var logged []string
+	r := NewRouter(false, func(err error) {
+		logged = append(logged, err.Error())
+	})
+	r.Handle(http.MethodGet, "/panic", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		panic("boom")
+	})
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if len(logged) != 2 {
+		t.Fatalf("errLogFunc() calls = %d, want 2", len(logged))
+	}
+	if logged[0] != "recover from panic method=GET path=/panic panic=boom" {
+		t.Errorf("errLogFunc() message = %s", logged[0])
+	}
+	if !strings.HasPrefix(logged[1], `panic stack stack="goroutine`) {
+		t.Errorf("errLogFunc() stack = %s", logged[1])
+	}
+	if strings.Contains(recorder.Body.String(), "boom") {
+		t.Errorf("ServeHTTP() the panic value was sent to the client: %s", recorder.Body.String())
+	}
+}
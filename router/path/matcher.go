@@ -150,6 +150,7 @@ func (m *Matcher) Match(urlPath string, mc *MatchingContext) *Pattern {
 		return nil
 	}
 	if len(mc.PathSegments) == 0 && m.rootPathMatcher != nil {
+		mc.matchedPattern = m.rootPathMatcher
 		return m.rootPathMatcher
 	}
 	var treeDepth int
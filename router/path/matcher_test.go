@@ -399,6 +399,9 @@ func TestMatcher_Match(t *testing.T) {
 			ParseURLPath(reqUrl, mc)
 			if p := m.Match(reqUrl.Path, mc); p != nil {
 				got.matchedPattern = p.RawValue
+				if mc.MatchedPattern() != p.RawValue {
+					t.Errorf("MatchedPattern() got: %v, want: %v", mc.MatchedPattern(), p.RawValue)
+				}
 			}
 			if len(tt.want.captureVars) > 0 {
 				var captureVars []CaptureVar
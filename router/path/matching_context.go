@@ -50,6 +50,14 @@ func (mc *MatchingContext) Clone() MatchingContext {
 	}
 }
 
+// MatchedPattern returns the raw value of the pattern that matched the request (for example "/users/{id}") or an empty string
+func (mc *MatchingContext) MatchedPattern() string {
+	if mc.matchedPattern == nil {
+		return ""
+	}
+	return mc.matchedPattern.RawValue
+}
+
 func (mc *MatchingContext) PathVar(name string) string {
 	p := mc.matchedPattern
 	if p == nil || p.captureVarsLen == 0 {
@@ -1,13 +1,17 @@
 package router
 
 import (
+	"context"
 	"fmt"
 	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/logging"
 	"github.com/ixtendio/gofre/router/path"
 	"log"
 	"net/http"
+	"runtime/pprof"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 var urlPathSegmentsPool = sync.Pool{
@@ -17,6 +21,45 @@ var urlPathSegmentsPool = sync.Pool{
 	},
 }
 
+// The pprof labels of the requests, see Router.EnableProfilerLabels
+const (
+	ProfilerLabelMethod = "method"
+	ProfilerLabelRoute  = "route"
+)
+
+// the route label of the requests that don't match any route
+const unmatchedRouteLabel = "unmatched"
+
+type requestScopeCtxKey int
+
+// the key used to pass the requestScope to the context.Context, see SetRequestId
+const requestScopeKey requestScopeCtxKey = 1
+
+// requestScope contains the values set by the handler middlewares for the request served by the router. It is not pooled,
+// like the recordingResponseWriter, because it can be referenced by the context.Context after the request was served
+type requestScope struct {
+	// the requestIdHeader set with SetRequestId
+	requestId atomic.Value
+}
+
+// requestIdHeader contains the request id and the name of the response header used to echo it
+type requestIdHeader struct {
+	name  string
+	value string
+}
+
+func (s *requestScope) getRequestId() requestIdHeader {
+	requestId, _ := s.requestId.Load().(requestIdHeader)
+	return requestId
+}
+
+// setHeader echoes the request id, if any, on the response headers
+func (s *requestScope) setHeader(header http.Header) {
+	if requestId := s.getRequestId(); len(requestId.name) > 0 && len(requestId.value) > 0 {
+		header.Set(requestId.name, requestId.value)
+	}
+}
+
 var defaultErrLogFunc = func(err error) {
 	log.Printf("%v", err)
 }
@@ -25,6 +68,11 @@ type Router struct {
 	caseInsensitivePathMatch bool
 	endpointMatchers         map[string]*path.Matcher
 	errLogFunc               func(err error)
+	logger                   logging.Logger
+	notFoundHandler          handler.Handler
+	panicConfig              *PanicConfig
+	observers                []RequestObserver
+	profilerLabels           bool
 }
 
 func NewRouterWithDefaultConfig() *Router {
@@ -34,11 +82,13 @@ func NewRouter(caseInsensitivePathMatch bool, errLogFunc func(err error)) *Route
 	if errLogFunc == nil {
 		errLogFunc = defaultErrLogFunc
 	}
-	return &Router{
+	r := &Router{
 		caseInsensitivePathMatch: caseInsensitivePathMatch,
 		endpointMatchers:         make(map[string]*path.Matcher, 9),
 		errLogFunc:               errLogFunc,
+		logger:                   logging.NewErrFuncLogger(errLogFunc),
 	}
+	return r.HandlePanic(PanicConfig{})
 }
 
 // Handle register a new handler or panic if the handler can not be registered
@@ -61,9 +111,66 @@ func (r *Router) Handle(httpMethod string, pathPattern string, handler handler.H
 	return r
 }
 
+// HandleNotFound registers the handler that is called when no pattern matches the request
+// If no handler is registered, the http.StatusNotFound status code is written
+func (r *Router) HandleNotFound(handler handler.Handler) *Router {
+	r.notFoundHandler = handler
+	return r
+}
+
+// HandlePanic configures how the panics, recovered while a request is handled or while its response is written, are handled (see PanicConfig)
+// By default, the panic is logged with the router logger and an http.StatusInternalServerError response is written
+func (r *Router) HandlePanic(config PanicConfig) *Router {
+	config.setDefaults()
+	r.panicConfig = &config
+	return r
+}
+
+// WithLogger sets the logger used by the router, instead of the error log function. For each request, a logger with the method
+// and route fields is passed to the context.Context (see logging.GetLoggerFromContext)
+func (r *Router) WithLogger(logger logging.Logger) *Router {
+	if logger != nil {
+		r.logger = logger
+	}
+	return r
+}
+
+// EnableProfilerLabels runs each handler, and the write of its response, under pprof.Do with the route pattern (ProfilerLabelRoute)
+// and the method (ProfilerLabelMethod) labels, so that the CPU and goroutine profiles can be sliced per endpoint.
+// The requests that don't match any route have the "unmatched" route label
+func (r *Router) EnableProfilerLabels() *Router {
+	r.profilerLabels = true
+	return r
+}
+
 // ServeHTTP implements the http.Handler interface.
 // It's the entry point for all http traffic
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rw := recordingResponseWriterPool.Get().(*recordingResponseWriter)
+	rw.ResponseWriter = w
+	rw.req = req
+	rw.observers = r.observers
+	rw.start = timeNow()
+	for _, observer := range r.observers {
+		observer.RequestReceived(req)
+	}
+	scope := &requestScope{}
+	defer func() {
+		requestId := scope.getRequestId()
+		rw.requestId, rw.requestIdHeader = requestId.value, requestId.name
+		recovered := recover()
+		if recovered == nil {
+			r.observe(rw, req)
+			rw.reset()
+			recordingResponseWriterPool.Put(rw)
+			return
+		}
+		// the observers are called even if the panic is propagated
+		defer r.observe(rw, req)
+		r.panicConfig.recoverPanic(rw, req, recovered, r.logger)
+	}()
+	w = rw.responseWriter()
+	ctx := context.WithValue(req.Context(), requestScopeKey, scope)
 	httpMethod := req.Method
 	urlPath := req.URL.Path
 	urlSegmentsPtr := urlPathSegmentsPool.Get().(*[]path.UrlSegment)
@@ -80,25 +187,77 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	httpMethod = strings.ToUpper(httpMethod)
 	matcher := r.endpointMatchers[httpMethod]
 	if matcher == nil {
-		w.WriteHeader(http.StatusNotFound)
+		r.serveNotFound(ctx, w, req, mc)
 		return
 	}
 	pattern := matcher.Match(urlPath, &mc)
 	if pattern == nil {
-		w.WriteHeader(http.StatusNotFound)
+		r.serveNotFound(ctx, w, req, mc)
 		return
 	}
+	rw.route = mc.MatchedPattern()
 	matchedHandler := pattern.Attachment.(handler.Handler)
+	r.serve(ctx, w, req, mc, matchedHandler)
+}
+
+func (r *Router) serveNotFound(ctx context.Context, w http.ResponseWriter, req *http.Request, mc path.MatchingContext) {
+	if r.notFoundHandler == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	r.serve(ctx, w, req, mc, r.notFoundHandler)
+}
 
+func (r *Router) serve(ctx context.Context, w http.ResponseWriter, req *http.Request, mc path.MatchingContext, h handler.Handler) {
+	if !r.profilerLabels {
+		r.serveWithContext(ctx, w, req, mc, h)
+		return
+	}
+	route := mc.MatchedPattern()
+	if route == "" {
+		route = unmatchedRouteLabel
+	}
+	labels := pprof.Labels(ProfilerLabelMethod, req.Method, ProfilerLabelRoute, route)
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		r.serveWithContext(ctx, w, req, mc, h)
+	})
+}
+
+func (r *Router) serveWithContext(ctx context.Context, w http.ResponseWriter, req *http.Request, mc path.MatchingContext, h handler.Handler) {
+	logger := r.logger.With(logging.FieldMethod, req.Method, logging.FieldRoute, mc.MatchedPattern())
+	ctx = context.WithValue(ctx, logging.LoggerCtxKey, logger)
 	// Call the wrapped handler functions.
-	resp, err := matchedHandler(req.Context(), mc)
+	resp, err := h(ctx, mc)
+	// the request id is echoed by the router too, because the uncaught errors and the render failures don't write the response headers
+	if scope, ok := ctx.Value(requestScopeKey).(*requestScope); ok {
+		scope.setHeader(w.Header())
+	}
 	if err != nil {
-		r.errLogFunc(fmt.Errorf("uncaught error in GoFre framework, err: %w", err))
+		errLogger(ctx, logger).Error("uncaught error in GoFre framework", logging.FieldError, err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
 	if err := resp.Write(w, mc); err != nil {
-		r.errLogFunc(err)
+		errLogger(ctx, logger).Error("failed to write the response", logging.FieldError, err)
+	}
+}
+
+// SetRequestId sets the id of the request served with the context.Context, so that the errors logged by the router after the
+// handler returned, the recovered panics and the WriteStats contain it. If the header is not empty, the request id is echoed
+// with it on every response written by the router, including the uncaught errors and the panics. It is called by the middleware.RequestId
+func SetRequestId(ctx context.Context, header string, requestId string) {
+	if scope, ok := ctx.Value(requestScopeKey).(*requestScope); ok {
+		scope.requestId.Store(requestIdHeader{name: header, value: requestId})
+	}
+}
+
+// errLogger returns the request scoped logger with the request id set by the handler middlewares (see SetRequestId)
+func errLogger(ctx context.Context, logger logging.Logger) logging.Logger {
+	if scope, ok := ctx.Value(requestScopeKey).(*requestScope); ok {
+		if requestId := scope.getRequestId().value; len(requestId) > 0 {
+			return logger.With(logging.FieldRequestId, requestId)
+		}
 	}
+	return logger
 }
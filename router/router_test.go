@@ -1,15 +1,20 @@
 package router
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"github.com/ixtendio/gofre/logging"
 	"github.com/ixtendio/gofre/router/path"
 
 	"github.com/ixtendio/gofre/response"
+	"io"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"runtime/pprof"
 	"testing"
 )
 
@@ -148,6 +153,27 @@ func TestRouter_ServeHTTP(t *testing.T) {
 				Handle("GET", "/users/{userId}", okHandler).
 				Handle("POST", "/users/{userId}", errorHandler),
 		},
+		{
+			name: "handler not match with not found handler",
+			args: args{
+				writer: newFakeResponseWriter(),
+				req: &http.Request{
+					Method: "PUT",
+					URL:    mustParseURL("/users/batman/hello"),
+				},
+			},
+			want: want{
+				handlerInvoked: true,
+				pathVars:       nil,
+				responseCode:   200,
+				responseData:   "ok",
+				responseHeaders: map[string][]string{"Content-Type": {"text/plain; charset=utf-8"},
+					"X-Content-Type-Options": {"nosniff"}},
+			},
+			router: NewRouter(false, defaultErrLogFunc).
+				Handle("GET", "/users/{userId}", okHandler).
+				HandleNotFound(okHandler),
+		},
 	}
 	for _, tt := range tests {
 		gotRequest = path.MatchingContext{} //reset
@@ -255,3 +281,161 @@ func mustParseURL(rawURL string) *url.URL {
 	}
 	return u
 }
+
+func TestRouter_SetRequestId(t *testing.T) {
+	var servedCtx context.Context
+	var gotStats []WriteStats
+	r := NewRouterWithDefaultConfig().Handle(http.MethodGet, "/users/{id}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		if requestId := mc.R.URL.Query().Get("requestId"); len(requestId) > 0 {
+			SetRequestId(ctx, "X-Request-Id", requestId)
+		}
+		servedCtx = ctx
+		return response.PlainTextHttpResponseOK("ok"), nil
+	}).ObserveWrites(func(req *http.Request, stats WriteStats) {
+		gotStats = append(gotStats, stats)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/10?requestId=4bf92f35", nil))
+	// a context kept after the request was served doesn't change the next requests
+	SetRequestId(servedCtx, "X-Request-Id", "late")
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/11", nil))
+	if len(gotStats) != 2 || gotStats[0].RequestId != "4bf92f35" || gotStats[1].RequestId != "" {
+		t.Errorf("WriteStats.RequestId got: %+v, want: 4bf92f35 and an empty one", gotStats)
+	}
+}
+
+func TestRouter_SetRequestIdHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		want       string
+	}{
+		{name: "response", path: "/ok", header: "X-Request-Id", wantStatus: http.StatusOK, want: "4bf92f35"},
+		{name: "uncaught error", path: "/error", header: "X-Request-Id", wantStatus: http.StatusInternalServerError, want: "4bf92f35"},
+		{name: "panic", path: "/panic", header: "X-Request-Id", wantStatus: http.StatusInternalServerError, want: "4bf92f35"},
+		{name: "without header", path: "/error", wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouterWithDefaultConfig().WithLogger(logging.NewStdLogger(log.New(io.Discard, "", 0), logging.LevelInfo))
+			r.Handle(http.MethodGet, "/{action}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				SetRequestId(ctx, tt.header, "4bf92f35")
+				switch mc.PathVar("action") {
+				case "error":
+					return nil, fmt.Errorf("a simple error")
+				case "panic":
+					panic("a simple panic")
+				}
+				return response.PlainTextHttpResponseOK("ok"), nil
+			})
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != tt.wantStatus || recorder.Header().Get("X-Request-Id") != tt.want {
+				t.Errorf("ServeHTTP() got: %d %q, want: %d %q", recorder.Code, recorder.Header().Get("X-Request-Id"), tt.wantStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_WithLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+		path      string
+		want      string
+	}{
+		{
+			name: "request scoped logger",
+			path: "/users/10",
+			want: "level=INFO msg=handled method=GET route=/users/{id}\n",
+		},
+		{
+			name:      "the incoming request id is not logged",
+			path:      "/users/10",
+			requestId: "4bf92f35",
+			want:      "level=INFO msg=handled method=GET route=/users/{id}\n",
+		},
+		{
+			name: "uncaught error",
+			path: "/error",
+			want: "level=ERROR msg=\"uncaught error in GoFre framework\" method=GET route=/error err=\"a simple error\"\n",
+		},
+		{
+			name:      "uncaught error with request id",
+			path:      "/error",
+			requestId: "4bf92f35",
+			want:      "level=ERROR msg=\"uncaught error in GoFre framework\" method=GET route=/error requestId=4bf92f35 err=\"a simple error\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := NewRouterWithDefaultConfig().WithLogger(logging.NewStdLogger(log.New(&buf, "", 0), logging.LevelInfo))
+			r.Handle(http.MethodGet, "/users/{id}", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				logging.GetLoggerFromContext(ctx).Info("handled")
+				return response.PlainTextHttpResponseOK("ok"), nil
+			})
+			r.Handle(http.MethodGet, "/error", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				SetRequestId(ctx, "X-Request-Id", mc.R.Header.Get("X-Request-Id"))
+				return nil, fmt.Errorf("a simple error")
+			})
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if len(tt.requestId) > 0 {
+				req.Header.Set("X-Request-Id", tt.requestId)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("ServeHTTP() logged: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_EnableProfilerLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		path       string
+		wantRoute  string
+		wantMethod string
+	}{
+		{
+			name:    "labels disabled",
+			enabled: false,
+			path:    "/users/10",
+		},
+		{
+			name:       "matched route",
+			enabled:    true,
+			path:       "/users/10",
+			wantRoute:  "/users/{id}",
+			wantMethod: http.MethodGet,
+		},
+		{
+			name:       "unmatched route",
+			enabled:    true,
+			path:       "/orders/10",
+			wantRoute:  "unmatched",
+			wantMethod: http.MethodGet,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRoute, gotMethod string
+			h := func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+				gotRoute, _ = pprof.Label(ctx, ProfilerLabelRoute)
+				gotMethod, _ = pprof.Label(ctx, ProfilerLabelMethod)
+				return response.PlainTextHttpResponseOK("ok"), nil
+			}
+			r := NewRouterWithDefaultConfig().Handle(http.MethodGet, "/users/{id}", h).HandleNotFound(h)
+			if tt.enabled {
+				r.EnableProfilerLabels()
+			}
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if gotRoute != tt.wantRoute || gotMethod != tt.wantMethod {
+				t.Errorf("ServeHTTP() labels got: route=%q method=%q, want: route=%q method=%q", gotRoute, gotMethod, tt.wantRoute, tt.wantMethod)
+			}
+		})
+	}
+}
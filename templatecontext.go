@@ -0,0 +1,207 @@
+package gofre
+
+import (
+	"context"
+	"fmt"
+	"github.com/ixtendio/gofre/auth"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/middleware"
+	"github.com/ixtendio/gofre/router/path"
+	"html/template"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/ixtendio/gofre/response"
+)
+
+// templateRequest is the request whose values are returned by the request scoped template functions
+type templateRequest struct {
+	ctx context.Context
+	mc  path.MatchingContext
+}
+
+func (r *templateRequest) context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// requestTemplateFuncMap returns the template functions bound to a request:
+//   - csrfToken - returns the CSRF nonce (see middleware.CSRFPrevention) or an empty string
+//   - csrfField - returns a hidden <input> with the CSRF nonce, to be added in the forms, or an empty string
+//   - currentUser - returns the auth.SecurityPrincipal of the authenticated user or nil
+//   - locale - returns the i18n.Locale of the request (see middleware.Localization) or the I18n catalog default locale, to be used with t: {{t locale "greeting"}}
+//   - requestPath - returns the request URL path
+//   - routeName - returns the name of the matched route, which is its path pattern, for example: /users/{id}
+//   - flashes - returns the messages added by the previous request with middleware.AddFlash (see middleware.Flash)
+func (c *ResourcesConfig) requestTemplateFuncMap(req *templateRequest) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() string {
+			return middleware.GetCSRFNonceFromContext(req.context())
+		},
+		"csrfField": func() template.HTML {
+			nonce := middleware.GetCSRFNonceFromContext(req.context())
+			if len(nonce) == 0 {
+				return ""
+			}
+			return template.HTML(`<input type="hidden" name="` + middleware.CSRFNonceRequestParamName + `" value="` + template.HTMLEscapeString(nonce) + `">`)
+		},
+		"currentUser": func() auth.SecurityPrincipal {
+			return auth.GetSecurityPrincipalFromContext(req.context())
+		},
+		"locale": func() *i18n.Locale {
+			if locale := i18n.GetLocaleFromContext(req.context()); locale != nil {
+				return locale
+			}
+			if c.I18n != nil {
+				return c.I18n.Locale(c.I18n.DefaultLocale())
+			}
+			return nil
+		},
+		"requestPath": func() string {
+			if req.mc.R == nil {
+				return ""
+			}
+			return req.mc.R.URL.Path
+		},
+		"routeName": func() string {
+			return req.mc.MatchedPattern()
+		},
+		"flashes": func() []string {
+			return middleware.GetFlashesFromContext(req.context())
+		},
+	}
+}
+
+// boundTemplate is a copy of the parsed templates whose request scoped functions return the values of the bound request
+type boundTemplate struct {
+	template response.ExecutableTemplate
+	request  templateRequest
+}
+
+// templateBinder implements response.ContextTemplate and binds the request scoped functions (see requestTemplateFuncMap)
+// to the templates parsed by gofre. Because the functions of an html/template can not be changed while it is executed, and
+// it can not be cloned after it was executed, the binder keeps a copy of the parsed templates that is never executed, and
+// a list of bound copies, cloned from it when all the other ones are in use. A bound copy is escaped by html/template when
+// its templates are executed the first time and then is reused by all the next renderings. To not keep the copies cloned
+// for a peak of concurrent renderings, at most GOMAXPROCS unused copies are kept
+type templateBinder struct {
+	// the parsed templates, returned by MuxHandler.ExecutableTemplate: a *template.Template or a *response.LayoutTemplate
+	parsed response.ExecutableTemplate
+	// the never executed copy of the parsed templates
+	prototype response.ExecutableTemplate
+	config    *ResourcesConfig
+	mu        sync.Mutex
+	free      []*boundTemplate
+	// the maximum number of unused copies
+	maxFree int
+}
+
+// newTemplateBinder creates a binder for the templates parsed by gofre, before they are executed, or returns nil if
+// there is no template to bind (a zero template.Template)
+func newTemplateBinder(config *ResourcesConfig, parsed response.ExecutableTemplate) (*templateBinder, error) {
+	if t, ok := parsed.(*template.Template); ok && *t == (template.Template{}) {
+		return nil, nil
+	}
+	prototype, err := cloneTemplate(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed cloning the templates, err: %w", err)
+	}
+	return &templateBinder{
+		parsed:    parsed,
+		prototype: prototype,
+		config:    config,
+		maxFree:   runtime.GOMAXPROCS(0),
+	}, nil
+}
+
+// cloneTemplate returns a copy of a *template.Template or a *response.LayoutTemplate
+func cloneTemplate(tmpl response.ExecutableTemplate) (response.ExecutableTemplate, error) {
+	switch t := tmpl.(type) {
+	case *template.Template:
+		return t.Clone()
+	case *response.LayoutTemplate:
+		return t.Clone()
+	}
+	return nil, fmt.Errorf("unsupported template type: %T", tmpl)
+}
+
+// acquire returns an unused bound copy of the templates or clones a new one
+func (b *templateBinder) acquire() (*boundTemplate, error) {
+	b.mu.Lock()
+	if n := len(b.free); n > 0 {
+		bt := b.free[n-1]
+		b.free = b.free[:n-1]
+		b.mu.Unlock()
+		return bt, nil
+	}
+	b.mu.Unlock()
+
+	bt := &boundTemplate{}
+	clone, err := cloneTemplate(b.prototype)
+	if err != nil {
+		return nil, err
+	}
+	funcMap := b.config.requestTemplateFuncMap(&bt.request)
+	switch t := clone.(type) {
+	case *template.Template:
+		bt.template = t.Funcs(funcMap)
+	case *response.LayoutTemplate:
+		bt.template = t.Funcs(funcMap)
+	}
+	return bt, nil
+}
+
+func (b *templateBinder) release(bt *boundTemplate) {
+	// the request values are not kept while the copy is unused
+	bt.request = templateRequest{}
+	b.mu.Lock()
+	if len(b.free) < b.maxFree {
+		b.free = append(b.free, bt)
+	}
+	b.mu.Unlock()
+}
+
+func (b *templateBinder) WithContext(ctx context.Context, mc path.MatchingContext) (response.ExecutableTemplate, func(), error) {
+	bt, err := b.acquire()
+	if err != nil {
+		return nil, nil, err
+	}
+	bt.request = templateRequest{ctx: ctx, mc: mc}
+	return bt.template, func() { b.release(bt) }, nil
+}
+
+func (b *templateBinder) Execute(wr io.Writer, data any) error {
+	return b.parsed.Execute(wr, data)
+}
+
+func (b *templateBinder) ExecuteTemplate(wr io.Writer, name string, data any) error {
+	return b.parsed.ExecuteTemplate(wr, name, data)
+}
+
+// ContentType returns the HTML content type, because the templates are always parsed with html/template
+func (b *templateBinder) ContentType() string {
+	return htmlContentType
+}
+
+// bindTemplateContext passes the handler context.Context, that contains the values added by the middlewares, to the
+// template responses of the templates parsed by gofre, so that the request scoped template functions can be used without
+// adding these values to the template data
+func bindTemplateContext(h handler.Handler, binder *templateBinder) handler.Handler {
+	return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		resp, err := h(ctx, mc)
+		if tr, ok := resp.(*response.HttpTemplateResponse); ok {
+			// the parsed templates are pointers, so they can be compared with any template
+			if binder != nil && tr.Template == binder.parsed {
+				tr.Template = binder
+			}
+			if tr.Context == nil {
+				tr.Context = ctx
+			}
+		}
+		return resp, err
+	}
+}
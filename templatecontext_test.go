@@ -0,0 +1,155 @@
+package gofre
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/auth"
+	"github.com/ixtendio/gofre/handler"
+	"github.com/ixtendio/gofre/i18n"
+	"github.com/ixtendio/gofre/middleware"
+	"github.com/ixtendio/gofre/router/path"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ixtendio/gofre/response"
+)
+
+func TestMuxHandler_RequestTemplateFuncs(t *testing.T) {
+	catalog := i18n.NewCatalog("en")
+	if err := catalog.AddJSON("en", []byte(`{"hello": "Hello"}`)); err != nil {
+		t.Fatalf("AddJSON() unexpected error: %v", err)
+	}
+	if err := catalog.AddJSON("fr", []byte(`{"hello": "Bonjour"}`)); err != nil {
+		t.Fatalf("AddJSON() unexpected error: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"templates/form.html":  &fstest.MapFile{Data: []byte(`<form>{{csrfField}}</form>{{with currentUser}}{{.Identity}}{{end}}|{{t locale "hello"}}|{{requestPath}}|{{routeName}}|{{range flashes}}{{.}};{{end}}|{{.}}`)},
+		"templates/token.html": &fstest.MapFile{Data: []byte(`{{csrfToken}}`)},
+		"pages/_layout.html":   &fstest.MapFile{Data: []byte(`{{block "content" .}}{{end}}`)},
+		"pages/users.html":     &fstest.MapFile{Data: []byte(`{{define "content"}}{{routeName}} {{with currentUser}}{{.Identity}}{{end}}{{end}}`)},
+	}
+	newMuxHandler := func(resourcesConfig *ResourcesConfig) *MuxHandler {
+		m, err := NewMuxHandler(&Config{ResourcesConfig: resourcesConfig})
+		if err != nil {
+			t.Fatalf("NewMuxHandler() unexpected error: %v", err)
+		}
+		return m
+	}
+	m := newMuxHandler(&ResourcesConfig{FS: fsys, TemplatesPathPattern: "templates/*.html", I18n: catalog})
+	lm := newMuxHandler(&ResourcesConfig{FS: fsys, TemplatesLayout: &response.LayoutTemplateOptions{PagesDir: "pages"}})
+
+	requestValues := func(h handler.Handler) handler.Handler {
+		return func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+			if mc.R.Header.Get("X-Anonymous") != "" {
+				return h(ctx, mc)
+			}
+			ctx = context.WithValue(ctx, middleware.CSRFNonceCtxKey, `a"b`)
+			ctx = context.WithValue(ctx, auth.SecurityPrincipalCtxKey, auth.User{Id: "john"})
+			return h(ctx, mc)
+		}
+	}
+	m.CommonMiddlewares(requestValues, middleware.Localization(catalog, i18n.ResolverConfig{}), middleware.Flash(middleware.FlashConfig{}))
+	lm.CommonMiddlewares(requestValues)
+	m.HandleGet("/users/{id}/edit", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.TemplateHttpResponseOK(m.ExecutableTemplate(), "form.html", mc.PathVar("id")), nil
+	})
+	m.HandleGet("/token", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.TemplateHttpResponseOK(m.ExecutableTemplate(), "token.html", nil), nil
+	})
+	lm.HandleGet("/users", func(ctx context.Context, mc path.MatchingContext) (response.HttpResponse, error) {
+		return response.TemplateHttpResponseOK(lm.ExecutableTemplate(), "users.html", nil), nil
+	})
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		path     string
+		headers  http.Header
+		wantBody string
+	}{
+		{
+			name:     "request values",
+			handler:  m,
+			path:     "/users/10/edit",
+			headers:  http.Header{"Accept-Language": {"fr"}, "Cookie": {"gofre_flash=WyJTYXZlZCIsIjxiPiJd"}},
+			wantBody: `<form><input type="hidden" name="_csrf" value="a&#34;b"></form>john|Bonjour|/users/10/edit|/users/{id}/edit|Saved;&lt;b&gt;;|10`,
+		},
+		{
+			name:     "anonymous request",
+			handler:  m,
+			path:     "/users/11/edit",
+			headers:  http.Header{"X-Anonymous": {"true"}},
+			wantBody: `<form></form>|Hello|/users/11/edit|/users/{id}/edit||11`,
+		},
+		{
+			name:     "csrf token",
+			handler:  m,
+			path:     "/token",
+			wantBody: `a&#34;b`,
+		},
+		{
+			name:     "layout template",
+			handler:  lm,
+			path:     "/users",
+			wantBody: `/users john`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			// the same template is rendered concurrently for different requests
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+					for k, v := range tt.headers {
+						req.Header[k] = v
+					}
+					recorder := httptest.NewRecorder()
+					tt.handler.ServeHTTP(recorder, req)
+					if recorder.Code != http.StatusOK || recorder.Body.String() != tt.wantBody {
+						t.Errorf("ServeHTTP() got: %d %q, want: %q", recorder.Code, recorder.Body.String(), tt.wantBody)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+
+	// the bound copies of the templates are reused, instead of being cloned for each request
+	if got := len(m.templateBinder().free); got < 1 || got > 4 {
+		t.Errorf("templateBinder() bound copies got: %d, want between 1 and 4", got)
+	}
+
+	// executed directly, the request scoped functions return empty values
+	if _, ok := m.ExecutableTemplate().(*template.Template); !ok {
+		t.Fatalf("ExecutableTemplate() got: %T, want: *template.Template", m.ExecutableTemplate())
+	}
+	var sb strings.Builder
+	if err := m.ExecutableTemplate().ExecuteTemplate(&sb, "token.html", nil); err != nil || sb.String() != "" {
+		t.Errorf("ExecuteTemplate() got: %q, err: %v", sb.String(), err)
+	}
+	// the unused bound copies are limited
+	binder := m.templateBinder()
+	binder.maxFree = 1
+	bt1, _ := binder.acquire()
+	bt2, _ := binder.acquire()
+	binder.release(bt1)
+	binder.release(bt2)
+	if got := len(binder.free); got != 1 {
+		t.Errorf("templateBinder() unused bound copies got: %d, want: 1", got)
+	}
+
+	// and the parsed templates can still be bound, after they were executed
+	m.templateBinder().free = nil
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/token", nil))
+	if recorder.Body.String() != `a&#34;b` {
+		t.Errorf("ServeHTTP() after a direct execution got: %q, want: %q", recorder.Body.String(), `a&#34;b`)
+	}
+}
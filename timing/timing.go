@@ -0,0 +1,150 @@
+package timing
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ctxKey int
+
+// TimingsCtxKey is used to pass the request *Timings to the context.Context
+const TimingsCtxKey ctxKey = 1
+
+// GetTimingsFromContext returns the request *Timings from the context.Context or nil if missing. The *Timings methods can be called on a nil *Timings
+func GetTimingsFromContext(ctx context.Context) *Timings {
+	if timings, ok := ctx.Value(TimingsCtxKey).(*Timings); ok {
+		return timings
+	}
+	return nil
+}
+
+// ContextWithTimings returns a copy of the context.Context with the request *Timings
+func ContextWithTimings(ctx context.Context, timings *Timings) context.Context {
+	return context.WithValue(ctx, TimingsCtxKey, timings)
+}
+
+// Start starts a timer for a named operation of the request, for example "db", that is recorded when it is stopped:
+//
+//	defer timing.Start(ctx, "db").Stop()
+//
+// If the context.Context has no *Timings (see the middleware.ServerTiming), the returned timer is nil and its methods are no-ops
+func Start(ctx context.Context, name string) *Timer {
+	timings := GetTimingsFromContext(ctx)
+	if timings == nil {
+		return nil
+	}
+	return &Timer{timings: timings, name: name, start: time.Now()}
+}
+
+// Add records the duration of a named operation of the request, if the context.Context has *Timings
+func Add(ctx context.Context, name string, duration time.Duration) {
+	GetTimingsFromContext(ctx).Add(name, "", duration)
+}
+
+// A Metric is a named duration of the request
+type Metric struct {
+	Name        string
+	Description string
+	Duration    time.Duration
+}
+
+// Timings collects the metrics of a request. It is safe for concurrent use
+type Timings struct {
+	mu      sync.Mutex
+	metrics []Metric
+}
+
+func NewTimings() *Timings {
+	return &Timings{}
+}
+
+// Add records a metric. The metrics with the same name are not merged
+func (t *Timings) Add(name string, description string, duration time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.metrics = append(t.metrics, Metric{Name: name, Description: description, Duration: duration})
+	t.mu.Unlock()
+}
+
+// Metrics returns a copy of the recorded metrics, in the order they were recorded
+func (t *Timings) Metrics() []Metric {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Metric(nil), t.metrics...)
+}
+
+// Header returns the Server-Timing header value of the recorded metrics, for example: db;dur=12.5, render;desc="page";dur=3.1
+// The durations are in milliseconds and the invalid chars of the metric names are replaced with '_'
+func (t *Timings) Header() string {
+	var sb strings.Builder
+	for i, m := range t.Metrics() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(metricName(m.Name))
+		if len(m.Description) > 0 {
+			sb.WriteString(";desc=")
+			sb.WriteString(strconv.Quote(m.Description))
+		}
+		sb.WriteString(";dur=")
+		sb.WriteString(strconv.FormatFloat(float64(m.Duration.Microseconds())/1000, 'f', -1, 64))
+	}
+	return sb.String()
+}
+
+// metricName returns the name as an HTTP token, as required by the Server-Timing header
+func metricName(name string) string {
+	if len(name) == 0 {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if isTokenChar(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func isTokenChar(r rune) bool {
+	if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
+
+// A Timer measures the duration of an operation. The *Timer methods can be called on a nil *Timer
+type Timer struct {
+	timings     *Timings
+	name        string
+	description string
+	start       time.Time
+	once        sync.Once
+}
+
+// Describe sets the description of the metric, for example the name of the query
+func (t *Timer) Describe(description string) *Timer {
+	if t != nil {
+		t.description = description
+	}
+	return t
+}
+
+// Stop records the elapsed time since the timer was started and returns it. The next calls have no effect
+func (t *Timer) Stop() time.Duration {
+	if t == nil {
+		return 0
+	}
+	elapsed := time.Since(t.start)
+	t.once.Do(func() {
+		t.timings.Add(t.name, t.description, elapsed)
+	})
+	return elapsed
+}
@@ -0,0 +1,86 @@
+package timing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimings_Header(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics []Metric
+		want    string
+	}{
+		{
+			name: "no metrics",
+			want: "",
+		},
+		{
+			name: "durations in milliseconds",
+			metrics: []Metric{
+				{Name: "db", Duration: 12500 * time.Microsecond},
+				{Name: "cache", Duration: 3 * time.Millisecond},
+				{Name: "fast", Duration: 999 * time.Nanosecond},
+			},
+			want: "db;dur=12.5, cache;dur=3, fast;dur=0",
+		},
+		{
+			name: "description",
+			metrics: []Metric{
+				{Name: "db", Description: `select "users"`, Duration: 1234 * time.Microsecond},
+			},
+			want: `db;desc="select \"users\"";dur=1.234`,
+		},
+		{
+			name: "invalid name chars",
+			metrics: []Metric{
+				{Name: "db query;1", Duration: time.Millisecond},
+				{Name: "", Duration: time.Millisecond},
+			},
+			want: "db_query_1;dur=1, _;dur=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timings := NewTimings()
+			for _, m := range tt.metrics {
+				timings.Add(m.Name, m.Description, m.Duration)
+			}
+			if got := timings.Header(); got != tt.want {
+				t.Errorf("Header() got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	// without Timings in the context, the timers are no-ops
+	timer := Start(context.Background(), "db")
+	if timer != nil {
+		t.Fatalf("Start() got: %v, want nil", timer)
+	}
+	if got := timer.Describe("query").Stop(); got != 0 {
+		t.Errorf("Stop() got: %v, want 0", got)
+	}
+	Add(context.Background(), "cache", time.Millisecond)
+
+	timings := NewTimings()
+	ctx := ContextWithTimings(context.Background(), timings)
+	if GetTimingsFromContext(ctx) != timings {
+		t.Fatalf("GetTimingsFromContext() got: %v, want: %v", GetTimingsFromContext(ctx), timings)
+	}
+	timer = Start(ctx, "db").Describe("users")
+	elapsed := timer.Stop()
+	timer.Stop()
+	Add(ctx, "cache", time.Millisecond)
+
+	want := []Metric{
+		{Name: "db", Description: "users", Duration: elapsed},
+		{Name: "cache", Duration: time.Millisecond},
+	}
+	if got := timings.Metrics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metrics() got: %v, want: %v", got, want)
+	}
+}
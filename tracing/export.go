@@ -0,0 +1,217 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Exporter sends the ended spans to a tracing backend. The exporters are called from a single goroutine at a time
+type Exporter interface {
+	Export(ctx context.Context, serviceName string, spans []*Span) error
+}
+
+// The ExporterFunc type is an adapter to allow the use of ordinary functions as span exporters
+type ExporterFunc func(ctx context.Context, serviceName string, spans []*Span) error
+
+func (f ExporterFunc) Export(ctx context.Context, serviceName string, spans []*Span) error {
+	return f(ctx, serviceName, spans)
+}
+
+type jsonLinesSpan struct {
+	Service       string         `json:"service"`
+	TraceId       string         `json:"traceId"`
+	SpanId        string         `json:"spanId"`
+	ParentSpanId  string         `json:"parentSpanId,omitempty"`
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	Start         string         `json:"start"`
+	End           string         `json:"end"`
+	DurationMs    float64        `json:"durationMs"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Status        string         `json:"status"`
+	StatusMessage string         `json:"statusMessage,omitempty"`
+}
+
+// JSONLinesExporter returns an Exporter that writes a JSON object per span and line, for example to os.Stdout or a file
+func JSONLinesExporter(w io.Writer) Exporter {
+	var mu sync.Mutex
+	return ExporterFunc(func(ctx context.Context, serviceName string, spans []*Span) error {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, span := range spans {
+			line := jsonLinesSpan{
+				Service:       serviceName,
+				TraceId:       span.spanContext.TraceID.String(),
+				SpanId:        span.spanContext.SpanID.String(),
+				Name:          span.Name,
+				Kind:          span.Kind.String(),
+				Start:         span.StartTime.Format(time.RFC3339Nano),
+				End:           span.EndTime.Format(time.RFC3339Nano),
+				DurationMs:    float64(span.EndTime.Sub(span.StartTime).Microseconds()) / 1000,
+				Attributes:    span.Attributes,
+				Status:        span.StatusCode.String(),
+				StatusMessage: span.StatusMessage,
+			}
+			if span.ParentSpanID.IsValid() {
+				line.ParentSpanId = span.ParentSpanID.String()
+			}
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := buf.WriteTo(w)
+		return err
+	})
+}
+
+// OTLPExporter returns an Exporter that posts the spans, in the OTLP/HTTP JSON encoding, to an OpenTelemetry collector,
+// for example http://localhost:4318. The "/v1/traces" path is appended to the endpoint if missing. If the client is nil, http.DefaultClient is used
+func OTLPExporter(endpoint string, client *http.Client) Exporter {
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return ExporterFunc(func(ctx context.Context, serviceName string, spans []*Span) error {
+		body, err := json.Marshal(newOtlpRequest(serviceName, spans))
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("failed to export the spans to %s, status code: %d", endpoint, resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// the OTLP/HTTP JSON encoding, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string         `json:"traceId"`
+	SpanId            string         `json:"spanId"`
+	ParentSpanId      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+const otlpScopeName = "github.com/ixtendio/gofre/tracing"
+
+func newOtlpRequest(serviceName string, spans []*Span) otlpRequest {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceId:           span.spanContext.TraceID.String(),
+			SpanId:            span.spanContext.SpanID.String(),
+			TraceState:        span.spanContext.TraceState,
+			Name:              span.Name,
+			Kind:              int(span.Kind),
+			StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+			Status:            otlpStatus{Code: int(span.StatusCode), Message: span.StatusMessage},
+		}
+		if span.ParentSpanID.IsValid() {
+			s.ParentSpanId = span.ParentSpanID.String()
+		}
+		otlpSpans = append(otlpSpans, s)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]any{"service.name": serviceName})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}, Spans: otlpSpans}},
+	}}}
+}
+
+// otlpAttributes converts the attributes to the OTLP key values, sorted by key
+func otlpAttributes(attributes map[string]any) []otlpKeyValue {
+	if len(attributes) == 0 {
+		return nil
+	}
+	keyValues := make([]otlpKeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		keyValues = append(keyValues, otlpKeyValue{Key: key, Value: otlpValue(value)})
+	}
+	sort.Slice(keyValues, func(i, j int) bool {
+		return keyValues[i].Key < keyValues[j].Key
+	})
+	return keyValues
+}
+
+func otlpValue(value any) map[string]any {
+	switch v := value.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int:
+		return map[string]any{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int32:
+		return map[string]any{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float32:
+		return map[string]any{"doubleValue": float64(v)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testSpans() []*Span {
+	start := time.Date(2023, 10, 10, 13, 55, 36, 0, time.UTC)
+	parent := &Span{
+		spanContext: SpanContext{
+			TraceID:    TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:     SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			Flags:      flagSampled,
+			TraceState: "vendor=value",
+		},
+		Name:          "GET /users/{id}",
+		Kind:          SpanKindServer,
+		StartTime:     start,
+		EndTime:       start.Add(1500 * time.Microsecond),
+		Attributes:    map[string]any{"http.route": "/users/{id}", "http.response.status_code": 500, "retry": true, "ratio": 0.5},
+		StatusCode:    StatusError,
+		StatusMessage: "500",
+	}
+	child := &Span{
+		spanContext: SpanContext{
+			TraceID: parent.spanContext.TraceID,
+			SpanID:  SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			Flags:   flagSampled,
+		},
+		ParentSpanID: parent.spanContext.SpanID,
+		Name:         "HTTP GET",
+		Kind:         SpanKindClient,
+		StartTime:    start,
+		EndTime:      start.Add(time.Millisecond),
+	}
+	return []*Span{child, parent}
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := JSONLinesExporter(&buf).Export(context.Background(), "users", testSpans()); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := `{"service":"users","traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"0102030405060708","parentSpanId":"00f067aa0ba902b7","name":"HTTP GET","kind":"client","start":"2023-10-10T13:55:36Z","end":"2023-10-10T13:55:36.001Z","durationMs":1,"status":"unset"}
+{"service":"users","traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","name":"GET /users/{id}","kind":"server","start":"2023-10-10T13:55:36Z","end":"2023-10-10T13:55:36.0015Z","durationMs":1.5,"attributes":{"http.response.status_code":500,"http.route":"/users/{id}","ratio":0.5,"retry":true},"status":"error","statusMessage":"500"}
+`
+	if buf.String() != want {
+		t.Errorf("JSONLinesExporter() got:\n%v\nwant:\n%v", buf.String(), want)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	tests := []struct {
+		name       string
+		endpoint   func(url string) string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "collector base URL",
+			endpoint:   func(url string) string { return url + "/" },
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "collector traces URL",
+			endpoint:   func(url string) string { return url + "/v1/traces" },
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "collector error",
+			endpoint:   func(url string) string { return url },
+			statusCode: http.StatusServiceUnavailable,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotContentType string
+			var gotBody map[string]any
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				gotContentType = r.Header.Get("Content-Type")
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &gotBody)
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer collector.Close()
+
+			err := OTLPExporter(tt.endpoint(collector.URL), collector.Client()).Export(context.Background(), "users", testSpans())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Export() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotPath != "/v1/traces" || gotContentType != "application/json" {
+				t.Errorf("Export() request got = %v %v, want /v1/traces application/json", gotPath, gotContentType)
+			}
+			var want map[string]any
+			json.Unmarshal([]byte(`{"resourceSpans":[{
+				"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"users"}}]},
+				"scopeSpans":[{"scope":{"name":"github.com/ixtendio/gofre/tracing"},"spans":[
+					{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"0102030405060708","parentSpanId":"00f067aa0ba902b7","name":"HTTP GET","kind":3,
+					 "startTimeUnixNano":"1696946136000000000","endTimeUnixNano":"1696946136001000000","status":{}},
+					{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","spanId":"00f067aa0ba902b7","traceState":"vendor=value","name":"GET /users/{id}","kind":2,
+					 "startTimeUnixNano":"1696946136000000000","endTimeUnixNano":"1696946136001500000",
+					 "attributes":[
+						{"key":"http.response.status_code","value":{"intValue":"500"}},
+						{"key":"http.route","value":{"stringValue":"/users/{id}"}},
+						{"key":"ratio","value":{"doubleValue":0.5}},
+						{"key":"retry","value":{"boolValue":true}}],
+					 "status":{"code":2,"message":"500"}}]}]}]}`), &want)
+			if !reflect.DeepEqual(gotBody, want) {
+				t.Errorf("Export() body got = %v, want %v", gotBody, want)
+			}
+		})
+	}
+}
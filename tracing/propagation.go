@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// the maximum length of the propagated tracestate header
+const maxTracestateLength = 512
+
+// Extract returns the remote parent SpanContext from the W3C traceparent and tracestate headers.
+// The second returned value is false if the traceparent header is missing or invalid
+func Extract(header http.Header) (SpanContext, bool) {
+	traceparent := header.Get(HeaderTraceparent)
+	if traceparent == "" {
+		return SpanContext{}, false
+	}
+	sc, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return SpanContext{}, false
+	}
+	tracestate := strings.TrimSpace(strings.Join(header.Values(HeaderTracestate), ","))
+	if len(tracestate) <= maxTracestateLength {
+		sc.TraceState = tracestate
+	}
+	return sc, true
+}
+
+// Inject sets the W3C traceparent and tracestate headers from the SpanContext of the current span, if valid
+func Inject(sc SpanContext, header http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(HeaderTracestate, sc.TraceState)
+	} else {
+		header.Del(HeaderTracestate)
+	}
+}
+
+// Transport is an http.RoundTripper that creates a client span for each outgoing request, child of the span from the request
+// context, and propagates the trace context through the W3C headers. The requests without a span in the context are sent as they are.
+// It can be used to instrument the oauth.HttpClient (see InstrumentClient) or any other client whose requests are created with the handler context
+type Transport struct {
+	// the wrapped transport. Default: http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, span := StartSpan(req.Context(), "HTTP "+req.Method, SpanKindClient)
+	if span == nil {
+		return base.RoundTrip(req)
+	}
+	defer span.End()
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("server.address", req.URL.Hostname())
+	span.SetAttribute("url.full", redactedUrl(req))
+
+	// a RoundTripper must not modify the original request
+	req = req.Clone(ctx)
+	Inject(span.SpanContext(), req.Header)
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(StatusError, strconv.Itoa(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// InstrumentClient wraps the client transport with a Transport, so its requests are traced. It has no effect if the client is already instrumented
+func InstrumentClient(client *http.Client) {
+	if _, ok := client.Transport.(*Transport); ok {
+		return
+	}
+	client.Transport = &Transport{Base: client.Transport}
+}
+
+// redactedUrl returns the request URL without the user info and the query, which can contain credentials (for example the OAuth2 codes)
+func redactedUrl(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	return u.String()
+}
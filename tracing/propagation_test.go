@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+		wantTs string
+		wantOk bool
+	}{
+		{
+			name:   "missing traceparent",
+			header: http.Header{},
+		},
+		{
+			name:   "invalid traceparent",
+			header: http.Header{"Traceparent": {"00-invalid"}},
+		},
+		{
+			name:   "traceparent and tracestate",
+			header: http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, "Tracestate": {"a=1", "b=2"}},
+			want:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantTs: "a=1,b=2",
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := Extract(tt.header)
+			if ok != tt.wantOk {
+				t.Fatalf("Extract() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if sc.Traceparent() != tt.want || sc.TraceState != tt.wantTs || !sc.Remote {
+				t.Errorf("Extract() got = %v %v, want %v %v", sc.Traceparent(), sc.TraceState, tt.want, tt.wantTs)
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client := server.Client()
+	InstrumentClient(client)
+	InstrumentClient(client)
+	if transport, ok := client.Transport.(*Transport); !ok {
+		t.Fatalf("InstrumentClient() transport got = %T, want *Transport", client.Transport)
+	} else if _, ok := transport.Base.(*Transport); ok {
+		t.Fatalf("InstrumentClient() the transport was wrapped twice")
+	}
+
+	// without a span in the context the request is sent as it is
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if gotHeader.Get(HeaderTraceparent) != "" {
+		t.Errorf("traceparent got = %v, want none", gotHeader.Get(HeaderTraceparent))
+	}
+
+	var exported []*Span
+	tracer := NewTracer(TracerConfig{Exporter: ExporterFunc(func(ctx context.Context, serviceName string, spans []*Span) error {
+		exported = append(exported, spans...)
+		return nil
+	})})
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	remote.TraceState = "vendor=value"
+	ctx, parent := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "GET /login", SpanKindServer)
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/token?code=secret", nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if req.Header.Get(HeaderTraceparent) != "" {
+		t.Errorf("the original request was modified")
+	}
+	parent.End()
+	tracer.Shutdown(context.Background())
+
+	if len(exported) != 2 {
+		t.Fatalf("exported spans got = %d, want 2", len(exported))
+	}
+	clientSpan := exported[0]
+	if clientSpan.Kind != SpanKindClient || clientSpan.Name != "HTTP GET" || clientSpan.ParentSpanID != parent.SpanContext().SpanID {
+		t.Errorf("client span got = %v %v, parent: %v", clientSpan.Kind, clientSpan.Name, clientSpan.ParentSpanID)
+	}
+	wantHeader := clientSpan.SpanContext().Traceparent()
+	if gotHeader.Get(HeaderTraceparent) != wantHeader || gotHeader.Get(HeaderTracestate) != "vendor=value" {
+		t.Errorf("propagated headers got = %v %v, want %v vendor=value", gotHeader.Get(HeaderTraceparent), gotHeader.Get(HeaderTracestate), wantHeader)
+	}
+	wantAttributes := map[string]any{
+		"http.request.method":       http.MethodGet,
+		"server.address":            "127.0.0.1",
+		"url.full":                  server.URL + "/token",
+		"http.response.status_code": http.StatusBadGateway,
+	}
+	if !reflect.DeepEqual(clientSpan.Attributes, wantAttributes) {
+		t.Errorf("client span attributes got = %v, want %v", clientSpan.Attributes, wantAttributes)
+	}
+	if clientSpan.StatusCode != StatusError {
+		t.Errorf("client span status got = %v, want error", clientSpan.StatusCode)
+	}
+}
@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"github.com/ixtendio/gofre/logging"
+	"sync"
+	"time"
+)
+
+// TracerConfig contains the Tracer settings.
+// If no custom values are provided for the struct fields then, the default one are used
+type TracerConfig struct {
+	// the name of the service, exported as the service.name resource attribute. Default: "gofre"
+	ServiceName string
+	// the exporter of the ended spans. If nil, the spans are not exported, but the trace context is still propagated. Default: nil
+	Exporter Exporter
+	// the ratio, between 0 and 1, of the new traces that are sampled (exported). The traces started by other services
+	// keep their sampling decision. Default: 1 (all the traces are sampled)
+	SampleRate float64
+	// the maximum number of spans exported at once. Default: 128
+	BatchSize int
+	// the interval between the exports of the ended spans. Default: 5s
+	FlushInterval time.Duration
+	// the logger of the export errors. Default: logging.DefaultLogger
+	Logger logging.Logger
+}
+
+func (c *TracerConfig) setDefaults() {
+	if c.ServiceName == "" {
+		c.ServiceName = "gofre"
+	}
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		c.SampleRate = 1
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 128
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 5 * time.Second
+	}
+	if c.Logger == nil {
+		c.Logger = logging.DefaultLogger
+	}
+}
+
+// A Tracer starts the spans and exports them, in batches, when they end
+type Tracer struct {
+	config       TracerConfig
+	mu           sync.Mutex
+	exportMu     sync.Mutex
+	batch        []*Span
+	flushCh      chan struct{}
+	done         chan struct{}
+	stopped      chan struct{}
+	shutdownOnce sync.Once
+}
+
+// NewTracer creates a Tracer. If an exporter is configured, the ended spans are exported in background until Shutdown is called
+func NewTracer(config TracerConfig) *Tracer {
+	config.setDefaults()
+	t := &Tracer{
+		config:  config,
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if config.Exporter != nil {
+		go t.exportLoop()
+	} else {
+		close(t.stopped)
+	}
+	return t
+}
+
+// ServiceName returns the name of the traced service
+func (t *Tracer) ServiceName() string {
+	return t.config.ServiceName
+}
+
+// Start starts a span, child of the current span or of the remote parent SpanContext from the context.Context, or the root span of a new trace.
+// The returned context.Context contains the new span
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	var parentSpanID SpanID
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+		parentSpanID = parent.SpanID
+	} else {
+		sc.TraceID = newTraceID()
+		if t.config.SampleRate >= 1 || sampler() < t.config.SampleRate {
+			sc.Flags = flagSampled
+		}
+	}
+	span := &Span{
+		tracer:       t,
+		spanContext:  sc,
+		ParentSpanID: parentSpanID,
+		Name:         name,
+		Kind:         kind,
+		StartTime:    timeNow(),
+	}
+	return context.WithValue(ctx, SpanCtxKey, span), span
+}
+
+// enqueue adds an ended span to the export batch
+func (t *Tracer) enqueue(span *Span) {
+	if t.config.Exporter == nil {
+		return
+	}
+	t.mu.Lock()
+	t.batch = append(t.batch, span)
+	full := len(t.batch) >= t.config.BatchSize
+	t.mu.Unlock()
+	if full {
+		select {
+		case t.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) exportLoop() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		case <-t.flushCh:
+		}
+		if err := t.Flush(context.Background()); err != nil {
+			t.config.Logger.Error("failed to export the spans", logging.FieldError, err)
+		}
+	}
+}
+
+// Flush exports the ended spans
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t.config.Exporter == nil {
+		return nil
+	}
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+	for {
+		t.mu.Lock()
+		n := len(t.batch)
+		if n > t.config.BatchSize {
+			n = t.config.BatchSize
+		}
+		spans := t.batch[:n:n]
+		t.batch = t.batch[n:]
+		t.mu.Unlock()
+		if len(spans) == 0 {
+			return nil
+		}
+		if err := t.config.Exporter.Export(ctx, t.config.ServiceName, spans); err != nil {
+			return err
+		}
+	}
+}
+
+// Shutdown stops the background export and exports the remaining spans
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.shutdownOnce.Do(func() {
+		close(t.done)
+	})
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.Flush(ctx)
+}
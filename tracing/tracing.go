@@ -0,0 +1,299 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ctxKey int
+
+// SpanCtxKey is used to pass the current *Span to the request context.Context
+const SpanCtxKey ctxKey = 1
+
+// remoteSpanContextCtxKey is used to pass the remote parent SpanContext, extracted from the request headers, to the context.Context
+const remoteSpanContextCtxKey ctxKey = 2
+
+// The W3C trace context headers
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+const traceparentVersion = "00"
+const flagSampled byte = 0x01
+
+var ErrInvalidTraceparent = errors.New("invalid traceparent header")
+
+// GetSpanFromContext returns the current *Span from the context.Context or nil if missing. The *Span methods can be called on a nil *Span
+func GetSpanFromContext(ctx context.Context) *Span {
+	if span, ok := ctx.Value(SpanCtxKey).(*Span); ok {
+		return span
+	}
+	return nil
+}
+
+// ContextWithRemoteSpanContext returns a copy of the context.Context with the remote parent SpanContext, used as the parent of the next started span
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanContextCtxKey, sc)
+}
+
+// SpanContextFromContext returns the SpanContext of the current span or, if missing, the remote parent SpanContext
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := GetSpanFromContext(ctx); span != nil {
+		return span.spanContext
+	}
+	if sc, ok := ctx.Value(remoteSpanContextCtxKey).(SpanContext); ok {
+		return sc
+	}
+	return SpanContext{}
+}
+
+// StartSpan starts a child span of the current span from the context.Context, with the same Tracer. If there is no current span,
+// the returned span is nil (and its methods are no-ops), so the handlers can create child spans even if the tracing is not enabled
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := GetSpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, kind)
+}
+
+// A TraceID is the 16 bytes identifier of a trace
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// A SpanID is the 8 bytes identifier of a span
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext contains the span identity propagated to the other services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// the trace flags, where the first bit is the sampled flag
+	Flags byte
+	// the vendor specific trace data, propagated as it is
+	TraceState string
+	// true if the SpanContext was extracted from the request headers
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&flagSampled == flagSampled
+}
+
+// Traceparent returns the W3C traceparent header value, for example 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("%s-%s-%s-%02x", traceparentVersion, sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value. The future versions are parsed as the current version
+func ParseTraceparent(traceparent string) (SpanContext, error) {
+	traceparent = strings.TrimSpace(traceparent)
+	if len(traceparent) < 55 || (len(traceparent) > 55 && traceparent[55] != '-') {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	version := traceparent[0:2]
+	if version == "ff" || (version == traceparentVersion && len(traceparent) != 55) ||
+		traceparent[2] != '-' || traceparent[35] != '-' || traceparent[52] != '-' {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeLowerHex(version, make([]byte, 1)) ||
+		!decodeLowerHex(traceparent[3:35], sc.TraceID[:]) ||
+		!decodeLowerHex(traceparent[36:52], sc.SpanID[:]) ||
+		!decodeLowerHex(traceparent[53:55], flags[:]) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Flags = flags[0]
+	sc.Remote = true
+	return sc, nil
+}
+
+// decodeLowerHex decodes a lowercase hex string, as required by the W3C trace context
+func decodeLowerHex(src string, dst []byte) bool {
+	if strings.ToLower(src) != src {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(src))
+	return err == nil
+}
+
+// A SpanKind describes the relationship between the span and its parent and children
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// A StatusCode is the status of a span operation
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOk
+	StatusError
+)
+
+func (s StatusCode) String() string {
+	switch s {
+	case StatusOk:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
+
+// A Span is a timed operation of a trace. The exported fields should be read only after the span has ended, for example by the exporters
+type Span struct {
+	mu            sync.Mutex
+	tracer        *Tracer
+	spanContext   SpanContext
+	ParentSpanID  SpanID
+	Name          string
+	Kind          SpanKind
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]any
+	StatusCode    StatusCode
+	StatusMessage string
+	ended         bool
+}
+
+// SpanContext returns the span identity
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.spanContext
+}
+
+// SetAttribute sets an attribute, for example "http.route". The value should be a string, a bool, an integer or a float
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.Attributes == nil {
+		s.Attributes = map[string]any{}
+	}
+	s.Attributes[key] = value
+}
+
+// SetStatus sets the status of the span operation
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.StatusCode = code
+	s.StatusMessage = message
+}
+
+// RecordError sets the error status and the "exception.message" attribute
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.SetAttribute("exception.message", err.Error())
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End ends the span and, if sampled, sends it to the exporter. The next calls have no effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = timeNow()
+	s.mu.Unlock()
+	if s.spanContext.IsSampled() {
+		s.tracer.enqueue(s)
+	}
+}
+
+var timeNow = time.Now
+
+// the sampling random number generator and the ids generator, replaced in tests
+var (
+	sampler     = mathrand.Float64
+	readRandom  = rand.Read
+	randomMutex sync.Mutex
+)
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		randomMutex.Lock()
+		readRandom(id[:])
+		randomMutex.Unlock()
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		randomMutex.Lock()
+		readRandom(id[:])
+		randomMutex.Unlock()
+	}
+	return id
+}
@@ -0,0 +1,228 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceId := TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanId := SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+	tests := []struct {
+		name        string
+		traceparent string
+		want        SpanContext
+		wantErr     bool
+	}{
+		{
+			name:        "sampled",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:        SpanContext{TraceID: traceId, SpanID: spanId, Flags: 1, Remote: true},
+		},
+		{
+			name:        "not sampled",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			want:        SpanContext{TraceID: traceId, SpanID: spanId, Flags: 0, Remote: true},
+		},
+		{
+			name:        "future version with extra fields",
+			traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			want:        SpanContext{TraceID: traceId, SpanID: spanId, Flags: 1, Remote: true},
+		},
+		{
+			name:        "version 00 with extra fields",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			wantErr:     true,
+		},
+		{
+			name:        "invalid version",
+			traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantErr:     true,
+		},
+		{
+			name:        "uppercase hex",
+			traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+			wantErr:     true,
+		},
+		{
+			name:        "zero trace id",
+			traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			wantErr:     true,
+		},
+		{
+			name:        "zero span id",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			wantErr:     true,
+		},
+		{
+			name:        "too short",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			wantErr:     true,
+		},
+		{
+			name:        "wrong separator",
+			traceparent: "00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceparent(tt.traceparent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTraceparent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTraceparent() got = %v, want %v", got, tt.want)
+			}
+			if err == nil && tt.traceparent[:2] == traceparentVersion && got.Traceparent() != tt.traceparent {
+				t.Errorf("Traceparent() got = %v, want %v", got.Traceparent(), tt.traceparent)
+			}
+		})
+	}
+}
+
+func TestTracer_Start(t *testing.T) {
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	remote.TraceState = "vendor=value"
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		sampleRate    float64
+		sampler       float64
+		wantParent    SpanContext
+		wantSampled   bool
+		wantRootTrace bool
+	}{
+		{
+			name:          "root span sampled",
+			ctx:           context.Background(),
+			wantSampled:   true,
+			wantRootTrace: true,
+		},
+		{
+			name:          "root span not sampled",
+			ctx:           context.Background(),
+			sampleRate:    0.25,
+			sampler:       0.5,
+			wantSampled:   false,
+			wantRootTrace: true,
+		},
+		{
+			name:          "root span sampled by rate",
+			ctx:           context.Background(),
+			sampleRate:    0.25,
+			sampler:       0.1,
+			wantSampled:   true,
+			wantRootTrace: true,
+		},
+		{
+			name:        "remote parent keeps the sampling decision",
+			ctx:         ContextWithRemoteSpanContext(context.Background(), remote),
+			wantParent:  remote,
+			wantSampled: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalSampler := sampler
+			defer func() { sampler = originalSampler }()
+			sampler = func() float64 { return tt.sampler }
+
+			tracer := NewTracer(TracerConfig{SampleRate: tt.sampleRate})
+			ctx, span := tracer.Start(tt.ctx, "span", SpanKindServer)
+			if GetSpanFromContext(ctx) != span {
+				t.Fatalf("GetSpanFromContext() got = %v, want %v", GetSpanFromContext(ctx), span)
+			}
+			sc := span.SpanContext()
+			if !sc.IsValid() || sc.Remote {
+				t.Errorf("SpanContext() got = %v, want a valid local span context", sc)
+			}
+			if sc.IsSampled() != tt.wantSampled {
+				t.Errorf("IsSampled() got = %v, want %v", sc.IsSampled(), tt.wantSampled)
+			}
+			if tt.wantRootTrace {
+				if span.ParentSpanID.IsValid() {
+					t.Errorf("ParentSpanID got = %v, want none", span.ParentSpanID)
+				}
+			} else {
+				if sc.TraceID != tt.wantParent.TraceID || span.ParentSpanID != tt.wantParent.SpanID || sc.TraceState != tt.wantParent.TraceState {
+					t.Errorf("SpanContext() got = %v, parent: %v, want child of %v", sc, span.ParentSpanID, tt.wantParent)
+				}
+			}
+		})
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	ctx, span := StartSpan(context.Background(), "child", SpanKindInternal)
+	if span != nil || GetSpanFromContext(ctx) != nil {
+		t.Fatalf("StartSpan() without a parent got = %v, want nil", span)
+	}
+	// the nil span methods are no-ops
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("an error"))
+	span.End()
+
+	var exported []*Span
+	tracer := NewTracer(TracerConfig{Exporter: ExporterFunc(func(ctx context.Context, serviceName string, spans []*Span) error {
+		exported = append(exported, spans...)
+		return nil
+	})})
+	ctx, parent := tracer.Start(context.Background(), "parent", SpanKindServer)
+	_, child := StartSpan(ctx, "child", SpanKindInternal)
+	child.SetAttribute("db.system", "postgresql")
+	child.RecordError(errors.New("connection refused"))
+	child.End()
+	child.SetAttribute("ignored", true)
+	parent.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if len(exported) != 2 {
+		t.Fatalf("exported spans got = %d, want 2", len(exported))
+	}
+	if exported[0] != child || exported[1] != parent {
+		t.Fatalf("exported spans got = %v, want the child and the parent", exported)
+	}
+	if child.SpanContext().TraceID != parent.SpanContext().TraceID || child.ParentSpanID != parent.SpanContext().SpanID {
+		t.Errorf("child span got = %v, want child of %v", child.SpanContext(), parent.SpanContext())
+	}
+	wantAttributes := map[string]any{"db.system": "postgresql", "exception.message": "connection refused"}
+	if !reflect.DeepEqual(child.Attributes, wantAttributes) {
+		t.Errorf("child span attributes got = %v, want %v", child.Attributes, wantAttributes)
+	}
+	if child.StatusCode != StatusError || child.StatusMessage != "connection refused" {
+		t.Errorf("child span status got = %v %v, want error", child.StatusCode, child.StatusMessage)
+	}
+	if child.EndTime.Before(child.StartTime) {
+		t.Errorf("child span end time got = %v, want after %v", child.EndTime, child.StartTime)
+	}
+}
+
+func TestTracer_Flush(t *testing.T) {
+	var batches [][]*Span
+	tracer := NewTracer(TracerConfig{
+		BatchSize: 2,
+		Exporter: ExporterFunc(func(ctx context.Context, serviceName string, spans []*Span) error {
+			batches = append(batches, spans)
+			return nil
+		}),
+	})
+	// stop the background export, so the spans are exported only by Flush
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "span", SpanKindInternal)
+		span.End()
+	}
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("Flush() batches got = %v, want 2 batches with 2 and 1 spans", batches)
+	}
+}